@@ -1 +1,2 @@
-code-generator/generate-groups.sh all finupgroup.com/decision/traincrd/pkg/client     finupgroup.com/decision/traincrd/pkg apis:v1  --go-header-file  code-generator/hack/boilerplate.go.txt
\ No newline at end of file
+code-generator/generate-groups.sh all finupgroup.com/decision/traincrd/pkg/client     finupgroup.com/decision/traincrd/pkg apis:v1,v1beta2  --go-header-file  code-generator/hack/boilerplate.go.txt
+go run ./hack/crdgen -apis pkg/apis -output-dir artifacts
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+const header = "# Code generated by hack/crdgen. DO NOT EDIT.\n"
+
+type crd struct {
+	APIVersion string  `json:"apiVersion"`
+	Kind       string  `json:"kind"`
+	Metadata   crdMeta `json:"metadata"`
+	Spec       crdSpec `json:"spec"`
+}
+
+type crdMeta struct {
+	Name string `json:"name"`
+}
+
+type crdSpec struct {
+	Group      string         `json:"group"`
+	Names      crdNames       `json:"names"`
+	Scope      string         `json:"scope"`
+	Versions   []crdVersion   `json:"versions"`
+	Conversion *crdConversion `json:"conversion,omitempty"`
+}
+
+type crdConversion struct {
+	Strategy string            `json:"strategy"`
+	Webhook  *crdWebhookConfig `json:"webhook,omitempty"`
+}
+
+type crdWebhookConfig struct {
+	ClientConfig             crdClientConfig `json:"clientConfig"`
+	ConversionReviewVersions []string        `json:"conversionReviewVersions"`
+}
+
+type crdClientConfig struct {
+	Service crdService `json:"service"`
+}
+
+type crdService struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Path      string `json:"path"`
+}
+
+type crdNames struct {
+	Kind       string   `json:"kind"`
+	ListKind   string   `json:"listKind"`
+	Plural     string   `json:"plural"`
+	Singular   string   `json:"singular"`
+	ShortNames []string `json:"shortNames,omitempty"`
+}
+
+type crdVersion struct {
+	Name                     string           `json:"name"`
+	Served                   bool             `json:"served"`
+	Storage                  bool             `json:"storage"`
+	Schema                   crdValidation    `json:"schema"`
+	Subresources             *crdSubresources `json:"subresources,omitempty"`
+	AdditionalPrinterColumns []crdPrintColumn `json:"additionalPrinterColumns,omitempty"`
+}
+
+type crdValidation struct {
+	OpenAPIV3Schema *jsonSchema `json:"openAPIV3Schema"`
+}
+
+type crdSubresources struct {
+	Status *struct{} `json:"status,omitempty"`
+	Scale  *crdScale `json:"scale,omitempty"`
+}
+
+type crdScale struct {
+	SpecReplicasPath   string `json:"specReplicasPath"`
+	StatusReplicasPath string `json:"statusReplicasPath"`
+	LabelSelectorPath  string `json:"labelSelectorPath,omitempty"`
+}
+
+type crdPrintColumn struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	JSONPath string `json:"jsonPath"`
+}
+
+// generate parses every version package under apisDir and returns the CRD manifests keyed by file name
+func generate(apisDir string) (map[string][]byte, error) {
+	entries, err := ioutil.ReadDir(apisDir)
+	if err != nil {
+		return nil, err
+	}
+
+	crds := map[string]*crd{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		version := entry.Name()
+		pkg, err := parsePackage(filepath.Join(apisDir, version))
+		if err != nil {
+			return nil, err
+		}
+		if err := pkg.addVersion(crds, version); err != nil {
+			return nil, err
+		}
+	}
+
+	manifests := map[string][]byte{}
+	for kind, c := range crds {
+		sort.Slice(c.Spec.Versions, func(i, j int) bool {
+			return c.Spec.Versions[i].Name < c.Spec.Versions[j].Name
+		})
+		data, err := yaml.Marshal(c)
+		if err != nil {
+			return nil, err
+		}
+		manifests[strings.ToLower(kind)+".yaml"] = append([]byte(header), data...)
+	}
+	return manifests, nil
+}
+
+func parsePackage(dir string) (*pkgInfo, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go") && !strings.HasPrefix(fi.Name(), "zz_generated")
+	}, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &pkgInfo{types: map[string]*typeDecl{}}
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			imports := map[string]string{}
+			for _, imp := range file.Imports {
+				path, _ := strconv.Unquote(imp.Path.Value)
+				name := filepath.Base(path)
+				if imp.Name != nil {
+					name = imp.Name.Name
+				}
+				imports[name] = path
+			}
+
+			// +groupName sits in the package doc, possibly separated from the package clause
+			for _, group := range file.Comments {
+				if group.End() > file.Package {
+					break
+				}
+				if m, ok := findMarker(parseMarkers(group), "groupName"); ok {
+					info.group = m.args[""]
+				}
+			}
+
+			// markers may sit in a comment group separated from the declaration by a blank line,
+			// so every comment between the previous declaration and this one belongs to it
+			prevEnd := file.Name.End()
+			for _, decl := range file.Decls {
+				gen, ok := decl.(*ast.GenDecl)
+				if !ok || gen.Tok != token.TYPE {
+					prevEnd = decl.End()
+					continue
+				}
+				var groups []*ast.CommentGroup
+				for _, group := range file.Comments {
+					if group.Pos() > prevEnd && group.End() < gen.Pos() {
+						groups = append(groups, group)
+					}
+				}
+				for _, spec := range gen.Specs {
+					ts := spec.(*ast.TypeSpec)
+					doc := gen.Doc
+					if ts.Doc != nil {
+						doc = ts.Doc
+					}
+					info.types[ts.Name.Name] = &typeDecl{
+						name:    ts.Name.Name,
+						spec:    ts,
+						doc:     doc,
+						markers: parseMarkers(append(groups, ts.Doc)...),
+						imports: imports,
+					}
+				}
+				prevEnd = gen.End()
+			}
+		}
+	}
+	if info.group == "" {
+		return nil, fmt.Errorf("%s: no +groupName marker", dir)
+	}
+	return info, nil
+}
+
+// addVersion adds the version of every resource type in the package to crds
+func (p *pkgInfo) addVersion(crds map[string]*crd, version string) error {
+	names := make([]string, 0, len(p.types))
+	for name := range p.types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, kind := range names {
+		decl := p.types[kind]
+		resource, ok := findMarker(decl.markers, "kubebuilder:resource")
+		if !ok {
+			continue
+		}
+
+		schema, err := p.schemaForDecl(decl)
+		if err != nil {
+			return err
+		}
+		schema.Description = docText(decl.doc)
+
+		c, ok := crds[kind]
+		if !ok {
+			plural := resource.args["path"]
+			if plural == "" {
+				plural = strings.ToLower(kind) + "s"
+			}
+			scope := resource.args["scope"]
+			if scope == "" {
+				scope = "Namespaced"
+			}
+			var shortNames []string
+			if resource.args["shortName"] != "" {
+				shortNames = strings.Split(resource.args["shortName"], ";")
+			}
+			c = &crd{
+				APIVersion: "apiextensions.k8s.io/v1",
+				Kind:       "CustomResourceDefinition",
+				Metadata:   crdMeta{Name: plural + "." + p.group},
+				Spec: crdSpec{
+					Group: p.group,
+					Names: crdNames{
+						Kind:       kind,
+						ListKind:   kind + "List",
+						Plural:     plural,
+						Singular:   strings.ToLower(kind),
+						ShortNames: shortNames,
+					},
+					Scope: scope,
+				},
+			}
+			crds[kind] = c
+		}
+
+		v := crdVersion{
+			Name:    version,
+			Served:  true,
+			Storage: hasMarker(decl.markers, "kubebuilder:storageversion") || len(c.Spec.Versions) == 0,
+			Schema:  crdValidation{OpenAPIV3Schema: schema},
+		}
+		if hasMarker(decl.markers, "kubebuilder:subresource:status") || hasMarker(decl.markers, "kubebuilder:subresource:scale") {
+			v.Subresources = &crdSubresources{}
+			if hasMarker(decl.markers, "kubebuilder:subresource:status") {
+				v.Subresources.Status = &struct{}{}
+			}
+			if scale, ok := findMarker(decl.markers, "kubebuilder:subresource:scale"); ok {
+				v.Subresources.Scale = &crdScale{
+					SpecReplicasPath:   scale.args["specpath"],
+					StatusReplicasPath: scale.args["statuspath"],
+					LabelSelectorPath:  scale.args["selectorpath"],
+				}
+			}
+		}
+		for _, column := range findMarkers(decl.markers, "kubebuilder:printcolumn") {
+			v.AdditionalPrinterColumns = append(v.AdditionalPrinterColumns, crdPrintColumn{
+				Name:     column.args["name"],
+				Type:     column.args["type"],
+				JSONPath: column.args["JSONPath"],
+			})
+		}
+
+		// +crdgen:conversion:service=<name>,namespace=<namespace>,path=<path> on any version
+		// converts between the versions with the webhook behind that Service
+		if conversion, ok := findMarker(decl.markers, "crdgen:conversion"); ok {
+			c.Spec.Conversion = &crdConversion{
+				Strategy: "Webhook",
+				Webhook: &crdWebhookConfig{
+					ClientConfig: crdClientConfig{Service: crdService{
+						Name:      conversion.args["service"],
+						Namespace: conversion.args["namespace"],
+						Path:      conversion.args["path"],
+					}},
+					ConversionReviewVersions: []string{"v1"},
+				},
+			}
+		}
+
+		// a storage version marker elsewhere wins over the first version found
+		if v.Storage {
+			for i := range c.Spec.Versions {
+				c.Spec.Versions[i].Storage = false
+			}
+		}
+		c.Spec.Versions = append(c.Spec.Versions, v)
+	}
+	return nil
+}
+
+func hasMarker(markers []marker, name string) bool {
+	_, ok := findMarker(markers, name)
+	return ok
+}
@@ -0,0 +1,36 @@
+// crdgen generates apiextensions.k8s.io/v1 CustomResourceDefinitions from the Go types
+// under pkg/apis. Every type carrying a +kubebuilder:resource marker becomes one CRD written
+// to <output-dir>/<lowercase kind>.yaml, with one entry per API version found.
+//
+//	go run ./hack/crdgen -apis pkg/apis -output-dir artifacts
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"k8s.io/klog"
+)
+
+func main() {
+	apisDir := flag.String("apis", "pkg/apis", "directory holding one sub directory per API version")
+	outputDir := flag.String("output-dir", "artifacts", "directory the CRD manifests are written to")
+	klog.InitFlags(nil)
+	flag.Parse()
+
+	manifests, err := generate(*apisDir)
+	if err != nil {
+		klog.Fatalf("generate CRDs: %v", err)
+	}
+
+	for name, data := range manifests {
+		path := filepath.Join(*outputDir, name)
+		if err := ioutil.WriteFile(path, data, 0644); err != nil {
+			klog.Fatalf("write %s: %v", path, err)
+		}
+		klog.Infof("wrote %s", path)
+	}
+	os.Exit(0)
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// TestManifestsUpToDate fails when artifacts/ no longer matches pkg/apis; run
+// `go run ./hack/crdgen` from the repository root to regenerate.
+func TestManifestsUpToDate(t *testing.T) {
+	manifests, err := generate(filepath.Join("..", "..", "pkg", "apis"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifests) == 0 {
+		t.Fatal("no CRD generated")
+	}
+
+	for name, want := range manifests {
+		path := filepath.Join("..", "..", "artifacts", name)
+		got, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Errorf("%s: %v", path, err)
+			continue
+		}
+		if string(got) != string(want) {
+			t.Errorf("%s is out of date with pkg/apis, regenerate it with go run ./hack/crdgen", path)
+		}
+	}
+}
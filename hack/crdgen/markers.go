@@ -0,0 +1,127 @@
+package main
+
+import (
+	"go/ast"
+	"strings"
+)
+
+// marker is one "+name" or "+name=value" or "+name:key=value,..." comment line.
+// The value of a "+name=value" marker is stored under the empty key.
+type marker struct {
+	name string
+	args map[string]string
+}
+
+// argMarkers take a key=value argument list instead of a single value
+var argMarkers = map[string]bool{
+	"kubebuilder:resource":          true,
+	"kubebuilder:printcolumn":       true,
+	"kubebuilder:subresource:scale": true,
+	"crdgen:conversion":             true,
+}
+
+// parseMarkers collects the markers from the given comment groups
+func parseMarkers(groups ...*ast.CommentGroup) []marker {
+	var markers []marker
+	for _, group := range groups {
+		if group == nil {
+			continue
+		}
+		for _, c := range group.List {
+			text := strings.TrimSpace(strings.TrimPrefix(c.Text, "//"))
+			if !strings.HasPrefix(text, "+") {
+				continue
+			}
+			markers = append(markers, parseMarker(text[1:]))
+		}
+	}
+	return markers
+}
+
+func parseMarker(text string) marker {
+	m := marker{name: text, args: map[string]string{}}
+
+	eq := strings.Index(text, "=")
+	if eq < 0 {
+		return m
+	}
+
+	head := text[:eq]
+	if colon := strings.LastIndex(head, ":"); colon >= 0 && argMarkers[head[:colon]] {
+		m.name = head[:colon]
+		for _, arg := range splitArgs(text[colon+1:]) {
+			kv := strings.SplitN(arg, "=", 2)
+			if len(kv) == 2 {
+				m.args[kv[0]] = unquote(kv[1])
+			}
+		}
+		return m
+	}
+
+	m.name = head
+	m.args[""] = unquote(text[eq+1:])
+	return m
+}
+
+// splitArgs splits on commas outside of quotes and backticks
+func splitArgs(s string) []string {
+	var args []string
+	var quote rune
+	start := 0
+	for i, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '`':
+			quote = r
+		case r == ',':
+			args = append(args, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(args, s[start:])
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '`') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+func findMarker(markers []marker, name string) (marker, bool) {
+	for _, m := range markers {
+		if m.name == name {
+			return m, true
+		}
+	}
+	return marker{}, false
+}
+
+func findMarkers(markers []marker, name string) []marker {
+	var found []marker
+	for _, m := range markers {
+		if m.name == name {
+			found = append(found, m)
+		}
+	}
+	return found
+}
+
+// docText returns the comment text without markers, joined into one description
+func docText(group *ast.CommentGroup) string {
+	if group == nil {
+		return ""
+	}
+	var lines []string
+	for _, c := range group.List {
+		text := strings.TrimSpace(strings.TrimPrefix(c.Text, "//"))
+		if text == "" || strings.HasPrefix(text, "+") {
+			continue
+		}
+		lines = append(lines, text)
+	}
+	return strings.Join(lines, " ")
+}
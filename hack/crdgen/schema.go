@@ -0,0 +1,307 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// jsonSchema is the subset of apiextensions.k8s.io/v1 JSONSchemaProps a structural schema needs
+type jsonSchema struct {
+	Description            string                 `json:"description,omitempty"`
+	Type                   string                 `json:"type,omitempty"`
+	Format                 string                 `json:"format,omitempty"`
+	Enum                   []interface{}          `json:"enum,omitempty"`
+	Minimum                *float64               `json:"minimum,omitempty"`
+	Maximum                *float64               `json:"maximum,omitempty"`
+	Pattern                string                 `json:"pattern,omitempty"`
+	Items                  *jsonSchema            `json:"items,omitempty"`
+	Properties             map[string]*jsonSchema `json:"properties,omitempty"`
+	AdditionalProperties   *jsonSchema            `json:"additionalProperties,omitempty"`
+	Required               []string               `json:"required,omitempty"`
+	XIntOrString           bool                   `json:"x-kubernetes-int-or-string,omitempty"`
+	XPreserveUnknownFields *bool                  `json:"x-kubernetes-preserve-unknown-fields,omitempty"`
+}
+
+// externalTypes are schemas for types imported from other packages, keyed by "importpath.Name".
+// Types missing here are emitted as objects preserving unknown fields.
+var externalTypes = map[string]func() *jsonSchema{
+	"k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta": func() *jsonSchema {
+		return &jsonSchema{Type: "object"}
+	},
+	"k8s.io/apimachinery/pkg/apis/meta/v1.Time": func() *jsonSchema {
+		return &jsonSchema{Type: "string", Format: "date-time"}
+	},
+	"k8s.io/apimachinery/pkg/apis/meta/v1.Duration": func() *jsonSchema {
+		return &jsonSchema{Type: "string"}
+	},
+	"k8s.io/apimachinery/pkg/api/resource.Quantity": func() *jsonSchema {
+		return &jsonSchema{XIntOrString: true, Pattern: quantityPattern}
+	},
+	"k8s.io/apimachinery/pkg/util/intstr.IntOrString": func() *jsonSchema {
+		return &jsonSchema{XIntOrString: true}
+	},
+	"k8s.io/api/core/v1.ConditionStatus": func() *jsonSchema {
+		return &jsonSchema{Type: "string"}
+	},
+	"k8s.io/api/core/v1.ResourceRequirements": func() *jsonSchema {
+		quantities := func() *jsonSchema {
+			return &jsonSchema{Type: "object", AdditionalProperties: &jsonSchema{XIntOrString: true, Pattern: quantityPattern}}
+		}
+		return &jsonSchema{
+			Type: "object",
+			Properties: map[string]*jsonSchema{
+				"limits":   quantities(),
+				"requests": quantities(),
+			},
+		}
+	},
+	"k8s.io/api/core/v1.EnvVar": func() *jsonSchema {
+		preserve := true
+		return &jsonSchema{
+			Type: "object",
+			Properties: map[string]*jsonSchema{
+				"name":      {Type: "string"},
+				"value":     {Type: "string"},
+				"valueFrom": {Type: "object", XPreserveUnknownFields: &preserve},
+			},
+			Required: []string{"name"},
+		}
+	},
+	"k8s.io/api/core/v1.ContainerPort": func() *jsonSchema {
+		return &jsonSchema{
+			Type: "object",
+			Properties: map[string]*jsonSchema{
+				"name":          {Type: "string"},
+				"containerPort": {Type: "integer", Format: "int32"},
+				"hostPort":      {Type: "integer", Format: "int32"},
+				"hostIP":        {Type: "string"},
+				"protocol":      {Type: "string"},
+			},
+			Required: []string{"containerPort"},
+		}
+	},
+	"k8s.io/api/core/v1.TypedLocalObjectReference": func() *jsonSchema {
+		return &jsonSchema{
+			Type: "object",
+			Properties: map[string]*jsonSchema{
+				"apiGroup": {Type: "string"},
+				"kind":     {Type: "string"},
+				"name":     {Type: "string"},
+			},
+			Required: []string{"kind", "name"},
+		}
+	},
+}
+
+const quantityPattern = `^(\+|-)?(([0-9]+(\.[0-9]*)?)|(\.[0-9]+))(([KMGTPE]i)|[numkMGTPE]|([eE](\+|-)?(([0-9]+(\.[0-9]*)?)|(\.[0-9]+))))?$`
+
+// typeMetaKey is inlined into every top-level kind and becomes apiVersion and kind
+const typeMetaKey = "k8s.io/apimachinery/pkg/apis/meta/v1.TypeMeta"
+
+// pkgInfo holds the parsed declarations of one API version package
+type pkgInfo struct {
+	types map[string]*typeDecl
+	group string
+}
+
+type typeDecl struct {
+	name    string
+	spec    *ast.TypeSpec
+	doc     *ast.CommentGroup
+	markers []marker
+	imports map[string]string
+}
+
+func (p *pkgInfo) schemaFor(expr ast.Expr, imports map[string]string) (*jsonSchema, error) {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return p.schemaFor(t.X, imports)
+	case *ast.ArrayType:
+		if ident, ok := t.Elt.(*ast.Ident); ok && ident.Name == "byte" {
+			return &jsonSchema{Type: "string", Format: "byte"}, nil
+		}
+		items, err := p.schemaFor(t.Elt, imports)
+		if err != nil {
+			return nil, err
+		}
+		return &jsonSchema{Type: "array", Items: items}, nil
+	case *ast.MapType:
+		values, err := p.schemaFor(t.Value, imports)
+		if err != nil {
+			return nil, err
+		}
+		return &jsonSchema{Type: "object", AdditionalProperties: values}, nil
+	case *ast.SelectorExpr:
+		pkg, ok := t.X.(*ast.Ident)
+		if !ok {
+			return nil, fmt.Errorf("unsupported selector %v", t)
+		}
+		key := imports[pkg.Name] + "." + t.Sel.Name
+		if schema, ok := externalTypes[key]; ok {
+			return schema(), nil
+		}
+		preserve := true
+		return &jsonSchema{Type: "object", XPreserveUnknownFields: &preserve}, nil
+	case *ast.Ident:
+		if schema := builtinSchema(t.Name); schema != nil {
+			return schema, nil
+		}
+		decl, ok := p.types[t.Name]
+		if !ok {
+			return nil, fmt.Errorf("unknown type %s", t.Name)
+		}
+		return p.schemaForDecl(decl)
+	default:
+		return nil, fmt.Errorf("unsupported type expression %T", expr)
+	}
+}
+
+func builtinSchema(name string) *jsonSchema {
+	switch name {
+	case "string":
+		return &jsonSchema{Type: "string"}
+	case "bool":
+		return &jsonSchema{Type: "boolean"}
+	case "int", "uint":
+		return &jsonSchema{Type: "integer"}
+	case "int32", "uint32":
+		return &jsonSchema{Type: "integer", Format: "int32"}
+	case "int64", "uint64":
+		return &jsonSchema{Type: "integer", Format: "int64"}
+	case "float32", "float64":
+		return &jsonSchema{Type: "number"}
+	}
+	return nil
+}
+
+func (p *pkgInfo) schemaForDecl(decl *typeDecl) (*jsonSchema, error) {
+	var schema *jsonSchema
+	var err error
+	if st, ok := decl.spec.Type.(*ast.StructType); ok {
+		schema, err = p.schemaForStruct(st, decl.imports)
+	} else {
+		schema, err = p.schemaFor(decl.spec.Type, decl.imports)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", decl.name, err)
+	}
+	if err := applyValidationMarkers(schema, decl.markers); err != nil {
+		return nil, fmt.Errorf("%s: %v", decl.name, err)
+	}
+	return schema, nil
+}
+
+func (p *pkgInfo) schemaForStruct(st *ast.StructType, imports map[string]string) (*jsonSchema, error) {
+	schema := &jsonSchema{Type: "object", Properties: map[string]*jsonSchema{}}
+
+	for _, field := range st.Fields.List {
+		name, inline, omitempty := jsonName(field)
+		if name == "-" {
+			continue
+		}
+
+		if inline {
+			if sel, ok := field.Type.(*ast.SelectorExpr); ok && imports[sel.X.(*ast.Ident).Name]+"."+sel.Sel.Name == typeMetaKey {
+				schema.Properties["apiVersion"] = &jsonSchema{Type: "string"}
+				schema.Properties["kind"] = &jsonSchema{Type: "string"}
+				continue
+			}
+			embedded, err := p.schemaFor(field.Type, imports)
+			if err != nil {
+				return nil, err
+			}
+			for k, v := range embedded.Properties {
+				schema.Properties[k] = v
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+
+		prop, err := p.schemaFor(field.Type, imports)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %v", name, err)
+		}
+		// copy so that markers on this field do not leak into other uses of the type
+		prop = copySchema(prop)
+		markers := parseMarkers(field.Doc)
+		if err := applyValidationMarkers(prop, markers); err != nil {
+			return nil, fmt.Errorf("field %s: %v", name, err)
+		}
+		if doc := docText(field.Doc); doc != "" {
+			prop.Description = doc
+		}
+		schema.Properties[name] = prop
+
+		_, optional := findMarker(markers, "optional")
+		if !omitempty && !optional {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+
+	if len(schema.Properties) == 0 {
+		schema.Properties = nil
+	}
+	return schema, nil
+}
+
+// jsonName returns the json field name, whether it is inlined and whether it has omitempty
+func jsonName(field *ast.Field) (string, bool, bool) {
+	tag := ""
+	if field.Tag != nil {
+		unquoted, _ := strconv.Unquote(field.Tag.Value)
+		tag = reflect.StructTag(unquoted).Get("json")
+	}
+	parts := strings.Split(tag, ",")
+	name := parts[0]
+	inline, omitempty := false, false
+	for _, opt := range parts[1:] {
+		switch opt {
+		case "inline":
+			inline = true
+		case "omitempty":
+			omitempty = true
+		}
+	}
+	if name == "" && !inline && len(field.Names) > 0 {
+		name = field.Names[0].Name
+	}
+	return name, inline, omitempty
+}
+
+func applyValidationMarkers(schema *jsonSchema, markers []marker) error {
+	for _, m := range markers {
+		value := m.args[""]
+		switch m.name {
+		case "kubebuilder:validation:Enum":
+			schema.Enum = nil
+			for _, v := range strings.Split(value, ";") {
+				schema.Enum = append(schema.Enum, v)
+			}
+		case "kubebuilder:validation:Minimum":
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("invalid Minimum %q", value)
+			}
+			schema.Minimum = &f
+		case "kubebuilder:validation:Maximum":
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("invalid Maximum %q", value)
+			}
+			schema.Maximum = &f
+		case "kubebuilder:validation:Pattern":
+			schema.Pattern = value
+		case "kubebuilder:validation:Format":
+			schema.Format = value
+		}
+	}
+	return nil
+}
+
+func copySchema(in *jsonSchema) *jsonSchema {
+	out := *in
+	return &out
+}
@@ -1,23 +1,63 @@
 package main
 
 import (
+	"context"
+	"flag"
+
 	clientsetTrain "finupgroup.com/decision/traincrd/pkg/client/clientset/versioned"
+	"finupgroup.com/decision/traincrd/pkg/clientconfig"
+	"finupgroup.com/decision/traincrd/pkg/config"
 	"finupgroup.com/decision/traincrd/pkg/executor"
+	"finupgroup.com/decision/traincrd/pkg/webhook"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/dynamic"
 	clientset "k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
 	"k8s.io/klog"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
+	"time"
 )
 
+var (
+	workers = flag.Int("workers", 2, "number of workers processing traincrd keys concurrently")
+	resync  = flag.Duration("resync", 5*time.Minute, "period of the full level-driven reconcile of every traincrd, 0 disables it")
+
+	shutdownTimeout = flag.Duration("shutdown-timeout", 20*time.Second, "how long in-flight reconciles may run after a termination signal, keep it below terminationGracePeriodSeconds")
+
+	webhookAddr = flag.String("webhook-addr", ":8443", "address the admission webhook server listens on")
+	tlsCertFile = flag.String("tls-cert-file", "", "x509 certificate for the admission webhook, the webhook is disabled when empty")
+	tlsKeyFile  = flag.String("tls-private-key-file", "", "x509 private key matching --tls-cert-file")
+	policyFile  = flag.String("defaulting-policy-file", "", "yaml file with the per-channel defaults applied by the mutating webhook and the namespaces allowed to use each channel")
+
+	configFile     = flag.String("config", "", "controller config file with the platform settings, the built-in defaults are used when empty")
+	profile        = flag.String("profile", os.Getenv("TRAIN_PROFILE"), "profile of the controller config to apply, overrides its profile field (env TRAIN_PROFILE)")
+	configInterval = flag.Duration("config-reload-interval", 10*time.Second, "how often --config is checked for changes, 0 disables reloading")
+
+	leaderElect     = flag.Bool("leader-elect", true, "elect a leader through a Lease before reconciling, required when running more than one replica")
+	leaderElectName = flag.String("leader-elect-name", "train-controller", "name of the Lease used for leader election")
+	leaderElectNS   = flag.String("leader-elect-namespace", "", "namespace of the Lease, defaults to $POD_NAMESPACE or default")
+	leaseDuration   = flag.Duration("leader-elect-lease-duration", 15*time.Second, "how long standby replicas wait before taking over a lease that is not renewed")
+	renewDeadline   = flag.Duration("leader-elect-renew-deadline", 10*time.Second, "how long the leader retries renewing the lease before giving up leadership")
+	retryPeriod     = flag.Duration("leader-elect-retry-period", 2*time.Second, "interval between attempts to acquire or renew the lease")
+)
 
 func main() {
 
 	klog.SetOutput(os.Stdout)
 	klog.InitFlags(nil)
+	clientOptions, err := clientconfig.NewOptions()
+	if err != nil {
+		klog.Fatalf("Error reading client settings: %v", err)
+	}
+	clientOptions.AddFlags(flag.CommandLine)
+	flag.Parse()
 
-	clientT, clientK8s, err := getk8sclient()
+	clientT, clientK8s, clientDynamic, err := getk8sclient(clientOptions)
 
 	if err != nil {
 		klog.Fatalf("Error building example clientset: %v", err)
@@ -25,32 +65,209 @@ func main() {
 
 
 	klog.Info("run executor with client")
-	exe := executor.New(clientT, clientK8s)
-	go exe.Run()
-
+	exe := executor.New(clientT, clientK8s, clientDynamic, *resync)
+	if *configFile != "" {
+		cfg, err := config.Load(*configFile, *profile)
+		if err != nil {
+			klog.Fatalf("Error loading controller config: %v", err)
+		}
+		klog.Infof("loaded controller config %s, profile %q", *configFile, cfg.Profile)
+		exe.SetConfig(cfg)
+	} else if *profile != "" {
+		cfg := config.Default()
+		if err := cfg.ApplyProfile(*profile); err != nil {
+			klog.Fatalf("Error applying profile: %v", err)
+		}
+		exe.SetConfig(cfg)
+	}
 
-	// use a channel to handle OS signals to terminate and gracefully shut
-	// down processing
-	sigTerm := make(chan os.Signal, 1)
+	// a termination signal cancels ctx: informers and the webhook server stop, the queue stops
+	// accepting work and in-flight reconciles get --shutdown-timeout to finish
+	ctx, cancel := context.WithCancel(context.Background())
+	sigTerm := make(chan os.Signal, 2)
 	signal.Notify(sigTerm, syscall.SIGTERM)
 	signal.Notify(sigTerm, syscall.SIGINT)
-	<-sigTerm
+	go func() {
+		<-sigTerm
+		klog.Info("received termination signal, shutting down")
+		cancel()
+		<-sigTerm
+		klog.Info("received second termination signal, exiting without draining")
+		klog.Flush()
+		os.Exit(1)
+	}()
+
+	if *configFile != "" && *configInterval > 0 {
+		go config.Watch(*configFile, *profile, *configInterval, ctx.Done(), exe.SetConfig)
+	}
+
+	if *tlsCertFile != "" {
+		policy := &webhook.DefaultDefaultingPolicy
+		if *policyFile != "" {
+			if policy, err = webhook.LoadDefaultingPolicy(*policyFile); err != nil {
+				klog.Fatalf("Error loading defaulting policy: %v", err)
+			}
+		}
+		server := webhook.NewServer(*webhookAddr, *tlsCertFile, *tlsKeyFile, policy)
+		go func() {
+			if err := server.Run(ctx.Done()); err != nil {
+				klog.Fatalf("admission webhook stopped: %v", err)
+			}
+		}()
+	}
+
+	if err := run(ctx, exe, clientK8s); err != nil {
+		klog.Errorf("shutdown did not complete: %v", err)
+		klog.Flush()
+		os.Exit(1)
+	}
+	klog.Info("shutdown complete")
+	klog.Flush()
 }
 
-func getk8sclient() (clientsetTrain.Interface, clientset.Interface, error){
-	// creates the in-cluster config
-	config, err := rest.InClusterConfig()
+// run reconciles until ctx is cancelled and returns an error when the executor did not drain in time.
+func run(ctx context.Context, exe *executor.Executor, client clientset.Interface) error {
+	if !*leaderElect {
+		return exe.Run(ctx, *workers, *shutdownTimeout)
+	}
+
+	// standby replicas keep the informer cache warm so a new leader starts reconciling at once
+	exe.StartInformer(ctx.Done())
+
+	// the Lease is released only once the executor drained, so a standby never reconciles next to us
+	electionCtx, cancelElection := context.WithCancel(context.Background())
+	defer cancelElection()
+
+	// client-go starts lead in a goroutine, it may be entered after shutdown began or even after RunOrDie
+	// returned. A term only starts while the gate is open, and run waits for every term that started.
+	var (
+		mu      sync.Mutex
+		closed  bool
+		leading bool
+		terms   sync.WaitGroup
+	)
+	drained := make(chan error, 1)
+	go func() {
+		<-ctx.Done()
+		mu.Lock()
+		defer mu.Unlock()
+		closed = true
+		if !leading {
+			cancelElection()
+		}
+	}()
+
+	runLeaderElection(electionCtx, client, func(leaderCtx context.Context) {
+		mu.Lock()
+		if closed {
+			mu.Unlock()
+			return
+		}
+		leading = true
+		terms.Add(1)
+		mu.Unlock()
+		defer terms.Done()
+
+		runCtx, stop := context.WithCancel(ctx)
+		defer stop()
+		go func() {
+			select {
+			case <-leaderCtx.Done():
+				stop()
+			case <-runCtx.Done():
+			}
+		}()
+		drained <- exe.Run(runCtx, *workers, *shutdownTimeout)
+		cancelElection()
+	})
+
+	mu.Lock()
+	closed = true
+	mu.Unlock()
+	terms.Wait()
+	select {
+	case err := <-drained:
+		return err
+	default:
+		return nil
+	}
+}
+
+// runLeaderElection blocks until ctx is cancelled, running lead while this replica holds the Lease.
+// The Lease is released on cancel so that a standby replica takes over without waiting for it to expire.
+func runLeaderElection(ctx context.Context, client clientset.Interface, lead func(context.Context)) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		klog.Fatalf("Error getting hostname: %v", err)
+	}
+	identity := hostname + "_" + string(uuid.NewUUID())
+
+	namespace := *leaderElectNS
+	if namespace == "" {
+		namespace = os.Getenv("POD_NAMESPACE")
+	}
+	if namespace == "" {
+		namespace = metav1.NamespaceDefault
+	}
+
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta:  metav1.ObjectMeta{Name: *leaderElectName, Namespace: namespace},
+		Client:     client.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{Identity: identity},
+	}
+
+	klog.Infof("leader election as %s on lease %s/%s", identity, namespace, *leaderElectName)
+	leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		LeaseDuration:   *leaseDuration,
+		RenewDeadline:   *renewDeadline,
+		RetryPeriod:     *retryPeriod,
+		ReleaseOnCancel: true,
+		Name:            *leaderElectName,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(leaderCtx context.Context) {
+				klog.Infof("%s started leading", identity)
+				lead(leaderCtx)
+			},
+			OnStoppedLeading: func() {
+				if ctx.Err() != nil {
+					klog.Infof("%s released the lease", identity)
+					return
+				}
+				// another replica may already be reconciling, exit rather than risk two writers
+				klog.Fatalf("%s lost the lease", identity)
+			},
+			OnNewLeader: func(current string) {
+				if current != identity {
+					klog.Infof("standing by, current leader is %s", current)
+				}
+			},
+		},
+	})
+}
+
+func getk8sclient(options *clientconfig.Options) (clientsetTrain.Interface, clientset.Interface, dynamic.Interface, error) {
+	// in-cluster config unless a kubeconfig, context or master is given
+	config, err := options.Config()
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	// creates the clientset
 	clientsetT, err := clientsetTrain.NewForConfig(config)
+	if err != nil {
+		return nil, nil, nil, err
+	}
 	clientsetK8, err := clientset.NewForConfig(config)
+	if err != nil {
+		return nil, nil, nil, err
+	}
 
+	// VolumeSnapshot has no typed client in our dependencies
+	clientDynamic, err := dynamic.NewForConfig(config)
 	if err != nil {
-		return nil,nil,  err
+		return nil, nil, nil, err
 	}
 
-	return clientsetT,clientsetK8, nil
-}
\ No newline at end of file
+	return clientsetT, clientsetK8, clientDynamic, nil
+}
@@ -49,6 +49,12 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
 		&Traincrd{},
 		&TraincrdList{},
+		&WorkspaceTemplate{},
+		&WorkspaceTemplateList{},
+		&ClusterTraincrd{},
+		&ClusterTraincrdList{},
+		&TraincrdSnapshot{},
+		&TraincrdSnapshotList{},
 	)
 
 	scheme.AddKnownTypes(SchemeGroupVersion,
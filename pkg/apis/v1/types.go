@@ -17,11 +17,25 @@ limitations under the License.
 package v1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // +genclient
+// +genclient:method=GetScale,verb=get,subresource=scale,result=k8s.io/api/autoscaling/v1.Scale
+// +genclient:method=UpdateScale,verb=update,subresource=scale,input=k8s.io/api/autoscaling/v1.Scale,result=k8s.io/api/autoscaling/v1.Scale
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:path=traincrds,scope=Namespaced,shortName=tc;train
+// +kubebuilder:storageversion
+// +crdgen:conversion:service=decisiontrain-webhook,namespace=default,path=/convert
+// +kubebuilder:subresource:status
+// +kubebuilder:subresource:scale:specpath=.spec.replicas,statuspath=.status.replicas,selectorpath=.status.selector
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Image",type=string,JSONPath=`.spec.image`
+// +kubebuilder:printcolumn:name="CPU",type=string,JSONPath=`.spec.cpu`
+// +kubebuilder:printcolumn:name="Memory",type=string,JSONPath=`.spec.memory`
+// +kubebuilder:printcolumn:name="URL",type=string,JSONPath=`.status.url`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // Train is a top-level type. A client is created for it.
 type Traincrd struct {
@@ -34,15 +48,138 @@ type Traincrd struct {
 }
 
 type TraincrdSpec struct {
-	Image     string `json:"image"`
-	Cpu string `json:"cpu"`
-	Memory string `json:"memory"`
-	ReqCpu string `json:"reqcpu,omitempty"`  //如果
+	// Image, Cpu and Memory may only be left out when spec.profile provides them.
+	Image     string `json:"image,omitempty"`
+	Cpu       string `json:"cpu,omitempty"`
+	Memory    string `json:"memory,omitempty"`
+	ReqCpu    string `json:"reqcpu,omitempty"` //如果
 	ReqMemory string `json:"reqmemory,omitempty"`
-	Replicas int `json:"replicas,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=5
+	Replicas int    `json:"replicas,omitempty"`
 	Capacity string `json:"capacity,omitempty"`
+	// RetainPolicy decides what happens to the workspace volume when the Traincrd is deleted.
+	// Defaults to Delete.
+	// +optional
+	RetainPolicy TraincrdRetainPolicy `json:"retainPolicy,omitempty"`
+	// Template names the WorkspaceTemplate, or the labelled ConfigMap in the controller namespace,
+	// the workspace pods are rendered from. Empty means the built-in Jupyter workspace.
+	// +optional
+	Template string `json:"template,omitempty"`
+	// Profile names the ClusterTraincrd whose fields fill in the ones left empty here.
+	// +optional
+	Profile string `json:"profile,omitempty"`
+	// Suspended scales the workspace to zero while keeping its storage, the Ingress answers with
+	// a "workspace suspended" page. Clearing it restores spec.replicas.
+	// +optional
+	Suspended bool `json:"suspended,omitempty"`
+
+	// TTL expires the workspace this long after its creation, see ExpireAction.
+	// +optional
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+	// ExpiresAt expires the workspace at a fixed time, the earlier of TTL and ExpiresAt applies.
+	// +optional
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+	// ExpireAction is what happens to an expired workspace. Defaults to Suspend.
+	// +optional
+	ExpireAction TraincrdExpireAction `json:"expireAction,omitempty"`
+	// Schedule runs the workspace only between its start and stop times.
+	// +optional
+	Schedule *TraincrdSchedule `json:"schedule,omitempty"`
+
+	// RestoreFrom names a TraincrdSnapshot in the same namespace the workspace volume is
+	// provisioned from. It only applies when the volume is created.
+	// +optional
+	RestoreFrom string `json:"restoreFrom,omitempty"`
+	// CloneFrom names a Traincrd whose volume is copied into the new workspace volume, the image
+	// and resource fields left empty are inherited from it. It only applies when the volume is created.
+	// +optional
+	CloneFrom *TraincrdCloneSource `json:"cloneFrom,omitempty"`
+
+	// Volumes are mounted into the workspace container next to the workspace volume and the
+	// public storage, e.g. shared datasets, ConfigMaps, Secrets and scratch space.
+	// +optional
+	Volumes []TraincrdVolume `json:"volumes,omitempty"`
+	// Storage selects how the workspace volume is provisioned.
+	// +optional
+	Storage *TraincrdStorage `json:"storage,omitempty"`
 }
 
+// TraincrdStorage selects the backend of the workspace volume.
+type TraincrdStorage struct {
+	// Class names a volume class of the controller config (storage.classes), e.g. a CSI storage
+	// class or NFS. Empty means the class of the channel, else the default one. It only applies
+	// when the volume is created.
+	// +optional
+	Class string `json:"class,omitempty"`
+}
+
+// TraincrdVolume is an additional volume of the workspace, exactly one source must be set.
+type TraincrdVolume struct {
+	// Name of the volume, a DNS-1123 label unique within spec.volumes.
+	Name string `json:"name"`
+	// MountPath is where the volume is mounted in the workspace container.
+	MountPath string `json:"mountPath"`
+	// ReadOnly mounts the volume read-only.
+	// +optional
+	ReadOnly bool `json:"readOnly,omitempty"`
+
+	// PersistentVolumeClaim mounts a shared claim of the namespace. The controller config lists
+	// the claims each channel may mount in workspace.sharedClaims.
+	// +optional
+	PersistentVolumeClaim *corev1.PersistentVolumeClaimVolumeSource `json:"persistentVolumeClaim,omitempty"`
+	// +optional
+	ConfigMap *corev1.ConfigMapVolumeSource `json:"configMap,omitempty"`
+	// +optional
+	Secret *corev1.SecretVolumeSource `json:"secret,omitempty"`
+	// EmptyDir is scratch space removed together with the pod, bounded by its sizeLimit.
+	// +optional
+	EmptyDir *corev1.EmptyDirVolumeSource `json:"emptyDir,omitempty"`
+	// +optional
+	Projected *corev1.ProjectedVolumeSource `json:"projected,omitempty"`
+}
+
+// TraincrdCloneSource names the workspace a Traincrd is cloned from. A source in another
+// namespace must carry the annotation decision.finupgroup.com/cloneable: "true".
+type TraincrdCloneSource struct {
+	// Namespace of the source, defaults to the namespace of the clone.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+// TraincrdExpireAction describes what happens to a workspace after spec.ttl or spec.expiresAt.
+// +kubebuilder:validation:Enum=Suspend;Delete
+type TraincrdExpireAction string
+
+const (
+	// ExpireActionSuspend suspends the workspace, as spec.suspended does.
+	ExpireActionSuspend TraincrdExpireAction = "Suspend"
+	// ExpireActionDelete deletes the Traincrd, its volume follows spec.retainPolicy.
+	ExpireActionDelete TraincrdExpireAction = "Delete"
+)
+
+// TraincrdSchedule is a start/stop window given by five field cron expressions, e.g. start
+// "0 9 * * 1-5" and stop "0 19 * * 1-5" for office hours. The workspace runs after a start and
+// is suspended after a stop, in the time zone of the controller config (Asia/Shanghai).
+type TraincrdSchedule struct {
+	Start string `json:"start"`
+	Stop  string `json:"stop"`
+}
+
+// TraincrdRetainPolicy describes what happens to the workspace PVC on deletion.
+// +kubebuilder:validation:Enum=Delete;Retain;Archive
+type TraincrdRetainPolicy string
+
+const (
+	// RetainPolicyDelete deletes the PVC together with the Traincrd.
+	RetainPolicyDelete TraincrdRetainPolicy = "Delete"
+	// RetainPolicyRetain keeps the PVC after the Traincrd is gone.
+	RetainPolicyRetain TraincrdRetainPolicy = "Retain"
+	// RetainPolicyArchive copies the volume to a tarball on the public storage before deleting it.
+	RetainPolicyArchive TraincrdRetainPolicy = "Archive"
+)
+
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
 // TraincrdList is a top-level list type. The client methods for lists are automatically created.
@@ -55,33 +192,275 @@ type TraincrdList struct {
 	Items []Traincrd `json:"items"`
 }
 
+// TraincrdPhase is a coarse summary of where a workspace is in its lifecycle.
+// +kubebuilder:validation:Enum=Pending;Provisioning;Running;Suspended;Failed;Terminating
+type TraincrdPhase string
+
+const (
+	// TraincrdPending means the Traincrd was accepted but no child object exists yet.
+	TraincrdPending TraincrdPhase = "Pending"
+	// TraincrdProvisioning means children exist but storage or pods are not ready.
+	TraincrdProvisioning TraincrdPhase = "Provisioning"
+	// TraincrdRunning means every ready replica is serving behind the Ingress.
+	TraincrdRunning TraincrdPhase = "Running"
+	// TraincrdSuspended means spec.suspended scaled the workspace to zero.
+	TraincrdSuspended TraincrdPhase = "Suspended"
+	// TraincrdFailed means the spec cannot be realized without user action.
+	TraincrdFailed TraincrdPhase = "Failed"
+	// TraincrdTerminating means the Traincrd is being deleted.
+	TraincrdTerminating TraincrdPhase = "Terminating"
+)
+
+// TraincrdConditionType is a valid value for TraincrdCondition.Type.
+type TraincrdConditionType string
+
+const (
+	// TraincrdDeploymentReady is true when all desired replicas are ready.
+	TraincrdDeploymentReady TraincrdConditionType = "DeploymentReady"
+	// TraincrdStorageBound is true when the workspace PVC is bound.
+	TraincrdStorageBound TraincrdConditionType = "StorageBound"
+	// TraincrdIngressReady is true when the Ingress routes the workspace URL.
+	TraincrdIngressReady TraincrdConditionType = "IngressReady"
+	// TraincrdReconciled is false when the last reconcile failed; a reason other than
+	// ReconcileError means the spec has to be fixed before it can succeed.
+	TraincrdReconciled TraincrdConditionType = "Reconciled"
+	// TraincrdCleanedUp is false while the deletion of a Traincrd is blocked by its teardown.
+	TraincrdCleanedUp TraincrdConditionType = "CleanedUp"
+	// TraincrdCulled is true while the workspace is scaled to zero by the idle culler, it turns
+	// false once the replicas are raised again.
+	TraincrdCulled TraincrdConditionType = "Culled"
+	// TraincrdSuspendedCondition is true while spec.suspended is set.
+	TraincrdSuspendedCondition TraincrdConditionType = "Suspended"
+	// TraincrdExpired is true once spec.ttl or spec.expiresAt has passed.
+	TraincrdExpired TraincrdConditionType = "Expired"
+	// TraincrdStorageResized is false while the workspace volume is being expanded to spec.capacity,
+	// or when the new capacity cannot be applied, e.g. a shrink.
+	TraincrdStorageResized TraincrdConditionType = "StorageResized"
+	// TraincrdCloned is false while the volume of a spec.cloneFrom workspace is being copied,
+	// the workspace is started once it is true.
+	TraincrdCloned TraincrdConditionType = "Cloned"
+)
+
+// TraincrdCondition describes the state of one aspect of a workspace.
+type TraincrdCondition struct {
+	Type   TraincrdConditionType  `json:"type"`
+	Status corev1.ConditionStatus `json:"status"`
+	// +optional
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
 type TraincrdStatus struct {
-	Blah string
+	// +optional
+	Phase TraincrdPhase `json:"phase,omitempty"`
+	// +optional
+	Conditions []TraincrdCondition `json:"conditions,omitempty"`
+	// ObservedGeneration is the metadata.generation the status was computed for.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Replicas is the number of workspace pods, exposed through the scale subresource.
+	// +optional
+	Replicas int32 `json:"replicas,omitempty"`
+	// ReadyReplicas is the number of workspace pods that are ready.
+	// +optional
+	ReadyReplicas int32 `json:"readyReplicas,omitempty"`
+	// Selector is the label selector of the workspace pods in string form, used by the scale subresource.
+	// +optional
+	Selector string `json:"selector,omitempty"`
+	// URL is the public address of the workspace, built from the Ingress host and path.
+	// +optional
+	URL string `json:"url,omitempty"`
+	// Children references the Deployment, Service, Ingress and PVC owned by this Traincrd.
+	// +optional
+	Children []corev1.TypedLocalObjectReference `json:"children,omitempty"`
+	// LastActivityTime is the last activity the idle culler read from the workspace.
+	// +optional
+	LastActivityTime *metav1.Time `json:"lastActivityTime,omitempty"`
+	// Capacity is the size of the workspace volume, it lags behind the spec while a resize is in progress.
+	// +optional
+	Capacity string `json:"capacity,omitempty"`
+	// Lineage is where the workspace volume was copied from, see spec.cloneFrom and spec.restoreFrom.
+	// +optional
+	Lineage *TraincrdLineage `json:"lineage,omitempty"`
 }
 
+// TraincrdLineage records the source of a workspace volume, it is kept as an annotation on the PVC.
+type TraincrdLineage struct {
+	// Kind of the source, Traincrd for spec.cloneFrom and TraincrdSnapshot for spec.restoreFrom.
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// UID tells the source apart from a later object of the same name.
+	// +optional
+	UID string `json:"uid,omitempty"`
+	// Method is how the data was copied: CSIClone, Job or Snapshot.
+	Method string `json:"method"`
+}
+
+// +genclient
 // +genclient:nonNamespaced
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:path=workspacetemplates,scope=Cluster,shortName=wt
+// +kubebuilder:printcolumn:name="Type",type=string,JSONPath=`.spec.type`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
-type ClusterTraincrdList struct {
-	metav1.TypeMeta
-	metav1.ListMeta
-	Items []ClusterTraincrd
+// WorkspaceTemplate is a named pod template registered by the platform admins, selected by
+// spec.template of a Traincrd.
+type WorkspaceTemplate struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec WorkspaceTemplateSpec `json:"spec"`
+}
+
+type WorkspaceTemplateSpec struct {
+	// Type tells how the rendered template is turned into the pod template of the workspace.
+	Type WorkspaceTemplateType `json:"type"`
+	// Template is a Go text/template rendering YAML. It sees the workspace values such as
+	// .Name, .Username, .Channel, .Image, .WorkDir, .Resources and .Workspace.
+	Template string `json:"template"`
+	// Port is the container port the workspace Service targets, defaults to the notebook port
+	// of the controller config.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Port int32 `json:"port,omitempty"`
+}
+
+// WorkspaceTemplateType is a valid value for WorkspaceTemplateSpec.Type.
+// +kubebuilder:validation:Enum=GoTemplate;StrategicMerge
+type WorkspaceTemplateType string
+
+const (
+	// WorkspaceTemplateGoTemplate renders a complete PodTemplateSpec.
+	WorkspaceTemplateGoTemplate WorkspaceTemplateType = "GoTemplate"
+	// WorkspaceTemplateStrategicMerge renders a strategic merge patch applied over the built-in
+	// Jupyter PodTemplateSpec, containers and volumes are merged by name.
+	WorkspaceTemplateStrategicMerge WorkspaceTemplateType = "StrategicMerge"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// WorkspaceTemplateList is a list of WorkspaceTemplates.
+type WorkspaceTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []WorkspaceTemplate `json:"items"`
 }
 
 // +genclient
 // +genclient:nonNamespaced
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
-// +genclient:method=GetScale,verb=get,subresource=scale,result=k8s.io/kubernetes/pkg/apis/autoscaling.Scale
-// +genclient:method=UpdateScale,verb=update,subresource=scale,input=k8s.io/kubernetes/pkg/apis/autoscaling.Scale,result=k8s.io/kubernetes/pkg/apis/autoscaling.Scale
+// +kubebuilder:resource:path=clustertraincrds,scope=Cluster,shortName=ctc;profile
+// +kubebuilder:printcolumn:name="Image",type=string,JSONPath=`.spec.image`
+// +kubebuilder:printcolumn:name="CPU",type=string,JSONPath=`.spec.cpu`
+// +kubebuilder:printcolumn:name="Memory",type=string,JSONPath=`.spec.memory`
+// +kubebuilder:printcolumn:name="Template",type=string,JSONPath=`.spec.template`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
+// ClusterTraincrd is a workspace profile registered by the platform admins. A Traincrd selects
+// it with spec.profile and inherits every field of the profile it leaves empty.
 type ClusterTraincrd struct {
 	metav1.TypeMeta `json:",inline"`
 	// +optional
 	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ClusterTraincrdSpec `json:"spec"`
+}
+
+type ClusterTraincrdSpec struct {
+	// +optional
+	Image string `json:"image,omitempty"`
+	// +optional
+	Cpu string `json:"cpu,omitempty"`
+	// +optional
+	Memory string `json:"memory,omitempty"`
+	// +optional
+	ReqCpu string `json:"reqcpu,omitempty"`
+	// +optional
+	ReqMemory string `json:"reqmemory,omitempty"`
+	// Capacity is the size of the workspace volume.
+	// +optional
+	Capacity string `json:"capacity,omitempty"`
+	// Template names the WorkspaceTemplate of the workspace pods.
+	// +optional
+	Template string `json:"template,omitempty"`
+	// AllowedChannels are the channel labels of the Traincrds that may use this profile,
+	// empty allows every channel.
+	// +optional
+	AllowedChannels []string `json:"allowedChannels,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ClusterTraincrdList is a list of ClusterTraincrds.
+type ClusterTraincrdList struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []ClusterTraincrd `json:"items"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:path=traincrdsnapshots,scope=Namespaced,shortName=tsnap
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Traincrd",type=string,JSONPath=`.spec.traincrd`
+// +kubebuilder:printcolumn:name="Ready",type=boolean,JSONPath=`.status.readyToUse`
+// +kubebuilder:printcolumn:name="Size",type=string,JSONPath=`.status.restoreSize`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// TraincrdSnapshot is a point in time copy of the volume of a workspace, taken through the
+// VolumeSnapshot API. A Traincrd in the same namespace restores it with spec.restoreFrom.
+type TraincrdSnapshot struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec TraincrdSnapshotSpec `json:"spec"`
 	// +optional
-	Status ClusterTraincrdStatus `json:"status,omitempty"`
+	Status TraincrdSnapshotStatus `json:"status,omitempty"`
 }
 
-type ClusterTraincrdStatus struct {
-	Blah string
+type TraincrdSnapshotSpec struct {
+	// Traincrd names the workspace whose volume is copied.
+	Traincrd string `json:"traincrd"`
+	// VolumeSnapshotClassName overrides storage.volumeSnapshotClassName of the controller config.
+	// +optional
+	VolumeSnapshotClassName string `json:"volumeSnapshotClassName,omitempty"`
+}
+
+type TraincrdSnapshotStatus struct {
+	// VolumeSnapshotName is the VolumeSnapshot holding the data, it is deleted with the TraincrdSnapshot.
+	// +optional
+	VolumeSnapshotName string `json:"volumeSnapshotName,omitempty"`
+	// ReadyToUse is set once the snapshot can be restored.
+	// +optional
+	ReadyToUse bool `json:"readyToUse,omitempty"`
+	// RestoreSize is the minimum capacity of a volume restored from the snapshot.
+	// +optional
+	RestoreSize string `json:"restoreSize,omitempty"`
+	// CreationTime is when the storage system took the snapshot.
+	// +optional
+	CreationTime *metav1.Time `json:"creationTime,omitempty"`
+	// Error is the last error of the snapshotter or the controller, empty once it is resolved.
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// TraincrdSnapshotList is a list of TraincrdSnapshots.
+type TraincrdSnapshotList struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []TraincrdSnapshot `json:"items"`
 }
@@ -0,0 +1,336 @@
+package v1beta2
+
+import (
+	"encoding/json"
+	"fmt"
+
+	v1 "finupgroup.com/decision/traincrd/pkg/apis/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// v1 is the storage version. Conversion is lossless in both directions: what one version cannot
+// express is kept in an annotation of the other one and restored on the way back.
+const (
+	// V1SpecAnnotation keeps the original v1 quantity strings on a v1beta2 object, either because
+	// they are not in canonical form (1024Mi becomes 1Gi) or because they do not parse at all.
+	V1SpecAnnotation = "decision.finupgroup.com/v1-spec"
+	// V1beta2SpecAnnotation keeps the v1beta2 fields without a v1 counterpart on a v1 object.
+	V1beta2SpecAnnotation = "decision.finupgroup.com/v1beta2-spec"
+)
+
+// v1Quantities are the v1 quantity fields keyed by their json name
+type v1Quantities map[string]string
+
+// ExtraFields is the part of a v1beta2 spec the v1 API has no field for
+type ExtraFields struct {
+	// NilReplicas tells an omitted replicas apart from 0
+	NilReplicas bool `json:"nilReplicas,omitempty"`
+	// Resources holds limits and requests other than cpu and memory
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+	Env       []corev1.EnvVar             `json:"env,omitempty"`
+	Ports     []corev1.ContainerPort      `json:"ports,omitempty"`
+	Ingress   TraincrdIngress             `json:"ingress,omitempty"`
+}
+
+// ExtraFieldsOf returns the v1beta2 fields kept on a v1 object, empty when it has none.
+func ExtraFieldsOf(in *v1.Traincrd) (ExtraFields, error) {
+	extra := ExtraFields{}
+	if data, ok := in.Annotations[V1beta2SpecAnnotation]; ok {
+		if err := json.Unmarshal([]byte(data), &extra); err != nil {
+			return extra, fmt.Errorf("annotation %s: %v", V1beta2SpecAnnotation, err)
+		}
+	}
+	return extra, nil
+}
+
+// ConvertFromV1 converts the storage version into v1beta2.
+func ConvertFromV1(in *v1.Traincrd, out *Traincrd) error {
+	out.TypeMeta = in.TypeMeta
+	out.APIVersion = SchemeGroupVersion.String()
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+
+	extra, err := ExtraFieldsOf(in)
+	if err != nil {
+		return err
+	}
+	delete(out.Annotations, V1beta2SpecAnnotation)
+
+	spec := &out.Spec
+	*spec = TraincrdSpec{
+		Image:     in.Spec.Image,
+		Resources: *extra.Resources.DeepCopy(),
+		Env:       extra.Env,
+		Ports:     extra.Ports,
+		Ingress:   extra.Ingress,
+		Storage:   TraincrdStorage{RetainPolicy: TraincrdRetainPolicy(in.Spec.RetainPolicy)},
+		Template:  in.Spec.Template,
+		Profile:   in.Spec.Profile,
+		Suspended: in.Spec.Suspended,
+
+		TTL:          in.Spec.TTL.DeepCopy(),
+		ExpiresAt:    in.Spec.ExpiresAt.DeepCopy(),
+		ExpireAction: TraincrdExpireAction(in.Spec.ExpireAction),
+
+		RestoreFrom: in.Spec.RestoreFrom,
+	}
+	if in.Spec.Schedule != nil {
+		spec.Schedule = &TraincrdSchedule{Start: in.Spec.Schedule.Start, Stop: in.Spec.Schedule.Stop}
+	}
+	if in.Spec.CloneFrom != nil {
+		spec.CloneFrom = &TraincrdCloneSource{Namespace: in.Spec.CloneFrom.Namespace, Name: in.Spec.CloneFrom.Name}
+	}
+	if in.Spec.Storage != nil {
+		spec.Storage.Class = in.Spec.Storage.Class
+	}
+	if in.Spec.Volumes != nil {
+		spec.Volumes = make([]TraincrdVolume, len(in.Spec.Volumes))
+		for i := range in.Spec.Volumes {
+			v := in.Spec.Volumes[i].DeepCopy()
+			spec.Volumes[i] = TraincrdVolume{
+				Name:                  v.Name,
+				MountPath:             v.MountPath,
+				ReadOnly:              v.ReadOnly,
+				PersistentVolumeClaim: v.PersistentVolumeClaim,
+				ConfigMap:             v.ConfigMap,
+				Secret:                v.Secret,
+				EmptyDir:              v.EmptyDir,
+				Projected:             v.Projected,
+			}
+		}
+	}
+	if !(extra.NilReplicas && in.Spec.Replicas == 0) {
+		replicas := int32(in.Spec.Replicas)
+		spec.Replicas = &replicas
+	}
+
+	original := v1Quantities{}
+	fromString := func(name, value string) *resource.Quantity {
+		if value == "" {
+			return nil
+		}
+		q, err := resource.ParseQuantity(value)
+		if err != nil || q.String() != value {
+			original[name] = value
+		}
+		if err != nil {
+			return nil
+		}
+		return &q
+	}
+	setResource(&spec.Resources.Limits, corev1.ResourceCPU, fromString("cpu", in.Spec.Cpu))
+	setResource(&spec.Resources.Limits, corev1.ResourceMemory, fromString("memory", in.Spec.Memory))
+	setResource(&spec.Resources.Requests, corev1.ResourceCPU, fromString("reqcpu", in.Spec.ReqCpu))
+	setResource(&spec.Resources.Requests, corev1.ResourceMemory, fromString("reqmemory", in.Spec.ReqMemory))
+	spec.Storage.Capacity = fromString("capacity", in.Spec.Capacity)
+
+	if len(original) > 0 {
+		data, err := json.Marshal(original)
+		if err != nil {
+			return err
+		}
+		setAnnotation(&out.ObjectMeta.Annotations, V1SpecAnnotation, string(data))
+	}
+	if len(out.Annotations) == 0 {
+		out.Annotations = nil
+	}
+
+	return convertStatusFromV1(&in.Status, &out.Status)
+}
+
+// ConvertToV1 converts v1beta2 into the storage version.
+func ConvertToV1(in *Traincrd, out *v1.Traincrd) error {
+	out.TypeMeta = in.TypeMeta
+	out.APIVersion = v1.SchemeGroupVersion.String()
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+
+	original := v1Quantities{}
+	if data, ok := out.Annotations[V1SpecAnnotation]; ok {
+		if err := json.Unmarshal([]byte(data), &original); err != nil {
+			return fmt.Errorf("annotation %s: %v", V1SpecAnnotation, err)
+		}
+		delete(out.Annotations, V1SpecAnnotation)
+	}
+
+	// toString prefers the original v1 spelling as long as it still means the same quantity
+	toString := func(name string, q *resource.Quantity) string {
+		value, ok := original[name]
+		if q == nil {
+			if _, err := resource.ParseQuantity(value); ok && err != nil {
+				return value
+			}
+			return ""
+		}
+		if parsed, err := resource.ParseQuantity(value); ok && err == nil && parsed.Cmp(*q) == 0 {
+			return value
+		}
+		return q.String()
+	}
+
+	extra := ExtraFields{
+		NilReplicas: in.Spec.Replicas == nil,
+		Env:         in.Spec.Env,
+		Ports:       in.Spec.Ports,
+		Ingress:     in.Spec.Ingress,
+	}
+	resources := in.Spec.Resources.DeepCopy()
+	out.Spec = v1.TraincrdSpec{
+		Image:        in.Spec.Image,
+		Cpu:          toString("cpu", takeResource(resources.Limits, corev1.ResourceCPU)),
+		Memory:       toString("memory", takeResource(resources.Limits, corev1.ResourceMemory)),
+		ReqCpu:       toString("reqcpu", takeResource(resources.Requests, corev1.ResourceCPU)),
+		ReqMemory:    toString("reqmemory", takeResource(resources.Requests, corev1.ResourceMemory)),
+		Capacity:     toString("capacity", in.Spec.Storage.Capacity),
+		RetainPolicy: v1.TraincrdRetainPolicy(in.Spec.Storage.RetainPolicy),
+		Template:     in.Spec.Template,
+		Profile:      in.Spec.Profile,
+		Suspended:    in.Spec.Suspended,
+		TTL:          in.Spec.TTL.DeepCopy(),
+		ExpiresAt:    in.Spec.ExpiresAt.DeepCopy(),
+		ExpireAction: v1.TraincrdExpireAction(in.Spec.ExpireAction),
+		RestoreFrom:  in.Spec.RestoreFrom,
+	}
+	if in.Spec.Schedule != nil {
+		out.Spec.Schedule = &v1.TraincrdSchedule{Start: in.Spec.Schedule.Start, Stop: in.Spec.Schedule.Stop}
+	}
+	if in.Spec.CloneFrom != nil {
+		out.Spec.CloneFrom = &v1.TraincrdCloneSource{Namespace: in.Spec.CloneFrom.Namespace, Name: in.Spec.CloneFrom.Name}
+	}
+	if in.Spec.Storage.Class != "" {
+		out.Spec.Storage = &v1.TraincrdStorage{Class: in.Spec.Storage.Class}
+	}
+	if in.Spec.Volumes != nil {
+		out.Spec.Volumes = make([]v1.TraincrdVolume, len(in.Spec.Volumes))
+		for i := range in.Spec.Volumes {
+			v := in.Spec.Volumes[i].DeepCopy()
+			out.Spec.Volumes[i] = v1.TraincrdVolume{
+				Name:                  v.Name,
+				MountPath:             v.MountPath,
+				ReadOnly:              v.ReadOnly,
+				PersistentVolumeClaim: v.PersistentVolumeClaim,
+				ConfigMap:             v.ConfigMap,
+				Secret:                v.Secret,
+				EmptyDir:              v.EmptyDir,
+				Projected:             v.Projected,
+			}
+		}
+	}
+	if in.Spec.Replicas != nil {
+		out.Spec.Replicas = int(*in.Spec.Replicas)
+	}
+	if len(resources.Limits) > 0 || len(resources.Requests) > 0 {
+		extra.Resources = *resources
+	}
+
+	if extra.NilReplicas || len(extra.Resources.Limits) > 0 || len(extra.Resources.Requests) > 0 ||
+		len(extra.Env) > 0 || len(extra.Ports) > 0 || extra.Ingress.Host != "" || extra.Ingress.Path != "" ||
+		len(extra.Ingress.Annotations) > 0 {
+		data, err := json.Marshal(extra)
+		if err != nil {
+			return err
+		}
+		setAnnotation(&out.ObjectMeta.Annotations, V1beta2SpecAnnotation, string(data))
+	}
+	if len(out.Annotations) == 0 {
+		out.Annotations = nil
+	}
+
+	return convertStatusToV1(&in.Status, &out.Status)
+}
+
+func convertStatusFromV1(in *v1.TraincrdStatus, out *TraincrdStatus) error {
+	*out = TraincrdStatus{
+		Phase:              TraincrdPhase(in.Phase),
+		ObservedGeneration: in.ObservedGeneration,
+		Replicas:           in.Replicas,
+		ReadyReplicas:      in.ReadyReplicas,
+		Selector:           in.Selector,
+		URL:                in.URL,
+		LastActivityTime:   in.LastActivityTime.DeepCopy(),
+		Capacity:           in.Capacity,
+	}
+	if in.Lineage != nil {
+		out.Lineage = &TraincrdLineage{Kind: in.Lineage.Kind, Namespace: in.Lineage.Namespace, Name: in.Lineage.Name, UID: in.Lineage.UID, Method: in.Lineage.Method}
+	}
+	if in.Children != nil {
+		out.Children = make([]corev1.TypedLocalObjectReference, len(in.Children))
+		for i := range in.Children {
+			in.Children[i].DeepCopyInto(&out.Children[i])
+		}
+	}
+	if in.Conditions != nil {
+		out.Conditions = make([]TraincrdCondition, len(in.Conditions))
+		for i, c := range in.Conditions {
+			out.Conditions[i] = TraincrdCondition{
+				Type:               TraincrdConditionType(c.Type),
+				Status:             c.Status,
+				LastTransitionTime: *c.LastTransitionTime.DeepCopy(),
+				Reason:             c.Reason,
+				Message:            c.Message,
+			}
+		}
+	}
+	return nil
+}
+
+func convertStatusToV1(in *TraincrdStatus, out *v1.TraincrdStatus) error {
+	*out = v1.TraincrdStatus{
+		Phase:              v1.TraincrdPhase(in.Phase),
+		ObservedGeneration: in.ObservedGeneration,
+		Replicas:           in.Replicas,
+		ReadyReplicas:      in.ReadyReplicas,
+		Selector:           in.Selector,
+		URL:                in.URL,
+		LastActivityTime:   in.LastActivityTime.DeepCopy(),
+		Capacity:           in.Capacity,
+	}
+	if in.Lineage != nil {
+		out.Lineage = &v1.TraincrdLineage{Kind: in.Lineage.Kind, Namespace: in.Lineage.Namespace, Name: in.Lineage.Name, UID: in.Lineage.UID, Method: in.Lineage.Method}
+	}
+	if in.Children != nil {
+		out.Children = make([]corev1.TypedLocalObjectReference, len(in.Children))
+		for i := range in.Children {
+			in.Children[i].DeepCopyInto(&out.Children[i])
+		}
+	}
+	if in.Conditions != nil {
+		out.Conditions = make([]v1.TraincrdCondition, len(in.Conditions))
+		for i, c := range in.Conditions {
+			out.Conditions[i] = v1.TraincrdCondition{
+				Type:               v1.TraincrdConditionType(c.Type),
+				Status:             c.Status,
+				LastTransitionTime: *c.LastTransitionTime.DeepCopy(),
+				Reason:             c.Reason,
+				Message:            c.Message,
+			}
+		}
+	}
+	return nil
+}
+
+func setResource(list *corev1.ResourceList, name corev1.ResourceName, q *resource.Quantity) {
+	if q == nil {
+		return
+	}
+	if *list == nil {
+		*list = corev1.ResourceList{}
+	}
+	(*list)[name] = *q
+}
+
+// takeResource removes name from list and returns its quantity
+func takeResource(list corev1.ResourceList, name corev1.ResourceName) *resource.Quantity {
+	q, ok := list[name]
+	if !ok {
+		return nil
+	}
+	delete(list, name)
+	return &q
+}
+
+func setAnnotation(annotations *map[string]string, key, value string) {
+	if *annotations == nil {
+		*annotations = map[string]string{}
+	}
+	(*annotations)[key] = value
+}
@@ -0,0 +1,140 @@
+package v1beta2
+
+import (
+	"testing"
+	"time"
+
+	v1 "finupgroup.com/decision/traincrd/pkg/apis/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/diff"
+)
+
+func TestRoundTripFromV1(t *testing.T) {
+	lastActivity := metav1.Date(2019, 12, 2, 18, 30, 0, 0, time.UTC)
+	tests := []v1.Traincrd{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "notebook", Namespace: "wangxx", Labels: map[string]string{"username": "wangxx"}},
+			Spec: v1.TraincrdSpec{
+				Image: "jupyter:1.0", Cpu: "2", Memory: "4Gi", ReqCpu: "500m", ReqMemory: "1Gi",
+				Replicas: 1, Capacity: "5Gi", RetainPolicy: v1.RetainPolicyArchive, Template: "kodexplorer", Profile: "gpu",
+				Suspended: true, TTL: &metav1.Duration{Duration: 72 * time.Hour}, ExpiresAt: &lastActivity, ExpireAction: v1.ExpireActionDelete,
+				Schedule:    &v1.TraincrdSchedule{Start: "0 9 * * 1-5", Stop: "0 19 * * 1-5"},
+				RestoreFrom: "notebook-20191202",
+				CloneFrom:   &v1.TraincrdCloneSource{Namespace: "teacher", Name: "lesson-1"},
+				Storage:     &v1.TraincrdStorage{Class: "rbd"},
+				Volumes: []v1.TraincrdVolume{
+					{Name: "datasets", MountPath: "/datasets", ReadOnly: true, PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "risk-datasets"}},
+					{Name: "scratch", MountPath: "/scratch", EmptyDir: &corev1.EmptyDirVolumeSource{SizeLimit: resource.NewQuantity(10<<30, resource.BinarySI)}},
+				},
+			},
+			Status: v1.TraincrdStatus{
+				Phase:    v1.TraincrdRunning,
+				Replicas: 1,
+				Conditions: []v1.TraincrdCondition{
+					{Type: v1.TraincrdDeploymentReady, Status: corev1.ConditionTrue, Reason: "MinimumReplicasAvailable"},
+				},
+				Children:         []corev1.TypedLocalObjectReference{{Kind: "Deployment", Name: "notebook"}},
+				LastActivityTime: &lastActivity,
+				Capacity:         "5Gi",
+				Lineage:          &v1.TraincrdLineage{Kind: "Traincrd", Namespace: "teacher", Name: "lesson-1", UID: "3f2a9c1e", Method: "Job"},
+			},
+		},
+		{
+			// non canonical spellings and a quantity that does not parse at all
+			ObjectMeta: metav1.ObjectMeta{Name: "legacy", Annotations: map[string]string{"note": "kept"}},
+			Spec:       v1.TraincrdSpec{Image: "jupyter", Cpu: "0.5", Memory: "1024Mi", ReqMemory: "lots"},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "stopped"},
+			Spec:       v1.TraincrdSpec{Image: "jupyter", Cpu: "1", Memory: "1Gi", Replicas: 0},
+		},
+	}
+
+	for _, in := range tests {
+		converted := &Traincrd{}
+		if err := ConvertFromV1(&in, converted); err != nil {
+			t.Errorf("%s: %v", in.Name, err)
+			continue
+		}
+		out := &v1.Traincrd{}
+		if err := ConvertToV1(converted, out); err != nil {
+			t.Errorf("%s: %v", in.Name, err)
+			continue
+		}
+		in.APIVersion = v1.SchemeGroupVersion.String()
+		if !apiequality.Semantic.DeepEqual(&in, out) {
+			t.Errorf("%s: round trip through v1beta2 changed the object: %s", in.Name, diff.ObjectReflectDiff(&in, out))
+		}
+	}
+}
+
+func TestRoundTripFromV1beta2(t *testing.T) {
+	three := int32(3)
+	capacity := resource.MustParse("1.5Gi")
+	tests := []Traincrd{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "gpu", Namespace: "wangxx"},
+			Spec: TraincrdSpec{
+				Image:    "jupyter:1.0",
+				Replicas: &three,
+				Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("2"),
+						corev1.ResourceMemory: resource.MustParse("4Gi"),
+						"nvidia.com/gpu":      resource.MustParse("1"),
+					},
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+				},
+				Storage: TraincrdStorage{Capacity: &capacity, RetainPolicy: RetainPolicyRetain},
+				Env:     []corev1.EnvVar{{Name: "JUPYTER_ENABLE_LAB", Value: "yes"}},
+				Ports:   []corev1.ContainerPort{{Name: "tensorboard", ContainerPort: 6006, Protocol: corev1.ProtocolTCP}},
+				Ingress: TraincrdIngress{Host: "train.example.com", Path: "/gpu", Annotations: map[string]string{"a": "b"}},
+			},
+			Status: TraincrdStatus{Phase: TraincrdPending, Selector: "train=gpu"},
+		},
+		{
+			// omitted replicas must stay omitted rather than turn into 0
+			ObjectMeta: metav1.ObjectMeta{Name: "defaulted"},
+			Spec:       TraincrdSpec{Image: "jupyter"},
+		},
+	}
+
+	for _, in := range tests {
+		converted := &v1.Traincrd{}
+		if err := ConvertToV1(&in, converted); err != nil {
+			t.Errorf("%s: %v", in.Name, err)
+			continue
+		}
+		out := &Traincrd{}
+		if err := ConvertFromV1(converted, out); err != nil {
+			t.Errorf("%s: %v", in.Name, err)
+			continue
+		}
+		in.APIVersion = SchemeGroupVersion.String()
+		if !apiequality.Semantic.DeepEqual(&in, out) {
+			t.Errorf("%s: round trip through v1 changed the object: %s", in.Name, diff.ObjectReflectDiff(&in, out))
+		}
+	}
+}
+
+func TestConvertToV1Fields(t *testing.T) {
+	in := &Traincrd{Spec: TraincrdSpec{
+		Image: "jupyter",
+		Resources: corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1500m")},
+		},
+	}}
+	out := &v1.Traincrd{}
+	if err := ConvertToV1(in, out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Spec.Cpu != "1500m" || out.Spec.Memory != "" || out.Spec.Replicas != 0 {
+		t.Errorf("unexpected v1 spec %+v", out.Spec)
+	}
+	if out.Annotations[V1beta2SpecAnnotation] != `{"nilReplicas":true,"resources":{},"ingress":{}}` {
+		t.Errorf("unexpected %s annotation %q", V1beta2SpecAnnotation, out.Annotations[V1beta2SpecAnnotation])
+	}
+}
@@ -0,0 +1,284 @@
+/*
+Copyright 2015 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta2
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +genclient:method=GetScale,verb=get,subresource=scale,result=k8s.io/api/autoscaling/v1.Scale
+// +genclient:method=UpdateScale,verb=update,subresource=scale,input=k8s.io/api/autoscaling/v1.Scale,result=k8s.io/api/autoscaling/v1.Scale
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:path=traincrds,scope=Namespaced,shortName=tc;train
+// +kubebuilder:subresource:status
+// +kubebuilder:subresource:scale:specpath=.spec.replicas,statuspath=.status.replicas,selectorpath=.status.selector
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Image",type=string,JSONPath=`.spec.image`
+// +kubebuilder:printcolumn:name="CPU",type=string,JSONPath=`.spec.resources.limits.cpu`
+// +kubebuilder:printcolumn:name="Memory",type=string,JSONPath=`.spec.resources.limits.memory`
+// +kubebuilder:printcolumn:name="URL",type=string,JSONPath=`.status.url`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// Traincrd is a training workspace: a Deployment with its Service, Ingress and workspace volume.
+type Traincrd struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	// +optional
+	Spec   TraincrdSpec   `json:"spec"`
+	Status TraincrdStatus `json:"status,omitempty"`
+}
+
+type TraincrdSpec struct {
+	// Image is the workspace container image, may be left out when spec.profile provides it.
+	// +optional
+	Image string `json:"image,omitempty"`
+	// Replicas is the number of workspace pods.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=5
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+	// Resources are the compute requests and limits of the workspace container, the cpu and
+	// memory left out are taken from spec.profile.
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources"`
+	// Storage describes the workspace volume.
+	// +optional
+	Storage TraincrdStorage `json:"storage,omitempty"`
+	// Env is added to the environment of the workspace container.
+	// +optional
+	Env []corev1.EnvVar `json:"env,omitempty"`
+	// Ports are exposed by the workspace container in addition to the notebook port.
+	// +optional
+	Ports []corev1.ContainerPort `json:"ports,omitempty"`
+	// Ingress customizes how the workspace is published. Reserved: the validating webhook
+	// rejects it, workspaces are published on the host and path of the controller config.
+	// +optional
+	Ingress TraincrdIngress `json:"ingress,omitempty"`
+	// Template names the WorkspaceTemplate the workspace pods are rendered from.
+	// +optional
+	Template string `json:"template,omitempty"`
+	// Profile names the ClusterTraincrd whose fields fill in the ones left empty here.
+	// +optional
+	Profile string `json:"profile,omitempty"`
+	// Suspended scales the workspace to zero while keeping its storage.
+	// +optional
+	Suspended bool `json:"suspended,omitempty"`
+
+	// TTL expires the workspace this long after its creation.
+	// +optional
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+	// ExpiresAt expires the workspace at a fixed time, the earlier of TTL and ExpiresAt applies.
+	// +optional
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+	// ExpireAction is what happens to an expired workspace. Defaults to Suspend.
+	// +optional
+	ExpireAction TraincrdExpireAction `json:"expireAction,omitempty"`
+	// Schedule runs the workspace only between its start and stop times.
+	// +optional
+	Schedule *TraincrdSchedule `json:"schedule,omitempty"`
+
+	// RestoreFrom names a TraincrdSnapshot the workspace volume is provisioned from.
+	// +optional
+	RestoreFrom string `json:"restoreFrom,omitempty"`
+	// CloneFrom names a Traincrd whose volume is copied into the workspace volume.
+	// +optional
+	CloneFrom *TraincrdCloneSource `json:"cloneFrom,omitempty"`
+
+	// Volumes are mounted into the workspace container next to the workspace volume.
+	// +optional
+	Volumes []TraincrdVolume `json:"volumes,omitempty"`
+}
+
+// TraincrdVolume is an additional volume of the workspace, exactly one source must be set.
+type TraincrdVolume struct {
+	Name      string `json:"name"`
+	MountPath string `json:"mountPath"`
+	// +optional
+	ReadOnly bool `json:"readOnly,omitempty"`
+
+	// +optional
+	PersistentVolumeClaim *corev1.PersistentVolumeClaimVolumeSource `json:"persistentVolumeClaim,omitempty"`
+	// +optional
+	ConfigMap *corev1.ConfigMapVolumeSource `json:"configMap,omitempty"`
+	// +optional
+	Secret *corev1.SecretVolumeSource `json:"secret,omitempty"`
+	// +optional
+	EmptyDir *corev1.EmptyDirVolumeSource `json:"emptyDir,omitempty"`
+	// +optional
+	Projected *corev1.ProjectedVolumeSource `json:"projected,omitempty"`
+}
+
+// TraincrdCloneSource names the workspace a Traincrd is cloned from.
+type TraincrdCloneSource struct {
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+// TraincrdExpireAction describes what happens to a workspace after spec.ttl or spec.expiresAt.
+// +kubebuilder:validation:Enum=Suspend;Delete
+type TraincrdExpireAction string
+
+const (
+	ExpireActionSuspend TraincrdExpireAction = "Suspend"
+	ExpireActionDelete  TraincrdExpireAction = "Delete"
+)
+
+// TraincrdSchedule is a start/stop window given by five field cron expressions.
+type TraincrdSchedule struct {
+	Start string `json:"start"`
+	Stop  string `json:"stop"`
+}
+
+type TraincrdStorage struct {
+	// Capacity is the size requested for the workspace volume.
+	// +optional
+	Capacity *resource.Quantity `json:"capacity,omitempty"`
+	// RetainPolicy decides what happens to the workspace volume when the Traincrd is deleted.
+	// Defaults to Delete.
+	// +optional
+	RetainPolicy TraincrdRetainPolicy `json:"retainPolicy,omitempty"`
+	// Class names a volume class of the controller config.
+	// +optional
+	Class string `json:"class,omitempty"`
+}
+
+// TraincrdRetainPolicy describes what happens to the workspace PVC on deletion.
+// +kubebuilder:validation:Enum=Delete;Retain;Archive
+type TraincrdRetainPolicy string
+
+const (
+	// RetainPolicyDelete deletes the PVC together with the Traincrd.
+	RetainPolicyDelete TraincrdRetainPolicy = "Delete"
+	// RetainPolicyRetain keeps the PVC after the Traincrd is gone.
+	RetainPolicyRetain TraincrdRetainPolicy = "Retain"
+	// RetainPolicyArchive copies the volume to a tarball on the public storage before deleting it.
+	RetainPolicyArchive TraincrdRetainPolicy = "Archive"
+)
+
+type TraincrdIngress struct {
+	// Host overrides the default workspace host.
+	// +optional
+	Host string `json:"host,omitempty"`
+	// Path overrides the default /<name> path.
+	// +optional
+	Path string `json:"path,omitempty"`
+	// Annotations are added to the generated Ingress.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// TraincrdList is a list of Traincrds.
+type TraincrdList struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []Traincrd `json:"items"`
+}
+
+// TraincrdPhase is a coarse summary of where a workspace is in its lifecycle.
+// +kubebuilder:validation:Enum=Pending;Provisioning;Running;Suspended;Failed;Terminating
+type TraincrdPhase string
+
+const (
+	TraincrdPending      TraincrdPhase = "Pending"
+	TraincrdProvisioning TraincrdPhase = "Provisioning"
+	TraincrdRunning      TraincrdPhase = "Running"
+	TraincrdSuspended    TraincrdPhase = "Suspended"
+	TraincrdFailed       TraincrdPhase = "Failed"
+	TraincrdTerminating  TraincrdPhase = "Terminating"
+)
+
+// TraincrdConditionType is a valid value for TraincrdCondition.Type, see the v1 API for their meaning.
+type TraincrdConditionType string
+
+const (
+	TraincrdDeploymentReady TraincrdConditionType = "DeploymentReady"
+	TraincrdStorageBound    TraincrdConditionType = "StorageBound"
+	TraincrdIngressReady    TraincrdConditionType = "IngressReady"
+	TraincrdReconciled      TraincrdConditionType = "Reconciled"
+	TraincrdCleanedUp       TraincrdConditionType = "CleanedUp"
+	TraincrdCulled          TraincrdConditionType = "Culled"
+
+	TraincrdSuspendedCondition TraincrdConditionType = "Suspended"
+	TraincrdExpired            TraincrdConditionType = "Expired"
+	TraincrdStorageResized     TraincrdConditionType = "StorageResized"
+	TraincrdCloned             TraincrdConditionType = "Cloned"
+)
+
+// TraincrdCondition describes the state of one aspect of a workspace.
+type TraincrdCondition struct {
+	Type   TraincrdConditionType  `json:"type"`
+	Status corev1.ConditionStatus `json:"status"`
+	// +optional
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+type TraincrdStatus struct {
+	// +optional
+	Phase TraincrdPhase `json:"phase,omitempty"`
+	// +optional
+	Conditions []TraincrdCondition `json:"conditions,omitempty"`
+	// ObservedGeneration is the metadata.generation the status was computed for.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Replicas is the number of workspace pods, exposed through the scale subresource.
+	// +optional
+	Replicas int32 `json:"replicas,omitempty"`
+	// ReadyReplicas is the number of workspace pods that are ready.
+	// +optional
+	ReadyReplicas int32 `json:"readyReplicas,omitempty"`
+	// Selector is the label selector of the workspace pods in string form, used by the scale subresource.
+	// +optional
+	Selector string `json:"selector,omitempty"`
+	// URL is the public address of the workspace, built from the Ingress host and path.
+	// +optional
+	URL string `json:"url,omitempty"`
+	// Children references the Deployment, Service, Ingress and PVC owned by this Traincrd.
+	// +optional
+	Children []corev1.TypedLocalObjectReference `json:"children,omitempty"`
+	// LastActivityTime is the last activity the idle culler read from the workspace.
+	// +optional
+	LastActivityTime *metav1.Time `json:"lastActivityTime,omitempty"`
+	// Capacity is the size of the workspace volume, it lags behind the spec while a resize is in progress.
+	// +optional
+	Capacity string `json:"capacity,omitempty"`
+	// Lineage is where the workspace volume was copied from.
+	// +optional
+	Lineage *TraincrdLineage `json:"lineage,omitempty"`
+}
+
+// TraincrdLineage records the source of a workspace volume.
+type TraincrdLineage struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// +optional
+	UID    string `json:"uid,omitempty"`
+	Method string `json:"method"`
+}
@@ -22,6 +22,7 @@ import (
 	"fmt"
 
 	decisionv1 "finupgroup.com/decision/traincrd/pkg/client/clientset/versioned/typed/apis/v1"
+	decisionv1beta2 "finupgroup.com/decision/traincrd/pkg/client/clientset/versioned/typed/apis/v1beta2"
 	discovery "k8s.io/client-go/discovery"
 	rest "k8s.io/client-go/rest"
 	flowcontrol "k8s.io/client-go/util/flowcontrol"
@@ -30,13 +31,15 @@ import (
 type Interface interface {
 	Discovery() discovery.DiscoveryInterface
 	DecisionV1() decisionv1.DecisionV1Interface
+	DecisionV1beta2() decisionv1beta2.DecisionV1beta2Interface
 }
 
 // Clientset contains the clients for groups. Each group has exactly one
 // version included in a Clientset.
 type Clientset struct {
 	*discovery.DiscoveryClient
-	decisionV1 *decisionv1.DecisionV1Client
+	decisionV1      *decisionv1.DecisionV1Client
+	decisionV1beta2 *decisionv1beta2.DecisionV1beta2Client
 }
 
 // DecisionV1 retrieves the DecisionV1Client
@@ -44,6 +47,11 @@ func (c *Clientset) DecisionV1() decisionv1.DecisionV1Interface {
 	return c.decisionV1
 }
 
+// DecisionV1beta2 retrieves the DecisionV1beta2Client
+func (c *Clientset) DecisionV1beta2() decisionv1beta2.DecisionV1beta2Interface {
+	return c.decisionV1beta2
+}
+
 // Discovery retrieves the DiscoveryClient
 func (c *Clientset) Discovery() discovery.DiscoveryInterface {
 	if c == nil {
@@ -69,6 +77,10 @@ func NewForConfig(c *rest.Config) (*Clientset, error) {
 	if err != nil {
 		return nil, err
 	}
+	cs.decisionV1beta2, err = decisionv1beta2.NewForConfig(&configShallowCopy)
+	if err != nil {
+		return nil, err
+	}
 
 	cs.DiscoveryClient, err = discovery.NewDiscoveryClientForConfig(&configShallowCopy)
 	if err != nil {
@@ -82,6 +94,7 @@ func NewForConfig(c *rest.Config) (*Clientset, error) {
 func NewForConfigOrDie(c *rest.Config) *Clientset {
 	var cs Clientset
 	cs.decisionV1 = decisionv1.NewForConfigOrDie(c)
+	cs.decisionV1beta2 = decisionv1beta2.NewForConfigOrDie(c)
 
 	cs.DiscoveryClient = discovery.NewDiscoveryClientForConfigOrDie(c)
 	return &cs
@@ -91,6 +104,7 @@ func NewForConfigOrDie(c *rest.Config) *Clientset {
 func New(c rest.Interface) *Clientset {
 	var cs Clientset
 	cs.decisionV1 = decisionv1.New(c)
+	cs.decisionV1beta2 = decisionv1beta2.New(c)
 
 	cs.DiscoveryClient = discovery.NewDiscoveryClient(c)
 	return &cs
@@ -22,6 +22,8 @@ import (
 	clientset "finupgroup.com/decision/traincrd/pkg/client/clientset/versioned"
 	decisionv1 "finupgroup.com/decision/traincrd/pkg/client/clientset/versioned/typed/apis/v1"
 	fakedecisionv1 "finupgroup.com/decision/traincrd/pkg/client/clientset/versioned/typed/apis/v1/fake"
+	decisionv1beta2 "finupgroup.com/decision/traincrd/pkg/client/clientset/versioned/typed/apis/v1beta2"
+	fakedecisionv1beta2 "finupgroup.com/decision/traincrd/pkg/client/clientset/versioned/typed/apis/v1beta2/fake"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/discovery"
@@ -80,3 +82,8 @@ var _ clientset.Interface = &Clientset{}
 func (c *Clientset) DecisionV1() decisionv1.DecisionV1Interface {
 	return &fakedecisionv1.FakeDecisionV1{Fake: &c.Fake}
 }
+
+// DecisionV1beta2 retrieves the DecisionV1beta2Client
+func (c *Clientset) DecisionV1beta2() decisionv1beta2.DecisionV1beta2Interface {
+	return &fakedecisionv1beta2.FakeDecisionV1beta2{Fake: &c.Fake}
+}
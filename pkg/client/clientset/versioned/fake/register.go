@@ -20,6 +20,7 @@ package fake
 
 import (
 	decisionv1 "finupgroup.com/decision/traincrd/pkg/apis/v1"
+	decisionv1beta2 "finupgroup.com/decision/traincrd/pkg/apis/v1beta2"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 	schema "k8s.io/apimachinery/pkg/runtime/schema"
@@ -32,6 +33,7 @@ var codecs = serializer.NewCodecFactory(scheme)
 var parameterCodec = runtime.NewParameterCodec(scheme)
 var localSchemeBuilder = runtime.SchemeBuilder{
 	decisionv1.AddToScheme,
+	decisionv1beta2.AddToScheme,
 }
 
 // AddToScheme adds all types of this clientset into the given scheme. This allows composition
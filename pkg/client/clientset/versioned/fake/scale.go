@@ -0,0 +1,11 @@
+package fake
+
+import (
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+)
+
+// Scale objects returned by reactors for GetScale and UpdateScale, see scheme/scale.go.
+func init() {
+	utilruntime.Must(autoscalingv1.AddToScheme(scheme))
+}
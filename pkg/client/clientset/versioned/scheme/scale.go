@@ -0,0 +1,12 @@
+package scheme
+
+import (
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+)
+
+// The scale subresource of Traincrd speaks autoscaling/v1 Scale, which client-gen does not register.
+// It is kept out of AddToScheme so that composing schemes only adds the decision types.
+func init() {
+	utilruntime.Must(autoscalingv1.AddToScheme(Scheme))
+}
@@ -28,6 +28,8 @@ type DecisionV1Interface interface {
 	RESTClient() rest.Interface
 	ClusterTraincrdsGetter
 	TraincrdsGetter
+	TraincrdSnapshotsGetter
+	WorkspaceTemplatesGetter
 }
 
 // DecisionV1Client is used to interact with features provided by the decision.finupgroup.com group.
@@ -43,6 +45,14 @@ func (c *DecisionV1Client) Traincrds(namespace string) TraincrdInterface {
 	return newTraincrds(c, namespace)
 }
 
+func (c *DecisionV1Client) TraincrdSnapshots(namespace string) TraincrdSnapshotInterface {
+	return newTraincrdSnapshots(c, namespace)
+}
+
+func (c *DecisionV1Client) WorkspaceTemplates() WorkspaceTemplateInterface {
+	return newWorkspaceTemplates(c)
+}
+
 // NewForConfig creates a new DecisionV1Client for the given config.
 func NewForConfig(c *rest.Config) (*DecisionV1Client, error) {
 	config := *c
@@ -27,7 +27,6 @@ import (
 	types "k8s.io/apimachinery/pkg/types"
 	watch "k8s.io/apimachinery/pkg/watch"
 	rest "k8s.io/client-go/rest"
-	autoscaling "k8s.io/api/autoscaling/v1"
 )
 
 // ClusterTraincrdsGetter has a method to return a ClusterTraincrdInterface.
@@ -40,16 +39,12 @@ type ClusterTraincrdsGetter interface {
 type ClusterTraincrdInterface interface {
 	Create(*v1.ClusterTraincrd) (*v1.ClusterTraincrd, error)
 	Update(*v1.ClusterTraincrd) (*v1.ClusterTraincrd, error)
-	UpdateStatus(*v1.ClusterTraincrd) (*v1.ClusterTraincrd, error)
 	Delete(name string, options *metav1.DeleteOptions) error
 	DeleteCollection(options *metav1.DeleteOptions, listOptions metav1.ListOptions) error
 	Get(name string, options metav1.GetOptions) (*v1.ClusterTraincrd, error)
 	List(opts metav1.ListOptions) (*v1.ClusterTraincrdList, error)
 	Watch(opts metav1.ListOptions) (watch.Interface, error)
 	Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1.ClusterTraincrd, err error)
-	GetScale(clusterTraincrdName string, options metav1.GetOptions) (*autoscaling.Scale, error)
-	UpdateScale(clusterTraincrdName string, scale *autoscaling.Scale) (*autoscaling.Scale, error)
-
 	ClusterTraincrdExpansion
 }
 
@@ -130,21 +125,6 @@ func (c *clusterTraincrds) Update(clusterTraincrd *v1.ClusterTraincrd) (result *
 	return
 }
 
-// UpdateStatus was generated because the type contains a Status member.
-// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
-
-func (c *clusterTraincrds) UpdateStatus(clusterTraincrd *v1.ClusterTraincrd) (result *v1.ClusterTraincrd, err error) {
-	result = &v1.ClusterTraincrd{}
-	err = c.client.Put().
-		Resource("clustertraincrds").
-		Name(clusterTraincrd.Name).
-		SubResource("status").
-		Body(clusterTraincrd).
-		Do().
-		Into(result)
-	return
-}
-
 // Delete takes name of the clusterTraincrd and deletes it. Returns an error if one occurs.
 func (c *clusterTraincrds) Delete(name string, options *metav1.DeleteOptions) error {
 	return c.client.Delete().
@@ -182,29 +162,3 @@ func (c *clusterTraincrds) Patch(name string, pt types.PatchType, data []byte, s
 		Into(result)
 	return
 }
-
-// GetScale takes name of the clusterTraincrd, and returns the corresponding autoscaling.Scale object, and an error if there is any.
-func (c *clusterTraincrds) GetScale(clusterTraincrdName string, options metav1.GetOptions) (result *autoscaling.Scale, err error) {
-	result = &autoscaling.Scale{}
-	err = c.client.Get().
-		Resource("clustertraincrds").
-		Name(clusterTraincrdName).
-		SubResource("scale").
-		VersionedParams(&options, scheme.ParameterCodec).
-		Do().
-		Into(result)
-	return
-}
-
-// UpdateScale takes the top resource name and the representation of a scale and updates it. Returns the server's representation of the scale, and an error, if there is any.
-func (c *clusterTraincrds) UpdateScale(clusterTraincrdName string, scale *autoscaling.Scale) (result *autoscaling.Scale, err error) {
-	result = &autoscaling.Scale{}
-	err = c.client.Put().
-		Resource("clustertraincrds").
-		Name(clusterTraincrdName).
-		SubResource("scale").
-		Body(scale).
-		Do().
-		Into(result)
-	return
-}
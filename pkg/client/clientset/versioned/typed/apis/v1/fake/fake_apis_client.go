@@ -36,6 +36,14 @@ func (c *FakeDecisionV1) Traincrds(namespace string) v1.TraincrdInterface {
 	return &FakeTraincrds{c, namespace}
 }
 
+func (c *FakeDecisionV1) TraincrdSnapshots(namespace string) v1.TraincrdSnapshotInterface {
+	return &FakeTraincrdSnapshots{c, namespace}
+}
+
+func (c *FakeDecisionV1) WorkspaceTemplates() v1.WorkspaceTemplateInterface {
+	return &FakeWorkspaceTemplates{c}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeDecisionV1) RESTClient() rest.Interface {
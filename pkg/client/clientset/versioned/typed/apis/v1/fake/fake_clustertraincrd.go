@@ -26,7 +26,6 @@ import (
 	types "k8s.io/apimachinery/pkg/types"
 	watch "k8s.io/apimachinery/pkg/watch"
 	testing "k8s.io/client-go/testing"
-	autoscaling "k8s.io/kubernetes/pkg/apis/autoscaling"
 )
 
 // FakeClusterTraincrds implements ClusterTraincrdInterface
@@ -95,17 +94,6 @@ func (c *FakeClusterTraincrds) Update(clusterTraincrd *apisv1.ClusterTraincrd) (
 	return obj.(*apisv1.ClusterTraincrd), err
 }
 
-// UpdateStatus was generated because the type contains a Status member.
-// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
-func (c *FakeClusterTraincrds) UpdateStatus(clusterTraincrd *apisv1.ClusterTraincrd) (*apisv1.ClusterTraincrd, error) {
-	obj, err := c.Fake.
-		Invokes(testing.NewRootUpdateSubresourceAction(clustertraincrdsResource, "status", clusterTraincrd), &apisv1.ClusterTraincrd{})
-	if obj == nil {
-		return nil, err
-	}
-	return obj.(*apisv1.ClusterTraincrd), err
-}
-
 // Delete takes name of the clusterTraincrd and deletes it. Returns an error if one occurs.
 func (c *FakeClusterTraincrds) Delete(name string, options *v1.DeleteOptions) error {
 	_, err := c.Fake.
@@ -130,23 +118,3 @@ func (c *FakeClusterTraincrds) Patch(name string, pt types.PatchType, data []byt
 	}
 	return obj.(*apisv1.ClusterTraincrd), err
 }
-
-// GetScale takes name of the clusterTraincrd, and returns the corresponding scale object, and an error if there is any.
-func (c *FakeClusterTraincrds) GetScale(clusterTraincrdName string, options v1.GetOptions) (result *autoscaling.Scale, err error) {
-	obj, err := c.Fake.
-		Invokes(testing.NewRootGetSubresourceAction(clustertraincrdsResource, "scale", clusterTraincrdName), &autoscaling.Scale{})
-	if obj == nil {
-		return nil, err
-	}
-	return obj.(*autoscaling.Scale), err
-}
-
-// UpdateScale takes the representation of a scale and updates it. Returns the server's representation of the scale, and an error, if there is any.
-func (c *FakeClusterTraincrds) UpdateScale(clusterTraincrdName string, scale *autoscaling.Scale) (result *autoscaling.Scale, err error) {
-	obj, err := c.Fake.
-		Invokes(testing.NewRootUpdateSubresourceAction(clustertraincrdsResource, "scale", scale), &autoscaling.Scale{})
-	if obj == nil {
-		return nil, err
-	}
-	return obj.(*autoscaling.Scale), err
-}
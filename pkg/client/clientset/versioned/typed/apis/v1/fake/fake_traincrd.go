@@ -20,6 +20,7 @@ package fake
 
 import (
 	apisv1 "finupgroup.com/decision/traincrd/pkg/apis/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	labels "k8s.io/apimachinery/pkg/labels"
 	schema "k8s.io/apimachinery/pkg/runtime/schema"
@@ -138,3 +139,25 @@ func (c *FakeTraincrds) Patch(name string, pt types.PatchType, data []byte, subr
 	}
 	return obj.(*apisv1.Traincrd), err
 }
+
+// GetScale takes name of the traincrd, and returns the corresponding scale object, and an error if there is any.
+func (c *FakeTraincrds) GetScale(traincrdName string, options v1.GetOptions) (result *autoscalingv1.Scale, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewGetSubresourceAction(traincrdsResource, c.ns, "scale", traincrdName), &autoscalingv1.Scale{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*autoscalingv1.Scale), err
+}
+
+// UpdateScale takes the representation of a scale and updates it. Returns the server's representation of the scale, and an error, if there is any.
+func (c *FakeTraincrds) UpdateScale(traincrdName string, scale *autoscalingv1.Scale) (result *autoscalingv1.Scale, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateSubresourceAction(traincrdsResource, "scale", c.ns, scale), &autoscalingv1.Scale{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*autoscalingv1.Scale), err
+}
@@ -21,3 +21,7 @@ package v1
 type ClusterTraincrdExpansion interface{}
 
 type TraincrdExpansion interface{}
+
+type TraincrdSnapshotExpansion interface{}
+
+type WorkspaceTemplateExpansion interface{}
@@ -0,0 +1,61 @@
+package v1
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+// the scale subresource is served by the apiserver as autoscaling/v1 Scale
+func TestScaleSubresource(t *testing.T) {
+	const path = "/apis/decision.finupgroup.com/v1/namespaces/wangxx/traincrds/notebook/scale"
+	var updated autoscalingv1.Scale
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != path {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		scale := autoscalingv1.Scale{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "autoscaling/v1", Kind: "Scale"},
+			ObjectMeta: metav1.ObjectMeta{Name: "notebook", Namespace: "wangxx"},
+			Spec:       autoscalingv1.ScaleSpec{Replicas: 1},
+			Status:     autoscalingv1.ScaleStatus{Replicas: 1, Selector: "app=notebook,channel=risk,username=wangxx"},
+		}
+		if r.Method == http.MethodPut {
+			body, _ := ioutil.ReadAll(r.Body)
+			if err := json.Unmarshal(body, &updated); err != nil {
+				t.Errorf("decode request: %v", err)
+			}
+			scale.Spec = updated.Spec
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(scale)
+	}))
+	defer server.Close()
+
+	client, err := NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	scale, err := client.Traincrds("wangxx").GetScale("notebook", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scale.Status.Selector != "app=notebook,channel=risk,username=wangxx" {
+		t.Errorf("unexpected selector %q", scale.Status.Selector)
+	}
+
+	scale.Spec.Replicas = 3
+	scale, err = client.Traincrds("wangxx").UpdateScale("notebook", scale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.APIVersion != "autoscaling/v1" || updated.Kind != "Scale" || scale.Spec.Replicas != 3 {
+		t.Errorf("expected an autoscaling/v1 Scale with 3 replicas, sent %+v got %+v", updated.TypeMeta, scale.Spec)
+	}
+}
@@ -23,6 +23,7 @@ import (
 
 	v1 "finupgroup.com/decision/traincrd/pkg/apis/v1"
 	scheme "finupgroup.com/decision/traincrd/pkg/client/clientset/versioned/scheme"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	types "k8s.io/apimachinery/pkg/types"
 	watch "k8s.io/apimachinery/pkg/watch"
@@ -46,6 +47,9 @@ type TraincrdInterface interface {
 	List(opts metav1.ListOptions) (*v1.TraincrdList, error)
 	Watch(opts metav1.ListOptions) (watch.Interface, error)
 	Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1.Traincrd, err error)
+	GetScale(traincrdName string, options metav1.GetOptions) (*autoscalingv1.Scale, error)
+	UpdateScale(traincrdName string, scale *autoscalingv1.Scale) (*autoscalingv1.Scale, error)
+
 	TraincrdExpansion
 }
 
@@ -189,3 +193,31 @@ func (c *traincrds) Patch(name string, pt types.PatchType, data []byte, subresou
 		Into(result)
 	return
 }
+
+// GetScale takes name of the traincrd, and returns the corresponding autoscalingv1.Scale object, and an error if there is any.
+func (c *traincrds) GetScale(traincrdName string, options metav1.GetOptions) (result *autoscalingv1.Scale, err error) {
+	result = &autoscalingv1.Scale{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("traincrds").
+		Name(traincrdName).
+		SubResource("scale").
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do().
+		Into(result)
+	return
+}
+
+// UpdateScale takes the top resource name and the representation of a scale and updates it. Returns the server's representation of the scale, and an error, if there is any.
+func (c *traincrds) UpdateScale(traincrdName string, scale *autoscalingv1.Scale) (result *autoscalingv1.Scale, err error) {
+	result = &autoscalingv1.Scale{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("traincrds").
+		Name(traincrdName).
+		SubResource("scale").
+		Body(scale).
+		Do().
+		Into(result)
+	return
+}
@@ -28,6 +28,10 @@ type Interface interface {
 	ClusterTraincrds() ClusterTraincrdInformer
 	// Traincrds returns a TraincrdInformer.
 	Traincrds() TraincrdInformer
+	// TraincrdSnapshots returns a TraincrdSnapshotInformer.
+	TraincrdSnapshots() TraincrdSnapshotInformer
+	// WorkspaceTemplates returns a WorkspaceTemplateInformer.
+	WorkspaceTemplates() WorkspaceTemplateInformer
 }
 
 type version struct {
@@ -50,3 +54,13 @@ func (v *version) ClusterTraincrds() ClusterTraincrdInformer {
 func (v *version) Traincrds() TraincrdInformer {
 	return &traincrdInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
+
+// TraincrdSnapshots returns a TraincrdSnapshotInformer.
+func (v *version) TraincrdSnapshots() TraincrdSnapshotInformer {
+	return &traincrdSnapshotInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// WorkspaceTemplates returns a WorkspaceTemplateInformer.
+func (v *version) WorkspaceTemplates() WorkspaceTemplateInformer {
+	return &workspaceTemplateInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
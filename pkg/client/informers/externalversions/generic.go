@@ -22,6 +22,7 @@ import (
 	"fmt"
 
 	v1 "finupgroup.com/decision/traincrd/pkg/apis/v1"
+	v1beta2 "finupgroup.com/decision/traincrd/pkg/apis/v1beta2"
 	schema "k8s.io/apimachinery/pkg/runtime/schema"
 	cache "k8s.io/client-go/tools/cache"
 )
@@ -57,6 +58,14 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Decision().V1().ClusterTraincrds().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("traincrds"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Decision().V1().Traincrds().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("traincrdsnapshots"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Decision().V1().TraincrdSnapshots().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("workspacetemplates"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Decision().V1().WorkspaceTemplates().Informer()}, nil
+
+		// Group=decision.finupgroup.com, Version=v1beta2
+	case v1beta2.SchemeGroupVersion.WithResource("traincrds"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Decision().V1beta2().Traincrds().Informer()}, nil
 
 	}
 
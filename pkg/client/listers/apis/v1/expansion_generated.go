@@ -29,3 +29,15 @@ type TraincrdListerExpansion interface{}
 // TraincrdNamespaceListerExpansion allows custom methods to be added to
 // TraincrdNamespaceLister.
 type TraincrdNamespaceListerExpansion interface{}
+
+// TraincrdSnapshotListerExpansion allows custom methods to be added to
+// TraincrdSnapshotLister.
+type TraincrdSnapshotListerExpansion interface{}
+
+// TraincrdSnapshotNamespaceListerExpansion allows custom methods to be added to
+// TraincrdSnapshotNamespaceLister.
+type TraincrdSnapshotNamespaceListerExpansion interface{}
+
+// WorkspaceTemplateListerExpansion allows custom methods to be added to
+// WorkspaceTemplateLister.
+type WorkspaceTemplateListerExpansion interface{}
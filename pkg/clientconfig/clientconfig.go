@@ -0,0 +1,160 @@
+// Package clientconfig builds the rest.Config every train tool hands to clientsetTrain.NewForConfig
+// and kubernetes.NewForConfig, from flags with environment variable defaults.
+//
+// Without a kubeconfig, context or master the in-cluster config is used, falling back to
+// ~/.kube/config when not running in a pod.
+package clientconfig
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// environment variables providing the defaults of the flags
+const (
+	EnvKubeconfig        = "KUBECONFIG"
+	EnvContext           = "TRAIN_KUBE_CONTEXT"
+	EnvMaster            = "TRAIN_KUBE_MASTER"
+	EnvQPS               = "TRAIN_KUBE_API_QPS"
+	EnvBurst             = "TRAIN_KUBE_API_BURST"
+	EnvUserAgent         = "TRAIN_USER_AGENT"
+	EnvImpersonateUser   = "TRAIN_IMPERSONATE_USER"
+	EnvImpersonateGroups = "TRAIN_IMPERSONATE_GROUPS"
+)
+
+// Options are the connection settings to the API server.
+type Options struct {
+	// Kubeconfig is the path of the kubeconfig file; KUBECONFIG may hold a list of paths.
+	Kubeconfig string
+	Context    string
+	Master     string
+	// QPS and Burst limit the client side request rate, zero keeps the client-go defaults.
+	QPS       float64
+	Burst     int
+	UserAgent string
+	// ImpersonateUser and ImpersonateGroups make every request act as another user.
+	ImpersonateUser   string
+	ImpersonateGroups []string
+}
+
+// NewOptions returns Options holding the values of the environment variables.
+func NewOptions() (*Options, error) {
+	o := &Options{
+		Kubeconfig:      os.Getenv(EnvKubeconfig),
+		Context:         os.Getenv(EnvContext),
+		Master:          os.Getenv(EnvMaster),
+		UserAgent:       os.Getenv(EnvUserAgent),
+		ImpersonateUser: os.Getenv(EnvImpersonateUser),
+	}
+	if groups := os.Getenv(EnvImpersonateGroups); groups != "" {
+		o.ImpersonateGroups = strings.Split(groups, ",")
+	}
+
+	if qps := os.Getenv(EnvQPS); qps != "" {
+		value, err := strconv.ParseFloat(qps, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%s=%q: %v", EnvQPS, qps, err)
+		}
+		o.QPS = value
+	}
+	if burst := os.Getenv(EnvBurst); burst != "" {
+		value, err := strconv.Atoi(burst)
+		if err != nil {
+			return nil, fmt.Errorf("%s=%q: %v", EnvBurst, burst, err)
+		}
+		o.Burst = value
+	}
+	return o, nil
+}
+
+// AddFlags registers the options on fs, the current values become the flag defaults.
+func (o *Options) AddFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.Kubeconfig, "kubeconfig", o.Kubeconfig, "path to a kubeconfig, only required when running out of cluster (env "+EnvKubeconfig+")")
+	fs.StringVar(&o.Context, "context", o.Context, "kubeconfig context to use (env "+EnvContext+")")
+	fs.StringVar(&o.Master, "master", o.Master, "address of the API server, overrides the one in the kubeconfig (env "+EnvMaster+")")
+	fs.Float64Var(&o.QPS, "kube-api-qps", o.QPS, "queries per second allowed against the API server, 0 keeps the client default (env "+EnvQPS+")")
+	fs.IntVar(&o.Burst, "kube-api-burst", o.Burst, "burst allowed against the API server, 0 keeps the client default (env "+EnvBurst+")")
+	fs.StringVar(&o.UserAgent, "user-agent", o.UserAgent, "user agent sent to the API server (env "+EnvUserAgent+")")
+	fs.StringVar(&o.ImpersonateUser, "as", o.ImpersonateUser, "user to impersonate (env "+EnvImpersonateUser+")")
+	fs.Var(&stringList{values: &o.ImpersonateGroups}, "as-group", "group to impersonate, may be repeated (env "+EnvImpersonateGroups+", comma separated)")
+}
+
+// Config returns the rest.Config described by the options.
+func (o *Options) Config() (*rest.Config, error) {
+	config, err := o.baseConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if o.QPS > 0 {
+		config.QPS = float32(o.QPS)
+	}
+	if o.Burst > 0 {
+		config.Burst = o.Burst
+	}
+	if o.UserAgent != "" {
+		config.UserAgent = o.UserAgent
+	}
+	if o.ImpersonateUser != "" || len(o.ImpersonateGroups) > 0 {
+		config.Impersonate = rest.ImpersonationConfig{
+			UserName: o.ImpersonateUser,
+			Groups:   o.ImpersonateGroups,
+		}
+	}
+	return config, nil
+}
+
+func (o *Options) baseConfig() (*rest.Config, error) {
+	if o.Kubeconfig == "" && o.Context == "" && o.Master == "" {
+		if config, err := rest.InClusterConfig(); err == nil {
+			return config, nil
+		}
+	}
+
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if o.Kubeconfig != "" {
+		rules.Precedence = filepath.SplitList(o.Kubeconfig)
+		if len(rules.Precedence) == 1 {
+			rules.ExplicitPath = o.Kubeconfig
+		}
+	}
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: o.Context}
+	if o.Master != "" {
+		overrides.ClusterInfo.Server = o.Master
+	}
+
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("load kubeconfig: %v", err)
+	}
+	return config, nil
+}
+
+// stringList is a flag.Value collecting every occurrence of a flag, the first one replaces the default
+type stringList struct {
+	values *[]string
+	set    bool
+}
+
+func (l *stringList) String() string {
+	if l.values == nil {
+		return ""
+	}
+	return strings.Join(*l.values, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	if !l.set {
+		*l.values = nil
+		l.set = true
+	}
+	*l.values = append(*l.values, value)
+	return nil
+}
@@ -0,0 +1,113 @@
+package clientconfig
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const kubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: prod
+  cluster:
+    server: https://prod.example.com:6443
+- name: staging
+  cluster:
+    server: https://staging.example.com:6443
+users:
+- name: dev
+  user:
+    token: secret
+contexts:
+- name: prod
+  context: {cluster: prod, user: dev}
+- name: staging
+  context: {cluster: staging, user: dev}
+current-context: prod
+`
+
+func writeKubeconfig(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "clientconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "config")
+	if err := ioutil.WriteFile(path, []byte(kubeconfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestEnvDefaultsAndFlags(t *testing.T) {
+	path := writeKubeconfig(t)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	env := map[string]string{
+		EnvKubeconfig:        path,
+		EnvContext:           "staging",
+		EnvQPS:               "50",
+		EnvBurst:             "100",
+		EnvImpersonateGroups: "system:authenticated,train:admins",
+	}
+	for k, v := range env {
+		os.Setenv(k, v)
+		defer os.Unsetenv(k)
+	}
+
+	options, err := NewOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	options.AddFlags(fs)
+	if err := fs.Parse([]string{"--as=wangxx", "--as-group=train:users", "--user-agent=traincli/1.0", "--kube-api-burst=20"}); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := options.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Host != "https://staging.example.com:6443" {
+		t.Errorf("expected the staging context from the environment, got host %q", config.Host)
+	}
+	if config.BearerToken != "secret" {
+		t.Errorf("expected the token of the kubeconfig user, got %q", config.BearerToken)
+	}
+	if config.QPS != 50 || config.Burst != 20 {
+		t.Errorf("expected qps 50 from the environment and burst 20 from the flag, got %v/%v", config.QPS, config.Burst)
+	}
+	if config.UserAgent != "traincli/1.0" {
+		t.Errorf("unexpected user agent %q", config.UserAgent)
+	}
+	if config.Impersonate.UserName != "wangxx" || !reflect.DeepEqual(config.Impersonate.Groups, []string{"train:users"}) {
+		t.Errorf("expected --as-group to replace the environment groups, got %+v", config.Impersonate)
+	}
+}
+
+func TestMasterOverridesKubeconfig(t *testing.T) {
+	path := writeKubeconfig(t)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	options := &Options{Kubeconfig: path, Master: "https://127.0.0.1:8443"}
+	config, err := options.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Host != "https://127.0.0.1:8443" {
+		t.Errorf("expected --master to win, got host %q", config.Host)
+	}
+}
+
+func TestInvalidEnv(t *testing.T) {
+	os.Setenv(EnvQPS, "fast")
+	defer os.Unsetenv(EnvQPS)
+
+	if _, err := NewOptions(); err == nil {
+		t.Errorf("expected an error for %s=fast", EnvQPS)
+	}
+}
@@ -0,0 +1,477 @@
+// Package config holds the platform settings of the train controller: ingress host, storage
+// class, service account, public volumes and so on. They are read from a versioned YAML file,
+// usually a mounted ConfigMap, e.g.
+//
+//   apiVersion: decision.finupgroup.com/v1alpha1
+//   kind: ControllerConfig
+//   profile: prod
+//   ingress:
+//     host: train-lab.finupgroup.com
+//   storage:
+//     storageClassName: cephfs
+//   profiles:
+//     dev:
+//       ingress:
+//         host: mt.10.10.184.25.nip.io
+//
+// Fields left out keep the values of Default(). A profile is a partial config applied over the
+// top level one, selected by the profile field or the --profile flag.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	APIVersion = "decision.finupgroup.com/v1alpha1"
+	Kind       = "ControllerConfig"
+)
+
+// Config is the controller configuration after the profile has been applied.
+type Config struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	// Profile names the entry of Profiles applied over this config.
+	Profile   string          `json:"profile,omitempty"`
+	Ingress   IngressConfig   `json:"ingress"`
+	Workspace WorkspaceConfig `json:"workspace"`
+	Storage   StorageConfig   `json:"storage"`
+	Archive   ArchiveConfig   `json:"archive"`
+	Templates TemplatesConfig `json:"templates"`
+	Culling   CullingConfig   `json:"culling"`
+	// Scheduling applies to spec.schedule of every Traincrd.
+	Scheduling SchedulingConfig `json:"scheduling"`
+	// Profiles are partial configs keyed by environment name.
+	Profiles map[string]json.RawMessage `json:"profiles,omitempty"`
+}
+
+type IngressConfig struct {
+	// Host every workspace Ingress routes, workspaces are told apart by path.
+	Host string `json:"host"`
+	// SuspendedService is the DNS name of the Service serving the "workspace suspended" page, the Service
+	// of a suspended workspace becomes an ExternalName to it. Empty keeps the Service without endpoints.
+	SuspendedService string `json:"suspendedService"`
+}
+
+type WorkspaceConfig struct {
+	// Port the notebook listens on, exposed by the Service and Ingress.
+	Port               int32             `json:"port"`
+	ServiceAccountName string            `json:"serviceAccountName"`
+	ImagePullPolicy    corev1.PullPolicy `json:"imagePullPolicy"`
+	// TerminationGracePeriodSeconds of the workspace pods.
+	TerminationGracePeriodSeconds int64 `json:"terminationGracePeriodSeconds"`
+	// PublicStorage is shared by every workspace and also holds the archives.
+	PublicStorage     PublicVolume `json:"publicStorage"`
+	PublicLibsStorage PublicVolume `json:"publicLibsStorage"`
+	// SharedClaims lists the claims the workspaces of a channel may mount through spec.volumes,
+	// keyed by the channel label. A channel left out may not mount any claim.
+	SharedClaims map[string][]string `json:"sharedClaims,omitempty"`
+}
+
+// SharedClaimAllowed tells whether the workspaces of channel may mount claim.
+func (c WorkspaceConfig) SharedClaimAllowed(channel, claim string) bool {
+	for _, allowed := range c.SharedClaims[channel] {
+		if allowed == claim {
+			return true
+		}
+	}
+	return false
+}
+
+// PublicVolume is an existing claim mounted into every workspace.
+type PublicVolume struct {
+	ClaimName string `json:"claimName"`
+	MountPath string `json:"mountPath"`
+}
+
+type StorageConfig struct {
+	// VolumeClass is the default class of the workspace volumes, used when neither
+	// spec.storage.class nor Channels select one of Classes.
+	VolumeClass
+	// DefaultCapacity is used when spec.capacity is empty.
+	DefaultCapacity string `json:"defaultCapacity"`
+	// VolumeSnapshotClassName is used by the TraincrdSnapshots that do not name one, empty means
+	// the default VolumeSnapshotClass of the cluster.
+	VolumeSnapshotClassName string `json:"volumeSnapshotClassName,omitempty"`
+	// CSIClone tells that the storage class supports CSI volume cloning. spec.cloneFrom then
+	// clones within a namespace through the PVC dataSource, otherwise a Job copies the data.
+	CSIClone bool `json:"csiClone,omitempty"`
+	// CloneStagingClaim is the claim the clone Jobs pass the data through from the namespace of the
+	// source to the one of the clone, it must exist in both on the same storage. The staged archive
+	// holds the whole source volume, so no workspace may mount the claim.
+	CloneStagingClaim string `json:"cloneStagingClaim"`
+	// Classes are the volume classes spec.storage.class may select, keyed by name.
+	Classes map[string]VolumeClass `json:"classes,omitempty"`
+	// Channels selects the class of Classes used by the workspaces of a channel that do not set
+	// spec.storage.class, keyed by the channel label.
+	Channels map[string]string `json:"channels,omitempty"`
+}
+
+// StorageProvider implements the workspace volumes of a VolumeClass.
+type StorageProvider string
+
+const (
+	// ProviderCephFS provisions through a CephFS storage class with the ceph.com/cephfs provisioner.
+	ProviderCephFS StorageProvider = "CephFS"
+	// ProviderCSI provisions through any storage class, e.g. a CSI driver.
+	ProviderCSI StorageProvider = "CSI"
+	// ProviderHostPath binds every workspace to a hostPath volume, for single node development clusters.
+	ProviderHostPath StorageProvider = "HostPath"
+	// ProviderNFS binds every workspace to a directory of an NFS export.
+	ProviderNFS StorageProvider = "NFS"
+)
+
+// VolumeClass describes how workspace volumes are provisioned.
+type VolumeClass struct {
+	// Provider defaults to CephFS.
+	Provider StorageProvider `json:"provider,omitempty"`
+	// StorageClassName of the CephFS and CSI providers.
+	StorageClassName string `json:"storageClassName,omitempty"`
+	// Annotations are set on every workspace PVC in addition to the ones of the provider.
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// AccessModes override the ones of the provider, ReadWriteMany for CephFS and NFS,
+	// ReadWriteOnce for CSI and HostPath.
+	AccessModes []corev1.PersistentVolumeAccessMode `json:"accessModes,omitempty"`
+	// Server is the address of the NFS server.
+	Server string `json:"server,omitempty"`
+	// Path is the host directory of HostPath or the exported directory of NFS, each workspace
+	// gets a sub directory of it.
+	Path string `json:"path,omitempty"`
+	// Node is the kubernetes.io/hostname label of the node holding the HostPath directories,
+	// the volumes, and so the workspaces, are pinned to it.
+	Node string `json:"node,omitempty"`
+}
+
+// ClassFor returns the class of a workspace: the named one of Classes, else the class of its
+// channel, else the default class. ok is false when name is not in Classes.
+func (c StorageConfig) ClassFor(name, channel string) (class VolumeClass, ok bool) {
+	if name == "" {
+		name = c.Channels[channel]
+	}
+	if name == "" {
+		return c.VolumeClass, true
+	}
+	class, ok = c.Classes[name]
+	return class, ok
+}
+
+type ArchiveConfig struct {
+	// Image of the Job packing a workspace for retainPolicy Archive, it needs tar.
+	Image string `json:"image"`
+	// Dir is the archive directory, inside the public storage mount.
+	Dir string `json:"dir"`
+	// Timeout is the active deadline of the Job, a stuck archive fails instead of blocking the deletion forever.
+	Timeout metav1.Duration `json:"timeout"`
+}
+
+type TemplatesConfig struct {
+	// Namespace holds the ConfigMaps registering workspace templates, next to the
+	// cluster scoped WorkspaceTemplates.
+	Namespace string `json:"namespace"`
+}
+
+// CullingConfig scales idle workspaces to zero, the PVC is kept.
+type CullingConfig struct {
+	// IdleTimeout is how long a workspace may report no activity before it is culled, 0 disables culling.
+	IdleTimeout metav1.Duration `json:"idleTimeout"`
+	// Channels overrides IdleTimeout by the channel label of the Traincrd, 0 exempts the channel.
+	Channels map[string]metav1.Duration `json:"channels,omitempty"`
+	// Interval between two probes of every running workspace.
+	Interval metav1.Duration `json:"interval"`
+	// Path of the activity endpoint on the workspace Service, answering {"last_activity": "<RFC 3339>"}
+	// like the Jupyter /api/status. {name} is replaced by the workspace name, the base url of Jupyter.
+	Path string `json:"path"`
+}
+
+// IdleTimeoutFor returns the idle timeout of a channel, 0 when its workspaces are never culled.
+func (c CullingConfig) IdleTimeoutFor(channel string) time.Duration {
+	if timeout, ok := c.Channels[channel]; ok {
+		return timeout.Duration
+	}
+	return c.IdleTimeout.Duration
+}
+
+type SchedulingConfig struct {
+	// TimeZone the cron expressions of spec.schedule are evaluated in, an IANA name.
+	TimeZone string `json:"timeZone"`
+}
+
+// Location loads TimeZone.
+func (c SchedulingConfig) Location() (*time.Location, error) {
+	return time.LoadLocation(c.TimeZone)
+}
+
+// Default returns the configuration the controller used before it was configurable.
+func Default() *Config {
+	return &Config{
+		APIVersion: APIVersion,
+		Kind:       Kind,
+		Ingress: IngressConfig{
+			Host:             "train-lab.finupgroup.com",
+			SuspendedService: "decisiontrain-suspended.default.svc.cluster.local",
+		},
+		Workspace: WorkspaceConfig{
+			Port:                          8888,
+			ServiceAccountName:            "fission-svc",
+			ImagePullPolicy:               corev1.PullAlways,
+			TerminationGracePeriodSeconds: 60,
+			PublicStorage:                 PublicVolume{ClaimName: "trainlabpublicstorage", MountPath: "/public"},
+			PublicLibsStorage:             PublicVolume{ClaimName: "trainlabpublic-libs-storage", MountPath: "/usr/crd/lib/"},
+		},
+		Storage: StorageConfig{
+			VolumeClass:       VolumeClass{Provider: ProviderCephFS, StorageClassName: "cephfs"},
+			DefaultCapacity:   "1Gi",
+			CloneStagingClaim: "trainlab-clone-staging",
+		},
+		Archive:   ArchiveConfig{Image: "busybox:1.31", Dir: "/public/archive", Timeout: metav1.Duration{Duration: time.Hour}},
+		Templates: TemplatesConfig{Namespace: "default"},
+		Culling: CullingConfig{
+			Interval: metav1.Duration{Duration: 5 * time.Minute},
+			Path:     "/{name}/api/status",
+		},
+		Scheduling: SchedulingConfig{TimeZone: "Asia/Shanghai"},
+		Profiles: map[string]json.RawMessage{
+			"dev": json.RawMessage(`{"ingress":{"host":"mt.10.10.184.25.nip.io"}}`),
+		},
+	}
+}
+
+// Load reads the config file at path. A non empty profile overrides the profile field of the file.
+func Load(path, profile string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c, err := Parse(data, profile)
+	if err != nil {
+		return nil, fmt.Errorf("controller config %s: %v", path, err)
+	}
+	return c, nil
+}
+
+// Parse decodes and validates a config document over Default().
+func Parse(data []byte, profile string) (*Config, error) {
+	c := Default()
+	// the defaults profiles only apply when the file has none
+	c.Profiles = nil
+	if err := yaml.UnmarshalStrict(data, c); err != nil {
+		return nil, err
+	}
+	if c.Profiles == nil {
+		c.Profiles = Default().Profiles
+	}
+	if c.APIVersion != APIVersion || c.Kind != Kind {
+		return nil, fmt.Errorf("unsupported config %s %s, expect apiVersion %s and kind %s", c.APIVersion, c.Kind, APIVersion, Kind)
+	}
+
+	if err := c.ApplyProfile(profile); err != nil {
+		return nil, err
+	}
+	if errs := c.Validate(); len(errs) > 0 {
+		return nil, errs.ToAggregate()
+	}
+	return c, nil
+}
+
+// ApplyProfile overlays the named profile, or the profile field when name is empty.
+func (c *Config) ApplyProfile(name string) error {
+	if name == "" {
+		name = c.Profile
+	}
+	if name == "" {
+		return nil
+	}
+	overlay, ok := c.Profiles[name]
+	if !ok {
+		return fmt.Errorf("profile %q not found", name)
+	}
+
+	// the overlay may only change settings, not the document type or the profiles
+	partial := struct {
+		Ingress   *IngressConfig   `json:"ingress,omitempty"`
+		Workspace *WorkspaceConfig `json:"workspace,omitempty"`
+		Storage   *StorageConfig   `json:"storage,omitempty"`
+		Archive   *ArchiveConfig   `json:"archive,omitempty"`
+		Templates *TemplatesConfig `json:"templates,omitempty"`
+		Culling   *CullingConfig   `json:"culling,omitempty"`
+
+		Scheduling *SchedulingConfig `json:"scheduling,omitempty"`
+	}{&c.Ingress, &c.Workspace, &c.Storage, &c.Archive, &c.Templates, &c.Culling, &c.Scheduling}
+	if err := yaml.UnmarshalStrict(overlay, &partial); err != nil {
+		return fmt.Errorf("profile %q: %v", name, err)
+	}
+	c.Profile = name
+	return nil
+}
+
+// Validate returns every invalid setting.
+func (c *Config) Validate() field.ErrorList {
+	var errs field.ErrorList
+
+	if c.Ingress.Host == "" {
+		errs = append(errs, field.Required(field.NewPath("ingress", "host"), ""))
+	} else {
+		for _, msg := range validation.IsDNS1123Subdomain(c.Ingress.Host) {
+			errs = append(errs, field.Invalid(field.NewPath("ingress", "host"), c.Ingress.Host, msg))
+		}
+	}
+
+	workspace := field.NewPath("workspace")
+	for _, msg := range validation.IsValidPortNum(int(c.Workspace.Port)) {
+		errs = append(errs, field.Invalid(workspace.Child("port"), c.Workspace.Port, msg))
+	}
+	for _, msg := range validation.IsDNS1123Subdomain(c.Workspace.ServiceAccountName) {
+		errs = append(errs, field.Invalid(workspace.Child("serviceAccountName"), c.Workspace.ServiceAccountName, msg))
+	}
+	switch c.Workspace.ImagePullPolicy {
+	case corev1.PullAlways, corev1.PullIfNotPresent, corev1.PullNever:
+	default:
+		errs = append(errs, field.NotSupported(workspace.Child("imagePullPolicy"), c.Workspace.ImagePullPolicy,
+			[]string{string(corev1.PullAlways), string(corev1.PullIfNotPresent), string(corev1.PullNever)}))
+	}
+	if c.Workspace.TerminationGracePeriodSeconds < 0 {
+		errs = append(errs, field.Invalid(workspace.Child("terminationGracePeriodSeconds"), c.Workspace.TerminationGracePeriodSeconds, "must be non-negative"))
+	}
+	errs = append(errs, c.Workspace.PublicStorage.validate(workspace.Child("publicStorage"))...)
+	errs = append(errs, c.Workspace.PublicLibsStorage.validate(workspace.Child("publicLibsStorage"))...)
+	for channel, claims := range c.Workspace.SharedClaims {
+		for i, claim := range claims {
+			for _, msg := range validation.IsDNS1123Subdomain(claim) {
+				errs = append(errs, field.Invalid(workspace.Child("sharedClaims").Key(channel).Index(i), claim, msg))
+			}
+		}
+	}
+
+	storage := field.NewPath("storage")
+	errs = append(errs, c.Storage.VolumeClass.validate(storage)...)
+	for name, class := range c.Storage.Classes {
+		for _, msg := range validation.IsDNS1123Label(name) {
+			errs = append(errs, field.Invalid(storage.Child("classes").Key(name), name, msg))
+		}
+		errs = append(errs, class.validate(storage.Child("classes").Key(name))...)
+	}
+	for channel, name := range c.Storage.Channels {
+		if _, ok := c.Storage.Classes[name]; !ok {
+			errs = append(errs, field.NotFound(storage.Child("channels").Key(channel), name))
+		}
+	}
+	if _, err := resource.ParseQuantity(c.Storage.DefaultCapacity); err != nil {
+		errs = append(errs, field.Invalid(storage.Child("defaultCapacity"), c.Storage.DefaultCapacity, err.Error()))
+	}
+	for _, msg := range validation.IsDNS1123Subdomain(c.Storage.CloneStagingClaim) {
+		errs = append(errs, field.Invalid(storage.Child("cloneStagingClaim"), c.Storage.CloneStagingClaim, msg))
+	}
+	if c.workspaceMounts(c.Storage.CloneStagingClaim) {
+		errs = append(errs, field.Invalid(storage.Child("cloneStagingClaim"), c.Storage.CloneStagingClaim, "must not be mounted by workspaces"))
+	}
+	if c.Storage.VolumeSnapshotClassName != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(c.Storage.VolumeSnapshotClassName) {
+			errs = append(errs, field.Invalid(storage.Child("volumeSnapshotClassName"), c.Storage.VolumeSnapshotClassName, msg))
+		}
+	}
+
+	archive := field.NewPath("archive")
+	if c.Archive.Image == "" {
+		errs = append(errs, field.Required(archive.Child("image"), ""))
+	}
+	if !path.IsAbs(c.Archive.Dir) || !strings.HasPrefix(path.Clean(c.Archive.Dir)+"/", path.Clean(c.Workspace.PublicStorage.MountPath)+"/") {
+		errs = append(errs, field.Invalid(archive.Child("dir"), c.Archive.Dir, "must be inside workspace.publicStorage.mountPath "+c.Workspace.PublicStorage.MountPath))
+	}
+	if c.Archive.Timeout.Duration < time.Second {
+		errs = append(errs, field.Invalid(archive.Child("timeout"), c.Archive.Timeout.Duration.String(), "must be at least 1s"))
+	}
+
+	for _, msg := range validation.IsDNS1123Label(c.Templates.Namespace) {
+		errs = append(errs, field.Invalid(field.NewPath("templates", "namespace"), c.Templates.Namespace, msg))
+	}
+
+	culling := field.NewPath("culling")
+	if c.Culling.IdleTimeout.Duration < 0 {
+		errs = append(errs, field.Invalid(culling.Child("idleTimeout"), c.Culling.IdleTimeout.Duration.String(), "must be non-negative"))
+	}
+	for channel, timeout := range c.Culling.Channels {
+		if timeout.Duration < 0 {
+			errs = append(errs, field.Invalid(culling.Child("channels").Key(channel), timeout.Duration.String(), "must be non-negative"))
+		}
+	}
+	if c.Culling.Interval.Duration <= 0 {
+		errs = append(errs, field.Invalid(culling.Child("interval"), c.Culling.Interval.Duration.String(), "must be positive"))
+	}
+	if !strings.HasPrefix(c.Culling.Path, "/") {
+		errs = append(errs, field.Invalid(culling.Child("path"), c.Culling.Path, "must start with /"))
+	}
+	if _, err := c.Scheduling.Location(); c.Scheduling.TimeZone == "" || err != nil {
+		errs = append(errs, field.Invalid(field.NewPath("scheduling", "timeZone"), c.Scheduling.TimeZone, "must be an IANA time zone, e.g. Asia/Shanghai"))
+	}
+	return errs
+}
+
+// workspaceMounts tells whether workspaces may mount claim, as a public volume or a shared claim.
+func (c *Config) workspaceMounts(claim string) bool {
+	if claim == c.Workspace.PublicStorage.ClaimName || claim == c.Workspace.PublicLibsStorage.ClaimName {
+		return true
+	}
+	for _, claims := range c.Workspace.SharedClaims {
+		for _, shared := range claims {
+			if shared == claim {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (v PublicVolume) validate(fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	for _, msg := range validation.IsDNS1123Subdomain(v.ClaimName) {
+		errs = append(errs, field.Invalid(fldPath.Child("claimName"), v.ClaimName, msg))
+	}
+	if !path.IsAbs(v.MountPath) {
+		errs = append(errs, field.Invalid(fldPath.Child("mountPath"), v.MountPath, "must be an absolute path"))
+	}
+	return errs
+}
+
+func (c VolumeClass) validate(fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	switch c.Provider {
+	case "", ProviderCephFS, ProviderCSI:
+		for _, msg := range validation.IsDNS1123Subdomain(c.StorageClassName) {
+			errs = append(errs, field.Invalid(fldPath.Child("storageClassName"), c.StorageClassName, msg))
+		}
+	case ProviderHostPath, ProviderNFS:
+		if !path.IsAbs(c.Path) {
+			errs = append(errs, field.Invalid(fldPath.Child("path"), c.Path, "must be an absolute path"))
+		}
+		if c.Provider == ProviderNFS && c.Server == "" {
+			errs = append(errs, field.Required(fldPath.Child("server"), ""))
+		}
+		if c.Provider == ProviderHostPath && c.Node == "" {
+			errs = append(errs, field.Required(fldPath.Child("node"), "the node holding the directories"))
+		}
+	default:
+		errs = append(errs, field.NotSupported(fldPath.Child("provider"), c.Provider,
+			[]string{string(ProviderCephFS), string(ProviderCSI), string(ProviderHostPath), string(ProviderNFS)}))
+	}
+	for i, mode := range c.AccessModes {
+		switch mode {
+		case corev1.ReadWriteOnce, corev1.ReadOnlyMany, corev1.ReadWriteMany:
+		default:
+			errs = append(errs, field.NotSupported(fldPath.Child("accessModes").Index(i), mode,
+				[]string{string(corev1.ReadWriteOnce), string(corev1.ReadOnlyMany), string(corev1.ReadWriteMany)}))
+		}
+	}
+	return errs
+}
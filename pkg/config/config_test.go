@@ -0,0 +1,108 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// the example shipped in the deployment must spell out the built-in defaults
+func TestExampleMatchesDefaults(t *testing.T) {
+	c, err := Load("testdata/config.yaml", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Default()
+	c.Profile, c.Profiles, expected.Profiles = "", nil, nil
+	if !reflect.DeepEqual(c, expected) {
+		t.Errorf("testdata/config.yaml differs from Default():\n%+v\n%+v", c, expected)
+	}
+}
+
+func TestProfile(t *testing.T) {
+	c, err := Load("testdata/config.yaml", "dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Profile != "dev" || c.Ingress.Host != "mt.10.10.184.25.nip.io" {
+		t.Errorf("expected the dev ingress host, got profile %q host %q", c.Profile, c.Ingress.Host)
+	}
+	if c.Storage.StorageClassName != "cephfs" {
+		t.Errorf("the profile must keep the settings it does not set, got storage class %q", c.Storage.StorageClassName)
+	}
+
+	if _, err := Load("testdata/config.yaml", "staging"); err == nil {
+		t.Errorf("expected an error for a missing profile")
+	}
+}
+
+func TestPartialConfig(t *testing.T) {
+	c, err := Parse([]byte(`
+apiVersion: decision.finupgroup.com/v1alpha1
+kind: ControllerConfig
+workspace:
+  serviceAccountName: train-workspace
+`), "dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Workspace.ServiceAccountName != "train-workspace" || c.Workspace.Port != 8888 {
+		t.Errorf("expected the service account to be replaced and the port kept, got %+v", c.Workspace)
+	}
+	if c.Ingress.Host != "mt.10.10.184.25.nip.io" {
+		t.Errorf("expected the default dev profile when the file has no profiles, got host %q", c.Ingress.Host)
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	cases := map[string]struct {
+		config string
+		err    string
+	}{
+		"unknown field": {
+			config: "apiVersion: decision.finupgroup.com/v1alpha1\nkind: ControllerConfig\ningress:\n  hostname: a.example.com\n",
+			err:    `unknown field "hostname"`,
+		},
+		"version": {
+			config: "apiVersion: decision.finupgroup.com/v1\nkind: ControllerConfig\n",
+			err:    "unsupported config",
+		},
+		"validation": {
+			config: "apiVersion: decision.finupgroup.com/v1alpha1\nkind: ControllerConfig\nworkspace:\n  port: 0\n  imagePullPolicy: Sometimes\narchive:\n  dir: /data/archive\n",
+			err:    "workspace.port",
+		},
+		"shared claims": {
+			config: "apiVersion: decision.finupgroup.com/v1alpha1\nkind: ControllerConfig\nworkspace:\n  sharedClaims:\n    risk: [Risk_Datasets]\n",
+			err:    "workspace.sharedClaims[risk][0]",
+		},
+		"nfs class": {
+			config: "apiVersion: decision.finupgroup.com/v1alpha1\nkind: ControllerConfig\nstorage:\n  classes:\n    nfs:\n      provider: NFS\n      path: /exports/traincrd\n",
+			err:    "storage.classes[nfs].server",
+		},
+		"staging claim": {
+			config: "apiVersion: decision.finupgroup.com/v1alpha1\nkind: ControllerConfig\nworkspace:\n  sharedClaims:\n    risk: [risk-datasets]\nstorage:\n  cloneStagingClaim: risk-datasets\n",
+			err:    "storage.cloneStagingClaim: Invalid value: \"risk-datasets\": must not be mounted by workspaces",
+		},
+		"hostPath class": {
+			config: "apiVersion: decision.finupgroup.com/v1alpha1\nkind: ControllerConfig\nstorage:\n  classes:\n    local:\n      provider: HostPath\n      path: /data/traincrd\n",
+			err:    "storage.classes[local].node",
+		},
+		"channel class": {
+			config: "apiVersion: decision.finupgroup.com/v1alpha1\nkind: ControllerConfig\nstorage:\n  channels:\n    quant: rbd\n",
+			err:    "storage.channels[quant]",
+		},
+	}
+	for name, tc := range cases {
+		_, err := Parse([]byte(tc.config), "")
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: expected an error containing %q, got %v", name, tc.err, err)
+		}
+	}
+
+	c := Default()
+	c.Workspace.ImagePullPolicy = "Sometimes"
+	c.Archive.Dir = "/data/archive"
+	if errs := c.Validate(); len(errs) != 2 {
+		t.Errorf("expected the pull policy and archive dir errors, got %v", errs)
+	}
+}
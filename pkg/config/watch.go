@@ -0,0 +1,40 @@
+package config
+
+import (
+	"bytes"
+	"io/ioutil"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog"
+)
+
+// Watch re-reads the config file every interval until stopCh is closed and calls onChange with
+// every new valid config. Polling follows the symlink swap kubelet does when a mounted ConfigMap
+// changes. An invalid file is logged and ignored, the last valid config stays in effect.
+func Watch(path, profile string, interval time.Duration, stopCh <-chan struct{}, onChange func(*Config)) {
+	last, err := ioutil.ReadFile(path)
+	if err != nil {
+		klog.Errorf("read controller config %s: %v", path, err)
+	}
+
+	wait.Until(func() {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			klog.Errorf("read controller config %s: %v", path, err)
+			return
+		}
+		if bytes.Equal(data, last) {
+			return
+		}
+		last = data
+
+		c, err := Parse(data, profile)
+		if err != nil {
+			klog.Errorf("controller config %s changed but is invalid, keep the previous one: %v", path, err)
+			return
+		}
+		klog.Infof("controller config %s changed, profile %q", path, c.Profile)
+		onChange(c)
+	}, interval, stopCh)
+}
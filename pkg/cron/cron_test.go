@@ -0,0 +1,62 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	shanghai := time.FixedZone("CST", 8*3600)
+	at := func(s string) time.Time {
+		parsed, err := time.ParseInLocation("2006-01-02 15:04:05", s, shanghai)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+
+	tests := []struct {
+		spec, from, next string
+	}{
+		{"0 9 * * 1-5", "2019-12-02 08:59:30", "2019-12-02 09:00:00"},
+		{"0 9 * * 1-5", "2019-12-02 09:00:00", "2019-12-03 09:00:00"},
+		// Friday evening to Monday morning
+		{"0 9 * * 1-5", "2019-12-06 18:00:00", "2019-12-09 09:00:00"},
+		{"30 18 * * 1-5", "2019-12-02 09:00:00", "2019-12-02 18:30:00"},
+		{"*/15 * * * *", "2019-12-02 09:07:00", "2019-12-02 09:15:00"},
+		{"5/20 * * * *", "2019-12-02 09:30:00", "2019-12-02 09:45:00"},
+		{"0 0 1,15 * *", "2019-12-02 00:00:00", "2019-12-15 00:00:00"},
+		{"0 0 1 1 *", "2019-12-02 00:00:00", "2020-01-01 00:00:00"},
+		{"0 0 29 2 *", "2019-12-02 00:00:00", "2020-02-29 00:00:00"},
+		// Sunday as 7
+		{"0 12 * * 7", "2019-12-02 00:00:00", "2019-12-08 12:00:00"},
+		// both day fields restricted: the 15th or any Monday
+		{"0 0 15 * 1", "2019-12-03 00:00:00", "2019-12-09 00:00:00"},
+	}
+	for _, test := range tests {
+		schedule, err := Parse(test.spec)
+		if err != nil {
+			t.Errorf("%q: %v", test.spec, err)
+			continue
+		}
+		if next := schedule.Next(at(test.from)); !next.Equal(at(test.next)) {
+			t.Errorf("%q from %s: expected %s, got %s", test.spec, test.from, test.next, next)
+		}
+	}
+
+	never, err := Parse("0 0 30 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next := never.Next(at("2019-12-02 00:00:00")); !next.IsZero() {
+		t.Errorf("expected no activation on February 30, got %s", next)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, spec := range []string{"", "0 9 * *", "60 9 * * *", "0 24 * * *", "0 9 0 * *", "0 9 * 13 *", "0 9 * * 8", "0 18-9 * * *", "*/0 * * * *", "a * * * *"} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("expected %q to be rejected", spec)
+		}
+	}
+}
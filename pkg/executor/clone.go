@@ -0,0 +1,405 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	v1 "finupgroup.com/decision/traincrd/pkg/apis/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+)
+
+// CLONEABLE_ANNOTATION 设置为 "true" 的 Traincrd 允许被其他 namespace 的 Traincrd 通过 spec.cloneFrom 复制，
+// 例如教学 channel 中老师准备好的工作区
+const CLONEABLE_ANNOTATION = "decision.finupgroup.com/cloneable"
+
+// INHERITED_ANNOTATION 记录已经从 spec.cloneFrom 继承过 image 与资源，之后不再读取源工作区
+const INHERITED_ANNOTATION = "decision.finupgroup.com/inherited-from"
+
+// LINEAGE_ANNOTATION 记录 PVC 的数据来源，即 status.lineage
+const LINEAGE_ANNOTATION = "decision.finupgroup.com/lineage"
+
+// CLONED_ANNOTATION 记录复制 Job 完成的时间，之后不再复制，避免覆盖用户的数据
+const CLONED_ANNOTATION = "decision.finupgroup.com/cloned-at"
+
+// status.lineage.method 的取值
+const (
+	LINEAGE_METHOD_CSI_CLONE = "CSIClone"
+	LINEAGE_METHOD_JOB       = "Job"
+	LINEAGE_METHOD_SNAPSHOT  = "Snapshot"
+)
+
+// clonePollInterval 复制 Job 未完成时重新检查的间隔
+const clonePollInterval = 15 * time.Second
+
+// cloneStagingDir 复制 Job 中暂存卷的挂载路径
+const cloneStagingDir = "/staging"
+
+// volumeSource 是新建 PVC 的数据来源
+type volumeSource struct {
+	dataSource *corev1.TypedLocalObjectReference
+	// size 为源卷的大小，新卷不能小于它
+	size    *resource.Quantity
+	lineage *v1.TraincrdLineage
+}
+
+// volumeSource 解析 spec.restoreFrom 或 spec.cloneFrom，两者都未填写时返回 nil；静态卷没有 provisioner，不能从快照恢复
+func (t *Traindeploy) volumeSource(provider storageProvider) (*volumeSource, error) {
+	switch {
+	case t.restoreFrom != "" && t.cloneFrom != nil:
+		return nil, permanent("InvalidSource", fmt.Errorf("spec.restoreFrom and spec.cloneFrom are mutually exclusive"))
+	case t.restoreFrom != "" && !provider.dynamic():
+		return nil, permanent("RestoreNotSupported", fmt.Errorf("spec.restoreFrom: %s volumes cannot be restored from snapshots", provider.name()))
+	case t.restoreFrom != "":
+		return t.restoreSource()
+	case t.cloneFrom != nil:
+		return t.cloneSource(provider)
+	}
+	return nil, nil
+}
+
+// cloneAllowed 同一 namespace 内可以直接复制，跨 namespace 需要源工作区设置 CLONEABLE_ANNOTATION
+func cloneAllowed(namespace string, source *v1.Traincrd) error {
+	if source.Namespace == namespace || source.Annotations[CLONEABLE_ANNOTATION] == "true" {
+		return nil
+	}
+	return permanent("CloneNotAllowed", fmt.Errorf("spec.cloneFrom %s/%s: the source is in another namespace and is not annotated %s: \"true\"",
+		source.Namespace, source.Name, CLONEABLE_ANNOTATION))
+}
+
+/**
+inheritClone 把 spec.cloneFrom 源工作区的 image、资源、容量与模板写入 Traincrd 中未填写的字段。
+只在第一次 reconcile 时执行，写回 spec 后克隆不再依赖源工作区，源被删除也不影响
+*/
+func (exe *Executor) inheritClone(train *v1.Traincrd) (*v1.Traincrd, error) {
+	from := train.Spec.CloneFrom
+	if from == nil || train.DeletionTimestamp != nil || train.Annotations[INHERITED_ANNOTATION] != "" {
+		return train, nil
+	}
+	namespace := from.Namespace
+	if namespace == "" {
+		namespace = train.Namespace
+	}
+	obj, exists, err := exe.informer.GetIndexer().GetByKey(namespace + "/" + from.Name)
+	if err != nil {
+		return train, err
+	}
+	if !exists {
+		return train, permanent("CloneSourceNotFound", fmt.Errorf("spec.cloneFrom %s/%s: Traincrd not found", namespace, from.Name))
+	}
+	source := obj.(*v1.Traincrd)
+	if err := cloneAllowed(train.Namespace, source); err != nil {
+		return train, err
+	}
+
+	updated := train.DeepCopy()
+	spec, defaults := &updated.Spec, &source.Spec
+	fill := func(field *string, value string) {
+		if *field == "" {
+			*field = value
+		}
+	}
+	fill(&spec.Image, defaults.Image)
+	fill(&spec.Cpu, defaults.Cpu)
+	fill(&spec.Memory, defaults.Memory)
+	fill(&spec.Capacity, defaults.Capacity)
+	fill(&spec.Template, defaults.Template)
+	fill(&spec.Profile, defaults.Profile)
+	// 与 profile 相同，继承的 request 不能超过克隆自己的 limit
+	spec.ReqCpu = defaultRequest(spec.ReqCpu, defaults.ReqCpu, spec.Cpu)
+	spec.ReqMemory = defaultRequest(spec.ReqMemory, defaults.ReqMemory, spec.Memory)
+	if updated.Annotations == nil {
+		updated.Annotations = map[string]string{}
+	}
+	updated.Annotations[INHERITED_ANNOTATION] = namespace + "/" + from.Name
+
+	klog.Infof("train %s/%s 继承 %s/%s 的 image 与资源", train.Namespace, train.Name, namespace, from.Name)
+	return exe.clientTrain.DecisionV1().Traincrds(train.Namespace).Update(updated)
+}
+
+/**
+cloneSource 解析 spec.cloneFrom：存储类支持 CSI clone 且在同一 namespace 时以源 PVC 为 dataSource，
+否则创建空的 PVC，由 populateClone 启动 Job 复制数据
+*/
+func (t *Traindeploy) cloneSource(provider storageProvider) (*volumeSource, error) {
+	from := t.cloneFrom
+	source, err := t.clientTrain.DecisionV1().Traincrds(from.Namespace).Get(from.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, permanent("CloneSourceNotFound", fmt.Errorf("spec.cloneFrom %s/%s: Traincrd not found", from.Namespace, from.Name))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := cloneAllowed(t.namespace, source); err != nil {
+		return nil, err
+	}
+	// 源工作区的 PVC 与工作区同名，可能还在创建中，稍后重试
+	pvc, err := t.clientK8s.CoreV1().PersistentVolumeClaims(source.Namespace).Get(source.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	size := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	clone := &volumeSource{
+		size:    &size,
+		lineage: &v1.TraincrdLineage{Kind: "Traincrd", Namespace: source.Namespace, Name: source.Name, UID: string(source.UID), Method: LINEAGE_METHOD_JOB},
+	}
+	// dataSource 只能引用同一 namespace 的 PVC，静态卷总是用 Job 复制
+	if t.cfg.Storage.CSIClone && provider.dynamic() && source.Namespace == t.namespace {
+		clone.dataSource = &corev1.TypedLocalObjectReference{Kind: "PersistentVolumeClaim", Name: pvc.Name}
+		clone.lineage.Method = LINEAGE_METHOD_CSI_CLONE
+	}
+	return clone, nil
+}
+
+// pvcLineage 读取 LINEAGE_ANNOTATION，没有或无法解析时返回 nil
+func pvcLineage(pvc *corev1.PersistentVolumeClaim) *v1.TraincrdLineage {
+	data, ok := pvc.Annotations[LINEAGE_ANNOTATION]
+	if !ok {
+		return nil
+	}
+	lineage := &v1.TraincrdLineage{}
+	if err := json.Unmarshal([]byte(data), lineage); err != nil {
+		klog.Errorf("PVC %s/%s 的 %s 无法解析: %v", pvc.Namespace, pvc.Name, LINEAGE_ANNOTATION, err)
+		return nil
+	}
+	return lineage
+}
+
+/**
+populateClone 用 Job 复制源工作区的数据：PVC 只能在所在的 namespace 挂载，
+先在源 namespace 把数据打包到暂存卷 storage.cloneStagingClaim，再在本 namespace 解包到新的 PVC；
+完成后在 PVC 上记录 CLONED_ANNOTATION 并删除 Job。暂存卷不挂载到任何工作区，其他用户读不到复制中的数据
+*/
+func (t *Traindeploy) populateClone(pvc *corev1.PersistentVolumeClaim) (*corev1.PersistentVolumeClaim, error) {
+	lineage := pvcLineage(pvc)
+	if lineage == nil || lineage.Method != LINEAGE_METHOD_JOB || pvc.Annotations[CLONED_ANNOTATION] != "" {
+		return pvc, nil
+	}
+	t.cloning = true
+
+	// 源工作区仍在运行，ReadWriteOnce 的卷只能在它所在的节点上挂载
+	export := t.makeCloneExportJob(lineage)
+	if err := t.pinToClaimNode(&export.Spec.Template.Spec, lineage.Namespace, lineage.Name); err != nil {
+		return pvc, err
+	}
+	exported, err := t.runCloneJob(export)
+	if err != nil || !exported {
+		return pvc, err
+	}
+	imported, err := t.runCloneJob(t.makeCloneImportJob())
+	if _, failed := permanentReason(err); failed {
+		// 解包 Job 失败时已删除暂存的归档，删除导出 Job，删除解包 Job 重试时重新导出
+		if derr := t.deleteJob(export, metav1.DeletePropagationBackground); derr != nil {
+			return pvc, derr
+		}
+	}
+	if err != nil || !imported {
+		return pvc, err
+	}
+
+	klog.Infof("复制 %s/%s 完成 %s", lineage.Namespace, lineage.Name, t.toString())
+	updated := pvc.DeepCopy()
+	updated.Annotations[CLONED_ANNOTATION] = time.Now().UTC().Format(time.RFC3339)
+	if updated, err = t.clientK8s.CoreV1().PersistentVolumeClaims(t.namespace).Update(updated); err != nil {
+		return pvc, err
+	}
+	t.cloning = false
+	if err := t.deleteCloneJobs(lineage, metav1.DeletePropagationBackground); err != nil {
+		klog.Errorf("删除复制 Job 失败，%s: %v", t.toString(), err)
+	}
+	return updated, nil
+}
+
+// runCloneJob 创建或检查复制 Job，成功时返回 true；失败的 Job 需要删除后才会重试
+func (t *Traindeploy) runCloneJob(job *batchv1.Job) (bool, error) {
+	existing, err := t.clientK8s.BatchV1().Jobs(job.Namespace).Get(job.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		klog.Infof("创建复制 Job %s/%s", job.Namespace, job.Name)
+		_, err = t.clientK8s.BatchV1().Jobs(job.Namespace).Create(job)
+		return false, err
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if existing.Status.Succeeded > 0 {
+		return true, nil
+	}
+	for _, cond := range existing.Status.Conditions {
+		if cond.Type == batchv1.JobFailed && cond.Status == corev1.ConditionTrue {
+			t.cloneErr = permanent("CloneFailed", fmt.Errorf("clone job %s/%s failed: %s, delete the job to retry", job.Namespace, job.Name, cond.Message))
+			return false, t.cloneErr
+		}
+	}
+	return false, nil
+}
+
+// deleteCloneJobs 删除两个复制 Job 及其 Pod，Traincrd 删除时也会调用，清理未完成的复制
+func (t *Traindeploy) deleteCloneJobs(lineage *v1.TraincrdLineage, propagation metav1.DeletionPropagation) error {
+	for _, job := range []*batchv1.Job{t.makeCloneExportJob(lineage), t.makeCloneImportJob()} {
+		if err := t.deleteJob(job, propagation); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *Traindeploy) deleteJob(job *batchv1.Job, propagation metav1.DeletionPropagation) error {
+	err := t.clientK8s.BatchV1().Jobs(job.Namespace).Delete(job.Name, &metav1.DeleteOptions{PropagationPolicy: &propagation})
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+/**
+cleanupClone Traincrd 删除时清理尚未完成的复制，返回 true 表示清理完毕。导出 Job 在源 namespace 中，不会被级联删除；
+Job 连同 Pod 删除后再用 Job 删除暂存卷上可能已经打包好的数据
+*/
+func (t *Traindeploy) cleanupClone() (bool, error) {
+	pvc, err := t.clientK8s.CoreV1().PersistentVolumeClaims(t.namespace).Get(t.name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	lineage := pvcLineage(pvc)
+	if lineage == nil || lineage.Method != LINEAGE_METHOD_JOB || pvc.Annotations[CLONED_ANNOTATION] != "" {
+		return true, nil
+	}
+	t.cleaningClone = true
+
+	// Foreground: 导出 Pod 退出后 Job 才消失，之后不会再写入暂存卷
+	if err := t.deleteCloneJobs(lineage, metav1.DeletePropagationForeground); err != nil {
+		return false, err
+	}
+	export := t.makeCloneExportJob(lineage)
+	if _, err := t.clientK8s.BatchV1().Jobs(export.Namespace).Get(export.Name, metav1.GetOptions{}); !errors.IsNotFound(err) {
+		return false, err
+	}
+	cleanup := t.makeCloneCleanupJob(lineage)
+	removed, err := t.runCloneJob(cleanup)
+	if err != nil || !removed {
+		return false, err
+	}
+	return true, t.deleteJob(cleanup, metav1.DeletePropagationBackground)
+}
+
+// clonePath 以克隆的 UID 命名，同一个源可以同时被多个工作区复制
+func (t *Traindeploy) clonePath() string {
+	return fmt.Sprintf("%s/%s.tar", cloneStagingDir, t.uid)
+}
+
+// makeCloneExportJob 在源 namespace 中运行，名称带上克隆的 UID，不同 namespace 中同名的克隆不会冲突
+func (t *Traindeploy) makeCloneExportJob(lineage *v1.TraincrdLineage) *batchv1.Job {
+	path := t.clonePath()
+	script := fmt.Sprintf("tar -cf %[1]s.tmp -C /source . && mv %[1]s.tmp %[1]s", path)
+	job := t.makeCopyJob("clone-"+t.uid, lineage.Namespace, script, "source", lineage.Name, true)
+	job.Labels = map[string]string{"app": lineage.Name, "clone": t.uid}
+	return job
+}
+
+func (t *Traindeploy) makeCloneImportJob() *batchv1.Job {
+	path := t.clonePath()
+	// 成功与否都删除暂存的归档，因此不重试，失败后删除 Job 时重新导出
+	script := fmt.Sprintf("tar -xf %[1]s -C /workspace; status=$?; rm -f %[1]s; exit $status", path)
+	job := t.makeCopyJob(t.name+"-clone", t.namespace, script, "workspace", t.name, false)
+	backoffLimit := int32(0)
+	job.Spec.BackoffLimit = &backoffLimit
+	job.Labels = map[string]string{"app": t.name, "username": t.username, "channel": t.channel}
+	job.OwnerReferences = t.ownerReferences()
+	return job
+}
+
+func (t *Traindeploy) cloneCleanupJobName() string {
+	return "clone-" + t.uid + "-cleanup"
+}
+
+// makeCloneCleanupJob 删除暂存的归档，与导出 Job 一样在源 namespace 中运行，Traincrd 删除后不会被级联删除
+func (t *Traindeploy) makeCloneCleanupJob(lineage *v1.TraincrdLineage) *batchv1.Job {
+	path := t.clonePath()
+	job := t.makeCopyJob(t.cloneCleanupJobName(), lineage.Namespace, fmt.Sprintf("rm -f %[1]s %[1]s.tmp", path), "", "", false)
+	job.Labels = map[string]string{"app": lineage.Name, "clone": t.uid}
+	return job
+}
+
+// makeCopyJob 与归档 Job 相同，挂载工作区的 PVC 与暂存卷，用 archive.image 执行 script；claimName 为空时只挂载暂存卷
+func (t *Traindeploy) makeCopyJob(name, namespace, script, volume, claimName string, readOnly bool) *batchv1.Job {
+	backoffLimit := int32(3)
+	staging := t.cfg.Storage.CloneStagingClaim
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{
+						{
+							Name:    "copy",
+							Image:   t.cfg.Archive.Image,
+							Command: []string{"sh", "-c", script},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	pod := &job.Spec.Template.Spec
+	if claimName != "" {
+		pod.Containers[0].VolumeMounts = append(pod.Containers[0].VolumeMounts, corev1.VolumeMount{Name: volume, MountPath: "/" + volume, ReadOnly: readOnly})
+		pod.Volumes = append(pod.Volumes, corev1.Volume{
+			Name: volume,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: claimName,
+					ReadOnly:  readOnly,
+				},
+			},
+		})
+	}
+	pod.Containers[0].VolumeMounts = append(pod.Containers[0].VolumeMounts, corev1.VolumeMount{Name: "staging", MountPath: cloneStagingDir})
+	pod.Volumes = append(pod.Volumes, corev1.Volume{
+		Name: "staging",
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: staging,
+			},
+		},
+	})
+	return job
+}
+
+// setCloneCondition 报告 spec.cloneFrom 的复制进度，其他工作区没有该 condition
+func (t *Traindeploy) setCloneCondition(status *v1.TraincrdStatus, pvc *corev1.PersistentVolumeClaim) {
+	lineage := status.Lineage
+	if lineage == nil || lineage.Kind != "Traincrd" {
+		return
+	}
+	source := lineage.Namespace + "/" + lineage.Name
+	switch {
+	case lineage.Method == LINEAGE_METHOD_CSI_CLONE && pvc.Status.Phase == corev1.ClaimBound,
+		lineage.Method == LINEAGE_METHOD_JOB && pvc.Annotations[CLONED_ANNOTATION] != "":
+		setCondition(status, v1.TraincrdCloned, corev1.ConditionTrue, "Cloned", "")
+	case lineage.Method == LINEAGE_METHOD_CSI_CLONE:
+		setCondition(status, v1.TraincrdCloned, corev1.ConditionFalse, "Provisioning", fmt.Sprintf("cloning the volume of %s", source))
+	default:
+		if reason, ok := permanentReason(t.cloneErr); ok {
+			setCondition(status, v1.TraincrdCloned, corev1.ConditionFalse, reason, t.cloneErr.Error())
+			return
+		}
+		setCondition(status, v1.TraincrdCloned, corev1.ConditionFalse, "Copying", fmt.Sprintf("copying the volume of %s with a job", source))
+	}
+}
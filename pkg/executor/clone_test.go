@@ -0,0 +1,272 @@
+package executor
+
+import (
+	"testing"
+
+	v1 "finupgroup.com/decision/traincrd/pkg/apis/v1"
+	trainfake "finupgroup.com/decision/traincrd/pkg/client/clientset/versioned/fake"
+	"finupgroup.com/decision/traincrd/pkg/config"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+// lesson 是老师准备好的工作区，允许其他 namespace 复制
+func lesson(namespace string, cloneable bool) (*v1.Traincrd, *corev1.PersistentVolumeClaim) {
+	train := &v1.Traincrd{
+		ObjectMeta: metav1.ObjectMeta{Name: "lesson-1", Namespace: namespace, UID: "3f2a9c1e", Labels: map[string]string{"username": "teacher", "channel": "course"}},
+		Spec:       v1.TraincrdSpec{Image: "jupyter:2.0", Cpu: "2", Memory: "4Gi", ReqCpu: "1", ReqMemory: "2Gi", Replicas: 1, Capacity: "5Gi"},
+	}
+	if cloneable {
+		train.Annotations = map[string]string{CLONEABLE_ANNOTATION: "true"}
+	}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "lesson-1", Namespace: namespace},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("8Gi")}},
+		},
+	}
+	return train, pvc
+}
+
+func TestCloneWithJob(t *testing.T) {
+	source, sourcePVC := lesson("teacher", true)
+	train := &v1.Traincrd{
+		ObjectMeta: metav1.ObjectMeta{Name: "notebook", Namespace: "wangxx", UID: "6c1e4f3a", Labels: map[string]string{"username": "wangxx", "channel": "course"}},
+		Spec:       v1.TraincrdSpec{Cpu: "1", Replicas: 1, CloneFrom: &v1.TraincrdCloneSource{Namespace: "teacher", Name: "lesson-1"}},
+	}
+	clientK8s := k8sfake.NewSimpleClientset(sourcePVC)
+	exe := New(trainfake.NewSimpleClientset(source, train), clientK8s, nil, 0)
+	if err := exe.informer.GetIndexer().Add(source); err != nil {
+		t.Fatal(err)
+	}
+
+	replicas := func() int32 {
+		dep, err := clientK8s.AppsV1().Deployments("wangxx").Get("notebook", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return *dep.Spec.Replicas
+	}
+	expectCloned := func(train *v1.Traincrd, status corev1.ConditionStatus, reason string) {
+		t.Helper()
+		if cond := getCondition(&train.Status, v1.TraincrdCloned); cond == nil || cond.Status != status || cond.Reason != reason {
+			t.Errorf("expected Cloned=%s with reason %s, got %+v", status, reason, cond)
+		}
+	}
+	// finishJob 模拟 Job 成功
+	finishJob := func(namespace, name string) {
+		job, err := clientK8s.BatchV1().Jobs(namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		job.Status.Succeeded = 1
+		if _, err := clientK8s.BatchV1().Jobs(namespace).UpdateStatus(job); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	train = reconcileOnce(t, exe, "wangxx", "notebook")
+	spec := train.Spec
+	if spec.Image != "jupyter:2.0" || spec.Cpu != "1" || spec.ReqCpu != "1" || spec.Memory != "4Gi" || spec.ReqMemory != "2Gi" || spec.Capacity != "5Gi" {
+		t.Errorf("expected the image and resources of lesson-1 with cpu 1, got %+v", spec)
+	}
+	pvc, err := clientK8s.CoreV1().PersistentVolumeClaims("wangxx").Get("notebook", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; size.String() != "8Gi" {
+		t.Errorf("expected the clone to be as large as the 8Gi source volume, got %s", size.String())
+	}
+	if lineage := train.Status.Lineage; lineage == nil || *lineage != (v1.TraincrdLineage{Kind: "Traincrd", Namespace: "teacher", Name: "lesson-1", UID: "3f2a9c1e", Method: "Job"}) {
+		t.Errorf("unexpected lineage %+v", lineage)
+	}
+	if replicas() != 0 || train.Status.Phase != v1.TraincrdProvisioning {
+		t.Errorf("expected the workspace to wait for the copy, got %d replicas, phase %s", replicas(), train.Status.Phase)
+	}
+	expectCloned(train, corev1.ConditionFalse, "Copying")
+
+	export, err := clientK8s.BatchV1().Jobs("teacher").Get("clone-6c1e4f3a", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claim := export.Spec.Template.Spec.Volumes[0].PersistentVolumeClaim; claim.ClaimName != "lesson-1" || !claim.ReadOnly {
+		t.Errorf("expected the export job to mount lesson-1 read-only, got %+v", claim)
+	}
+
+	finishJob("teacher", "clone-6c1e4f3a")
+	reconcileOnce(t, exe, "wangxx", "notebook")
+	finishJob("wangxx", "notebook-clone")
+	train = reconcileOnce(t, exe, "wangxx", "notebook")
+	expectCloned(train, corev1.ConditionTrue, "Cloned")
+	if replicas() != 1 {
+		t.Errorf("expected the workspace to start after the copy, got %d replicas", replicas())
+	}
+	for _, job := range []struct{ namespace, name string }{{"teacher", "clone-6c1e4f3a"}, {"wangxx", "notebook-clone"}} {
+		if _, err := clientK8s.BatchV1().Jobs(job.namespace).Get(job.name, metav1.GetOptions{}); !errors.IsNotFound(err) {
+			t.Errorf("expected job %s/%s to be deleted, got %v", job.namespace, job.name, err)
+		}
+	}
+
+	// 复制完成后不再创建 Job，源工作区删除也不影响
+	if err := exe.informer.GetIndexer().Delete(source); err != nil {
+		t.Fatal(err)
+	}
+	if err := exe.clientTrain.DecisionV1().Traincrds("teacher").Delete("lesson-1", &metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	train = reconcileOnce(t, exe, "wangxx", "notebook")
+	expectCloned(train, corev1.ConditionTrue, "Cloned")
+	if jobs, _ := clientK8s.BatchV1().Jobs("").List(metav1.ListOptions{}); len(jobs.Items) != 0 {
+		t.Errorf("expected no more jobs, got %d", len(jobs.Items))
+	}
+}
+
+func TestCloneStaging(t *testing.T) {
+	source, sourcePVC := lesson("teacher", true)
+	train := &v1.Traincrd{
+		ObjectMeta: metav1.ObjectMeta{Name: "notebook", Namespace: "wangxx", UID: "6c1e4f3a", Labels: map[string]string{"username": "wangxx", "channel": "course"}},
+		Spec:       v1.TraincrdSpec{Cpu: "1", Replicas: 1, CloneFrom: &v1.TraincrdCloneSource{Namespace: "teacher", Name: "lesson-1"}},
+	}
+	clientK8s := k8sfake.NewSimpleClientset(sourcePVC)
+	exe := New(trainfake.NewSimpleClientset(source, train), clientK8s, nil, 0)
+	if err := exe.informer.GetIndexer().Add(source); err != nil {
+		t.Fatal(err)
+	}
+	getJob := func(namespace, name string) *batchv1.Job {
+		t.Helper()
+		job, err := clientK8s.BatchV1().Jobs(namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return job
+	}
+	setJobStatus := func(job *batchv1.Job, status batchv1.JobStatus) {
+		t.Helper()
+		job.Status = status
+		if _, err := clientK8s.BatchV1().Jobs(job.Namespace).UpdateStatus(job); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 数据经过工作区不挂载的暂存卷，而不是公共存储
+	reconcileOnce(t, exe, "wangxx", "notebook")
+	export := getJob("teacher", "clone-6c1e4f3a")
+	for _, volume := range export.Spec.Template.Spec.Volumes {
+		if volume.PersistentVolumeClaim.ClaimName == "trainlabpublicstorage" {
+			t.Errorf("expected the export job not to mount the public storage")
+		}
+	}
+	if volumes := export.Spec.Template.Spec.Volumes; len(volumes) != 2 || volumes[1].PersistentVolumeClaim.ClaimName != "trainlab-clone-staging" {
+		t.Errorf("expected the export job to stage on trainlab-clone-staging, got %+v", volumes)
+	}
+
+	// 解包失败时删除暂存的归档与导出 Job，删除解包 Job 后重新导出
+	setJobStatus(export, batchv1.JobStatus{Succeeded: 1})
+	reconcileOnce(t, exe, "wangxx", "notebook")
+	imported := getJob("wangxx", "notebook-clone")
+	if script := imported.Spec.Template.Spec.Containers[0].Command[2]; script != "tar -xf /staging/6c1e4f3a.tar -C /workspace; status=$?; rm -f /staging/6c1e4f3a.tar; exit $status" {
+		t.Errorf("expected the import job to remove the staged archive, got %q", script)
+	}
+	if *imported.Spec.BackoffLimit != 0 {
+		t.Errorf("expected the import job not to retry without the staged archive, got backoffLimit %d", *imported.Spec.BackoffLimit)
+	}
+	setJobStatus(imported, batchv1.JobStatus{Failed: 1, Conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Message: "BackoffLimitExceeded"}}})
+	if reason, _ := permanentReason(exe.Reconcile("wangxx", "notebook")); reason != "CloneFailed" {
+		t.Errorf("expected CloneFailed after the import job failed")
+	}
+	if _, err := clientK8s.BatchV1().Jobs("teacher").Get("clone-6c1e4f3a", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("expected the export job to be deleted after the import failed, got %v", err)
+	}
+
+	// 删除未完成的克隆时先用 Job 删除暂存卷上的数据，再释放 finalizer
+	setJobStatus(imported, batchv1.JobStatus{})
+	reconcileOnce(t, exe, "wangxx", "notebook")
+	train = deleteTraincrd(t, exe, "wangxx", "notebook")
+	if cond := getCondition(&train.Status, v1.TraincrdCleanedUp); cond == nil || cond.Reason != "RemovingClone" {
+		t.Errorf("expected CleanedUp with reason RemovingClone, got %+v", cond)
+	}
+	if !containsString(train.Finalizers, CLEANUP_FINALIZER) {
+		t.Fatalf("expected the finalizer to be kept until the staged data is removed")
+	}
+	for _, job := range []struct{ namespace, name string }{{"teacher", "clone-6c1e4f3a"}, {"wangxx", "notebook-clone"}} {
+		if _, err := clientK8s.BatchV1().Jobs(job.namespace).Get(job.name, metav1.GetOptions{}); !errors.IsNotFound(err) {
+			t.Errorf("expected job %s/%s to be deleted, got %v", job.namespace, job.name, err)
+		}
+	}
+	cleanup := getJob("teacher", "clone-6c1e4f3a-cleanup")
+	if script := cleanup.Spec.Template.Spec.Containers[0].Command[2]; script != "rm -f /staging/6c1e4f3a.tar /staging/6c1e4f3a.tar.tmp" {
+		t.Errorf("unexpected cleanup script %q", script)
+	}
+	setJobStatus(cleanup, batchv1.JobStatus{Succeeded: 1})
+	if train = deleteTraincrd(t, exe, "wangxx", "notebook"); containsString(train.Finalizers, CLEANUP_FINALIZER) {
+		t.Errorf("expected the finalizer to be removed after the staged data was removed")
+	}
+	if _, err := clientK8s.BatchV1().Jobs("teacher").Get("clone-6c1e4f3a-cleanup", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("expected the cleanup job to be deleted, got %v", err)
+	}
+}
+
+func TestCloneNotAllowed(t *testing.T) {
+	source, sourcePVC := lesson("teacher", false)
+	train := &v1.Traincrd{
+		ObjectMeta: metav1.ObjectMeta{Name: "notebook", Namespace: "wangxx", UID: "6c1e4f3a", Labels: map[string]string{"username": "wangxx", "channel": "course"}},
+		Spec:       v1.TraincrdSpec{Replicas: 1, CloneFrom: &v1.TraincrdCloneSource{Namespace: "teacher", Name: "lesson-1"}},
+	}
+	exe := New(trainfake.NewSimpleClientset(source, train), k8sfake.NewSimpleClientset(sourcePVC), nil, 0)
+	if err := exe.informer.GetIndexer().Add(source); err != nil {
+		t.Fatal(err)
+	}
+	if err := exe.informer.GetIndexer().Add(train); err != nil {
+		t.Fatal(err)
+	}
+	if reason, _ := permanentReason(exe.Reconcile("wangxx", "notebook")); reason != "CloneNotAllowed" {
+		t.Errorf("expected CloneNotAllowed for a source in another namespace without %s", CLONEABLE_ANNOTATION)
+	}
+}
+
+func TestCSIClone(t *testing.T) {
+	source, sourcePVC := lesson("wangxx", false)
+	train := &v1.Traincrd{
+		ObjectMeta: metav1.ObjectMeta{Name: "notebook", Namespace: "wangxx", UID: "6c1e4f3a", Labels: map[string]string{"username": "wangxx", "channel": "course"}},
+		Spec:       v1.TraincrdSpec{Replicas: 1, CloneFrom: &v1.TraincrdCloneSource{Name: "lesson-1"}},
+	}
+	clientK8s := k8sfake.NewSimpleClientset(sourcePVC)
+	exe := New(trainfake.NewSimpleClientset(source, train), clientK8s, nil, 0)
+	cfg := config.Default()
+	cfg.Storage.CSIClone = true
+	exe.cfg.Store(cfg)
+	if err := exe.informer.GetIndexer().Add(source); err != nil {
+		t.Fatal(err)
+	}
+
+	train = reconcileOnce(t, exe, "wangxx", "notebook")
+	pvc, err := clientK8s.CoreV1().PersistentVolumeClaims("wangxx").Get("notebook", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ds := pvc.Spec.DataSource; ds == nil || ds.Kind != "PersistentVolumeClaim" || ds.Name != "lesson-1" {
+		t.Errorf("expected the PVC to clone lesson-1, got %+v", ds)
+	}
+	if train.Status.Lineage == nil || train.Status.Lineage.Method != "CSIClone" {
+		t.Errorf("expected lineage method CSIClone, got %+v", train.Status.Lineage)
+	}
+	if cond := getCondition(&train.Status, v1.TraincrdCloned); cond == nil || cond.Reason != "Provisioning" {
+		t.Errorf("expected Cloned=False while the PVC is provisioned, got %+v", cond)
+	}
+
+	pvc.Status.Phase = corev1.ClaimBound
+	if _, err := clientK8s.CoreV1().PersistentVolumeClaims("wangxx").UpdateStatus(pvc); err != nil {
+		t.Fatal(err)
+	}
+	train = reconcileOnce(t, exe, "wangxx", "notebook")
+	if cond := getCondition(&train.Status, v1.TraincrdCloned); cond == nil || cond.Status != corev1.ConditionTrue {
+		t.Errorf("expected Cloned=True once the PVC is bound, got %+v", cond)
+	}
+	if jobs, _ := clientK8s.BatchV1().Jobs("").List(metav1.ListOptions{}); len(jobs.Items) != 0 {
+		t.Errorf("expected no copier job with CSI cloning, got %d", len(jobs.Items))
+	}
+}
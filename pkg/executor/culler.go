@@ -0,0 +1,149 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "finupgroup.com/decision/traincrd/pkg/apis/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog"
+)
+
+// activityProbe 查询工作区最后一次活动的时间
+type activityProbe interface {
+	LastActivity(train *v1.Traincrd, path string) (time.Time, error)
+}
+
+// httpActivityProbe 请求工作区 Service 上的活动接口，返回 Jupyter /api/status 格式的 {"last_activity": "<RFC 3339>"}
+type httpActivityProbe struct {
+	client *http.Client
+	// baseURL 返回工作区的地址，测试中替换为本地的 httptest server
+	baseURL func(train *v1.Traincrd) string
+}
+
+func (p *httpActivityProbe) LastActivity(train *v1.Traincrd, path string) (time.Time, error) {
+	url := p.baseURL(train) + path
+	resp, err := p.client.Get(url)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return time.Time{}, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+
+	var status struct {
+		LastActivity string `json:"last_activity"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return time.Time{}, fmt.Errorf("GET %s: %v", url, err)
+	}
+	return time.Parse(time.RFC3339, status.LastActivity)
+}
+
+// runCuller 每隔 culling.interval 检查一次所有运行中的工作区，直到 stopCh 关闭
+func (exe *Executor) runCuller(stopCh <-chan struct{}) {
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-exe.clock.After(exe.config().Culling.Interval.Duration):
+		}
+		exe.cullIdle(stopCh)
+	}
+}
+
+// cullWorkers 同时探测的工作区数，无法访问的工作区各自等待 probe 超时，不会让一轮检查超过 culling.interval
+const cullWorkers = 16
+
+/**
+探测运行中的工作区，记录 status.lastActivityTime；空闲超过所在 channel 的 idleTimeout 时把 replicas 缩为 0，
+PVC 保留，用户把 replicas 改回大于 0 即可恢复
+*/
+func (exe *Executor) cullIdle(stopCh <-chan struct{}) {
+	cfg := exe.config().Culling
+	trains := make(chan *v1.Traincrd)
+	var wg sync.WaitGroup
+	for i := 0; i < cullWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for train := range trains {
+				timeout := cfg.IdleTimeoutFor(train.Labels["channel"])
+				if err := exe.cull(train, timeout, strings.Replace(cfg.Path, "{name}", train.Name, -1)); err != nil {
+					klog.Errorf("检查 train %s/%s 是否空闲失败: %v", train.Namespace, train.Name, err)
+				}
+			}
+		}()
+	}
+	defer func() {
+		close(trains)
+		wg.Wait()
+	}()
+
+	for _, obj := range exe.informer.GetStore().List() {
+		train := obj.(*v1.Traincrd)
+		timeout := cfg.IdleTimeoutFor(train.Labels["channel"])
+		if timeout == 0 || train.DeletionTimestamp != nil || train.Spec.Suspended || train.Spec.Replicas == 0 || train.Status.ReadyReplicas == 0 {
+			continue
+		}
+		select {
+		case <-stopCh:
+			return
+		case trains <- train:
+		}
+	}
+}
+
+func (exe *Executor) cull(train *v1.Traincrd, timeout time.Duration, path string) error {
+	lastActivity, err := exe.probe.LastActivity(train, path)
+	if err != nil {
+		return err
+	}
+	lastActivity = lastActivity.Truncate(time.Second)
+
+	// 刚恢复的工作区从恢复时开始计时，否则会因为 culling 之前的活动时间立即再次被 culling
+	idleSince := lastActivity
+	if cond := getCondition(&train.Status, v1.TraincrdCulled); cond != nil && cond.LastTransitionTime.After(idleSince) {
+		idleSince = cond.LastTransitionTime.Time
+	}
+	idle := exe.clock.Since(idleSince)
+	if idle < timeout {
+		status := *train.Status.DeepCopy()
+		status.LastActivityTime = &metav1.Time{Time: lastActivity}
+		return exe.updateStatus(train, status)
+	}
+
+	// 通过 scale 子资源缩容，不经过 mutating webhook，replicas 为 0 时不会被补回默认值
+	trains := exe.clientTrain.DecisionV1().Traincrds(train.Namespace)
+	scale, err := trains.GetScale(train.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	scale.Spec.Replicas = 0
+	if _, err := trains.UpdateScale(train.Name, scale); err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("no activity since %s, idle timeout is %v", lastActivity.Format(time.RFC3339), timeout)
+	klog.Infof("train %s/%s 空闲超时，replicas 缩为 0: %s", train.Namespace, train.Name, message)
+	exe.recorder.Event(train, corev1.EventTypeNormal, "Culled", message)
+
+	// 缩容后 resourceVersion 已变化，取最新的 Traincrd 写 status
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latest, err := trains.Get(train.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		status := *latest.Status.DeepCopy()
+		status.LastActivityTime = &metav1.Time{Time: lastActivity}
+		setCondition(&status, v1.TraincrdCulled, corev1.ConditionTrue, "Idle", message)
+		return exe.updateStatus(latest, status)
+	})
+}
@@ -0,0 +1,206 @@
+package executor
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	v1 "finupgroup.com/decision/traincrd/pkg/apis/v1"
+	trainfake "finupgroup.com/decision/traincrd/pkg/client/clientset/versioned/fake"
+	"finupgroup.com/decision/traincrd/pkg/config"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/clock"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// addScaleReactors 让 fake clientset 支持 traincrds 的 scale 子资源
+func addScaleReactors(client *trainfake.Clientset) {
+	trainsResource := v1.SchemeGroupVersion.WithResource("traincrds")
+	client.PrependReactor("get", "traincrds", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "scale" {
+			return false, nil, nil
+		}
+		obj, err := client.Tracker().Get(trainsResource, action.GetNamespace(), action.(k8stesting.GetAction).GetName())
+		if err != nil {
+			return true, nil, err
+		}
+		train := obj.(*v1.Traincrd)
+		return true, &autoscalingv1.Scale{
+			ObjectMeta: metav1.ObjectMeta{Name: train.Name, Namespace: train.Namespace},
+			Spec:       autoscalingv1.ScaleSpec{Replicas: int32(train.Spec.Replicas)},
+		}, nil
+	})
+	client.PrependReactor("update", "traincrds", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "scale" {
+			return false, nil, nil
+		}
+		scale := action.(k8stesting.UpdateAction).GetObject().(*autoscalingv1.Scale)
+		obj, err := client.Tracker().Get(trainsResource, action.GetNamespace(), scale.Name)
+		if err != nil {
+			return true, nil, err
+		}
+		train := obj.(*v1.Traincrd).DeepCopy()
+		train.Spec.Replicas = int(scale.Spec.Replicas)
+		return true, scale, client.Tracker().Update(trainsResource, train, train.Namespace)
+	})
+}
+
+func TestCullIdle(t *testing.T) {
+	now := time.Date(2019, 12, 2, 20, 0, 0, 0, time.UTC)
+	// 本地 server 代替 Jupyter 的 /api/status
+	activity := map[string]time.Time{
+		"/idle/api/status":   now.Add(-3 * time.Hour),
+		"/active/api/status": now.Add(-10 * time.Minute),
+		"/exempt/api/status": now.Add(-30 * time.Hour),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		last, ok := activity[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `{"started": "2019-12-01T08:00:00.000000Z", "last_activity": "%s", "connections": 0, "kernels": 1}`,
+			last.Format("2006-01-02T15:04:05.000000Z"))
+	}))
+	defer server.Close()
+
+	running := func(name, channel string) *v1.Traincrd {
+		return &v1.Traincrd{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "wangxx", Labels: map[string]string{"username": "wangxx", "channel": channel}},
+			Spec:       v1.TraincrdSpec{Image: "jupyter:1.0", Cpu: "1", Memory: "1Gi", Replicas: 1},
+			Status:     v1.TraincrdStatus{Replicas: 1, ReadyReplicas: 1},
+		}
+	}
+	trains := []*v1.Traincrd{running("idle", "risk"), running("active", "risk"), running("exempt", "quant")}
+	// 半小时前刚恢复的工作区，之前的活动时间不计入空闲
+	resumed := running("resumed", "risk")
+	resumed.Status.Conditions = []v1.TraincrdCondition{{
+		Type: v1.TraincrdCulled, Status: corev1.ConditionFalse, Reason: "Resumed", LastTransitionTime: metav1.NewTime(now.Add(-30 * time.Minute)),
+	}}
+	activity["/resumed/api/status"] = now.Add(-5 * time.Hour)
+	trains = append(trains, resumed)
+
+	objects := make([]runtime.Object, 0, len(trains))
+	for _, train := range trains {
+		objects = append(objects, train)
+	}
+	clientTrain := trainfake.NewSimpleClientset(objects...)
+	addScaleReactors(clientTrain)
+	exe := New(clientTrain, k8sfake.NewSimpleClientset(), nil, 0)
+	for _, train := range trains {
+		if err := exe.informer.GetIndexer().Add(train); err != nil {
+			t.Fatal(err)
+		}
+	}
+	exe.clock = clock.NewFakeClock(now)
+	exe.probe = &httpActivityProbe{client: server.Client(), baseURL: func(*v1.Traincrd) string { return server.URL }}
+	cfg := config.Default()
+	cfg.Culling.IdleTimeout = metav1.Duration{Duration: 2 * time.Hour}
+	cfg.Culling.Channels = map[string]metav1.Duration{"quant": {}}
+	exe.cfg.Store(cfg)
+
+	exe.cullIdle(make(chan struct{}))
+
+	for _, test := range []struct {
+		name     string
+		replicas int
+		culled   corev1.ConditionStatus
+	}{
+		{"idle", 0, corev1.ConditionTrue},
+		{"active", 1, ""},
+		{"exempt", 1, ""},
+		{"resumed", 1, corev1.ConditionFalse},
+	} {
+		train, err := clientTrain.DecisionV1().Traincrds("wangxx").Get(test.name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if train.Spec.Replicas != test.replicas {
+			t.Errorf("%s: expected %d replicas, got %d", test.name, test.replicas, train.Spec.Replicas)
+		}
+		var culled corev1.ConditionStatus
+		if cond := getCondition(&train.Status, v1.TraincrdCulled); cond != nil {
+			culled = cond.Status
+		}
+		if culled != test.culled {
+			t.Errorf("%s: expected Culled=%q, got %q", test.name, test.culled, culled)
+		}
+		if test.name != "exempt" && (train.Status.LastActivityTime == nil || !train.Status.LastActivityTime.Time.Equal(activity["/"+test.name+"/api/status"])) {
+			t.Errorf("%s: expected lastActivityTime %v, got %v", test.name, activity["/"+test.name+"/api/status"], train.Status.LastActivityTime)
+		}
+	}
+}
+
+// slowProbe 模拟无法访问的工作区，记录同时进行的探测数
+type slowProbe struct {
+	mu       sync.Mutex
+	inFlight int
+	max      int
+}
+
+func (p *slowProbe) LastActivity(train *v1.Traincrd, path string) (time.Time, error) {
+	p.mu.Lock()
+	p.inFlight++
+	if p.inFlight > p.max {
+		p.max = p.inFlight
+	}
+	p.mu.Unlock()
+	time.Sleep(50 * time.Millisecond)
+	p.mu.Lock()
+	p.inFlight--
+	p.mu.Unlock()
+	return time.Time{}, fmt.Errorf("dial tcp: i/o timeout")
+}
+
+func TestCullIdleProbesConcurrently(t *testing.T) {
+	exe := New(trainfake.NewSimpleClientset(), k8sfake.NewSimpleClientset(), nil, 0)
+	for i := 0; i < 3*cullWorkers; i++ {
+		train := &v1.Traincrd{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("notebook-%d", i), Namespace: "wangxx", Labels: map[string]string{"username": "wangxx", "channel": "risk"}},
+			Spec:       v1.TraincrdSpec{Image: "jupyter:1.0", Cpu: "1", Memory: "1Gi", Replicas: 1},
+			Status:     v1.TraincrdStatus{Replicas: 1, ReadyReplicas: 1},
+		}
+		if err := exe.informer.GetIndexer().Add(train); err != nil {
+			t.Fatal(err)
+		}
+	}
+	probe := &slowProbe{}
+	exe.probe = probe
+	cfg := config.Default()
+	cfg.Culling.IdleTimeout = metav1.Duration{Duration: 2 * time.Hour}
+	exe.cfg.Store(cfg)
+
+	exe.cullIdle(make(chan struct{}))
+	if probe.inFlight != 0 {
+		t.Errorf("expected cullIdle to wait for every probe, %d still running", probe.inFlight)
+	}
+	if probe.max <= 1 || probe.max > cullWorkers {
+		t.Errorf("expected between 2 and %d concurrent probes, got %d", cullWorkers, probe.max)
+	}
+}
+
+func TestCulledWorkspaceResumes(t *testing.T) {
+	train := &v1.Traincrd{
+		Spec: v1.TraincrdSpec{Replicas: 1},
+		Status: v1.TraincrdStatus{Conditions: []v1.TraincrdCondition{
+			{Type: v1.TraincrdCulled, Status: corev1.ConditionTrue, Reason: "Idle"},
+		}},
+	}
+	status := traindeployBuild(train, config.Default()).computeStatus(train, &children{}, nil)
+	if cond := getCondition(&status, v1.TraincrdCulled); cond == nil || cond.Status != corev1.ConditionFalse || cond.Reason != "Resumed" {
+		t.Errorf("expected Culled=False with reason Resumed, got %+v", cond)
+	}
+
+	train.Spec.Replicas = 0
+	status = traindeployBuild(train, config.Default()).computeStatus(train, &children{}, nil)
+	if cond := getCondition(&status, v1.TraincrdCulled); cond == nil || cond.Status != corev1.ConditionTrue {
+		t.Errorf("expected the workspace to stay culled while replicas is 0, got %+v", cond)
+	}
+}
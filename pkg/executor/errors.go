@@ -0,0 +1,75 @@
+package executor
+
+import (
+	"errors"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// errorClass 决定失败的 key 如何重新入队
+type errorClass int
+
+const (
+	// errRetriable 临时错误（网络、apiserver 不可用等），按指数退避重试
+	errRetriable errorClass = iota
+	// errConflict 缓存中的对象过期导致的冲突，按退避重新入队，不会因超过 maxRetries 被丢弃
+	errConflict
+	// errPermanent 需要用户修改 spec 才能恢复，重试没有意义
+	errPermanent
+)
+
+// permanentError 标记无需重试的错误，reason 作为 condition 与 Event 的 Reason
+type permanentError struct {
+	reason string
+	err    error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+func permanent(reason string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{reason: reason, err: err}
+}
+
+// asPermanent 在错误链中查找 permanentError，被 fmt.Errorf("%w") 包装的也能识别
+func asPermanent(err error) (*permanentError, bool) {
+	var pe *permanentError
+	if errors.As(err, &pe) && pe != nil {
+		return pe, true
+	}
+	return nil, false
+}
+
+func classify(err error) errorClass {
+	if _, ok := asPermanent(err); ok {
+		return errPermanent
+	}
+	switch {
+	case apierrors.IsConflict(err), apierrors.IsAlreadyExists(err):
+		return errConflict
+	case apierrors.IsInvalid(err), apierrors.IsBadRequest(err):
+		// apiserver 拒绝了根据 spec 生成的对象
+		return errPermanent
+	default:
+		return errRetriable
+	}
+}
+
+// permanentReason 返回永久错误的 Reason，非永久错误返回 false
+func permanentReason(err error) (string, bool) {
+	if classify(err) != errPermanent {
+		return "", false
+	}
+	if pe, ok := asPermanent(err); ok {
+		return pe.reason, true
+	}
+	return "Rejected", true
+}
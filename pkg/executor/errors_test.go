@@ -0,0 +1,108 @@
+package executor
+
+import (
+	"fmt"
+	"testing"
+
+	v1 "finupgroup.com/decision/traincrd/pkg/apis/v1"
+	trainfake "finupgroup.com/decision/traincrd/pkg/client/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+)
+
+func TestClassify(t *testing.T) {
+	gr := schema.GroupResource{Group: "apps", Resource: "deployments"}
+	gk := schema.GroupKind{Group: "apps", Kind: "Deployment"}
+	invalidResources := permanent("InvalidResources", fmt.Errorf("spec.cpu %q: invalid", "lots"))
+
+	tests := []struct {
+		name   string
+		err    error
+		class  errorClass
+		reason string
+	}{
+		{"permanent", invalidResources, errPermanent, "InvalidResources"},
+		{"wrapped permanent", fmt.Errorf("build deployment: %w", invalidResources), errPermanent, "InvalidResources"},
+		{"conflict", errors.NewConflict(gr, "notebook", fmt.Errorf("the object has been modified")), errConflict, ""},
+		{"already exists", errors.NewAlreadyExists(gr, "notebook"), errConflict, ""},
+		{"invalid", errors.NewInvalid(gk, "notebook", field.ErrorList{field.Required(field.NewPath("spec", "template"), "")}), errPermanent, "Rejected"},
+		{"bad request", errors.NewBadRequest("bad"), errPermanent, "Rejected"},
+		{"unavailable", errors.NewServiceUnavailable("etcd"), errRetriable, ""},
+		{"network", fmt.Errorf("dial tcp 10.0.0.1:443: connect: connection refused"), errRetriable, ""},
+	}
+
+	for _, test := range tests {
+		if class := classify(test.err); class != test.class {
+			t.Errorf("%s: expected class %d, got %d", test.name, test.class, class)
+		}
+		reason, ok := permanentReason(test.err)
+		if ok != (test.reason != "") || reason != test.reason {
+			t.Errorf("%s: expected reason %q, got %q", test.name, test.reason, reason)
+		}
+	}
+}
+
+func TestHandleErr(t *testing.T) {
+	exe := New(trainfake.NewSimpleClientset(), k8sfake.NewSimpleClientset(), nil, 0)
+	gr := schema.GroupResource{Group: "apps", Resource: "deployments"}
+
+	tests := []struct {
+		name     string
+		err      error
+		requeues int
+		queued   bool
+	}{
+		{"success", nil, 0, false},
+		{"permanent", permanent("InvalidResources", fmt.Errorf("spec.cpu: invalid")), 0, false},
+		{"conflict", errors.NewConflict(gr, "notebook", fmt.Errorf("the object has been modified")), 2, true},
+		{"retriable", fmt.Errorf("connection refused"), 2, true},
+	}
+
+	for _, test := range tests {
+		// 零延迟的限速器让 AddRateLimited 立即入队
+		queue := workqueue.NewRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(0, 0))
+		queue.AddRateLimited("wangxx/notebook")
+		queue.Get()
+		queue.Done("wangxx/notebook")
+
+		exe.handleErr(queue, test.err, "wangxx/notebook")
+		if n := queue.NumRequeues("wangxx/notebook"); n != test.requeues {
+			t.Errorf("%s: expected %d requeues, got %d", test.name, test.requeues, n)
+		}
+		if queued := queue.Len() == 1; queued != test.queued {
+			t.Errorf("%s: expected queued %v, got %v", test.name, test.queued, queued)
+		}
+		queue.ShutDown()
+	}
+}
+
+func TestPermanentErrorEvent(t *testing.T) {
+	train := &v1.Traincrd{
+		ObjectMeta: metav1.ObjectMeta{Name: "notebook", Namespace: "wangxx", UID: "6c1e4f3a", Labels: map[string]string{"username": "wangxx", "channel": "risk"}},
+		Spec:       v1.TraincrdSpec{Image: "jupyter:1.0", Cpu: "lots", Memory: "1Gi", ReqCpu: "500m", ReqMemory: "512Mi", Replicas: 1, Capacity: "1Gi"},
+	}
+	exe := New(trainfake.NewSimpleClientset(train), k8sfake.NewSimpleClientset(), nil, 0)
+	recorder := record.NewFakeRecorder(10)
+	exe.recorder = recorder
+	if err := exe.informer.GetIndexer().Add(train); err != nil {
+		t.Fatal(err)
+	}
+
+	err := exe.Reconcile("wangxx", "notebook")
+	if classify(err) != errPermanent {
+		t.Fatalf("expected a permanent error, got %v", err)
+	}
+	if event := <-recorder.Events; event != fmt.Sprintf("Warning InvalidResources %v", err) {
+		t.Errorf("unexpected event %q", event)
+	}
+	train, _ = exe.clientTrain.DecisionV1().Traincrds("wangxx").Get("notebook", metav1.GetOptions{})
+	if cond := getCondition(&train.Status, v1.TraincrdReconciled); cond == nil || cond.Status != corev1.ConditionFalse || cond.Reason != "InvalidResources" {
+		t.Errorf("expected Reconciled=False with reason InvalidResources, got %+v", cond)
+	}
+}
@@ -1,28 +1,86 @@
 package executor
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
 	v1 "finupgroup.com/decision/traincrd/pkg/apis/v1"
 	clientsetT "finupgroup.com/decision/traincrd/pkg/client/clientset/versioned"
+	"finupgroup.com/decision/traincrd/pkg/client/clientset/versioned/scheme"
+	"finupgroup.com/decision/traincrd/pkg/config"
+	corev1 "k8s.io/api/core/v1"
 	k8v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/clock"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog"
 )
 
+// maxRetries 单个 key 失败后按指数退避重试的最大次数，超过后丢弃，等待下一次事件
+const maxRetries = 15
 
 type Executor struct {
 	clientTrain clientsetT.Interface
 	clientK8s   kubernetes.Interface
-}
+	informer    cache.SharedIndexInformer
+	queue       workqueue.RateLimitingInterface
+	recorder    record.EventRecorder
+	cfg         atomic.Value
+
+	// profileInformer 缓存 ClusterTraincrd
+	profileInformer cache.SharedIndexInformer
 
-func New(client clientsetT.Interface, clientK8 kubernetes.Interface) *Executor {
-	return &Executor{clientTrain: client, clientK8s: clientK8}
+	// clock 与 probe 供空闲 culling 使用，测试中替换为 fake clock 与本地 server
+	clock clock.Clock
+	probe activityProbe
+	// scheduler 在 spec.ttl、spec.expiresAt 与 spec.schedule 到期时重新入队
+	scheduler *scheduler
+
+	// clientDynamic 访问 VolumeSnapshot，snapshotInformer 与 snapshotQueue 处理 TraincrdSnapshot
+	clientDynamic    dynamic.Interface
+	snapshotInformer cache.SharedIndexInformer
+	snapshotQueue    workqueue.RateLimitingInterface
+
+	startInformer sync.Once
 }
 
-func (exe *Executor) Run() {
-	informer := cache.NewSharedInformer(&cache.ListWatch{
+// New 构建 Executor，resync 为 informer 周期性全量 reconcile 的间隔（level-driven），0 表示不做周期 resync
+func New(client clientsetT.Interface, clientK8 kubernetes.Interface, clientDynamic dynamic.Interface, resync time.Duration) *Executor {
+	exe := &Executor{
+		clientTrain:   client,
+		clientK8s:     clientK8,
+		clientDynamic: clientDynamic,
+		queue:         workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "traincrds"),
+		snapshotQueue: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "traincrdsnapshots"),
+	}
+	exe.cfg.Store(config.Default())
+	exe.clock = clock.RealClock{}
+	exe.scheduler = newScheduler(exe.clock, func(key string) { exe.queue.Add(key) })
+	exe.probe = &httpActivityProbe{
+		client: &http.Client{Timeout: 10 * time.Second},
+		baseURL: func(train *v1.Traincrd) string {
+			return fmt.Sprintf("http://%s.%s.svc:%d", train.Name, train.Namespace, exe.config().Workspace.Port)
+		},
+	}
+
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartLogging(klog.Infof)
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: clientK8.CoreV1().Events("")})
+	exe.recorder = broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "train-controller"})
+
+	exe.informer = cache.NewSharedIndexInformer(&cache.ListWatch{
 		ListFunc: func(options k8v1.ListOptions) (object runtime.Object, e error) {
 			return exe.clientTrain.DecisionV1().Traincrds(k8v1.NamespaceAll).List(options)
 		},
@@ -31,71 +89,189 @@ func (exe *Executor) Run() {
 		},
 	},
 		&v1.Traincrd{},
-		0,
+		resync,
+		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc, PROFILE_INDEX: profileIndexFunc, RESTORE_INDEX: restoreIndexFunc},
 	)
+	exe.profileInformer = exe.newProfileInformer()
+	exe.snapshotInformer = exe.newSnapshotInformer()
 
 	klog.Info("setup the handler for informer..")
-	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+	exe.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
 			train := obj.(*v1.Traincrd)
 			klog.Infof("add train,  name: %s, ns: %s", train.Name, train.Namespace)
-			traindeploy := traindeployBuild(train)
-			traindeploy.clientK8s = exe.clientK8s
-			err := traindeploy.trainCreate()
-			if err != nil {
-				klog.Errorln("创建 失败，", traindeploy.toString(), err.Error())
-			} else {
-				klog.Infof("创建 成功，", traindeploy.toString())
-			}
+			exe.enqueue(obj)
 		},
 		UpdateFunc: func(oldObj, newObj interface{}) {
-			trainO := oldObj.(*v1.Traincrd)
-			trainN := newObj.(*v1.Traincrd)
-			traindeployO := traindeployBuild(trainO)
-			traindeployN := traindeployBuild(trainN)
-
-			// level-driven, “e.g., every five minutes”
-			// 部分可变属性发生变化时触发更新操作
-			deployChanged := false
-			if traindeployO.cpu != traindeployN.cpu ||
-				traindeployO.reqCpu != traindeployN.reqCpu ||
-				traindeployO.memory != traindeployN.memory ||
-				traindeployO.reqMemory != traindeployN.reqMemory ||
-				traindeployO.replicas != traindeployN.replicas ||
-				traindeployO.image != traindeployN.image{
-				deployChanged = true
-			}
-			if !deployChanged {
-				return
-			}
-
-			klog.Infof("update train, from %s to %s, \n", traindeployO.toString(), traindeployN.toString())
-			traindeployO.clientK8s = exe.clientK8s
-			_, err := traindeployO.updateOrGetDeployment(traindeployN)
-			if err != nil {
-				klog.Fatal("更新  失败，", traindeployO.toString(), err.Error())
-			} else {
-				klog.Infof("更新  成功，", traindeployO.toString())
-			}
+			// resync 时 old 与 new 相同，同样入队，由 Reconcile 修复子资源的偏差
+			exe.enqueue(newObj)
 		},
 		DeleteFunc: func(obj interface{}) {
-			train := obj.(*v1.Traincrd)
-			traindeploy := traindeployBuild(train)
-			traindeploy.clientK8s = exe.clientK8s
-
-			klog.Infof("delete train,  name: %s, ns: %s", train.Name, train.Namespace)
-			err := traindeploy.deleteTrain()
-			if err != nil {
-				klog.Fatal("删除  失败，", traindeploy.toString(), err.Error())
-			} else {
-				klog.Infof("删除  成功，", traindeploy.toString())
-			}
+			klog.Infof("delete train, %v", obj)
+			exe.enqueue(obj)
 		},
 	})
 
-	stopCh := make(chan struct{})
-	defer close(stopCh)
+	return exe
+}
+
+// SetConfig 替换平台配置，并把所有 Traincrd 重新入队，让子资源按新配置收敛
+func (exe *Executor) SetConfig(cfg *config.Config) {
+	exe.cfg.Store(cfg)
+	for _, key := range exe.informer.GetStore().ListKeys() {
+		exe.queue.Add(key)
+	}
+}
+
+func (exe *Executor) config() *config.Config {
+	return exe.cfg.Load().(*config.Config)
+}
+
+// enqueue 把 namespace/name 形式的 key 放入队列，删除事件可能携带 DeletedFinalStateUnknown
+func (exe *Executor) enqueue(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	exe.queue.Add(key)
+}
+
+// StartInformer 启动 informer，不处理队列；备用副本借此保持缓存与队列是热的，当选 leader 后可以立即开始处理
+func (exe *Executor) StartInformer(stopCh <-chan struct{}) {
+	exe.startInformer.Do(func() {
+		go exe.informer.Run(stopCh)
+		go exe.profileInformer.Run(stopCh)
+		go exe.snapshotInformer.Run(stopCh)
+	})
+}
+
+// Run 启动 informer（如未启动）与 workers 个处理协程，阻塞直到 ctx 取消。
+// ctx 取消后队列不再接收新的 key，workers 不再取新的 key，正在执行的 reconcile 最多等待 drainTimeout；
+// 超时仍未完成时返回错误，这些 key 由下一任 leader 重新 list 后处理。
+func (exe *Executor) Run(ctx context.Context, workers int, drainTimeout time.Duration) error {
+	defer utilruntime.HandleCrash()
+
+	exe.StartInformer(ctx.Done())
+
+	if !cache.WaitForCacheSync(ctx.Done(), exe.informer.HasSynced, exe.profileInformer.HasSynced, exe.snapshotInformer.HasSynced) {
+		exe.queue.ShutDown()
+		exe.snapshotQueue.ShutDown()
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("timed out waiting for traincrd cache to sync")
+	}
+
+	klog.Infof("cache synced, starting %d workers", workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			wait.Until(func() {
+				for exe.processNextItem(ctx, exe.queue, exe.sync) {
+				}
+			}, time.Second, ctx.Done())
+		}()
+	}
+
+	// TraincrdSnapshot 数量少且只需创建 VolumeSnapshot、同步 status，一个协程足够
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		wait.Until(func() {
+			for exe.processNextItem(ctx, exe.snapshotQueue, exe.syncSnapshot) {
+			}
+		}, time.Second, ctx.Done())
+	}()
+	go func() {
+		defer wg.Done()
+		exe.runCuller(ctx.Done())
+	}()
+	go func() {
+		defer wg.Done()
+		exe.scheduler.run(ctx.Done())
+	}()
+
+	<-ctx.Done()
+	klog.Infof("shutting down workers, waiting up to %v for in-flight reconciles", drainTimeout)
+	// 唤醒阻塞在 Get 上的 workers，之后的 Add 都被忽略
+	exe.queue.ShutDown()
+	exe.snapshotQueue.ShutDown()
 
-	informer.Run(stopCh)
+	drained := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		klog.Info("all workers drained")
+		return nil
+	case <-time.After(drainTimeout):
+		return fmt.Errorf("in-flight reconciles did not finish within %v", drainTimeout)
+	}
 }
 
+// processNextItem 从 queue 取出一个 key 交给 sync 处理，Traincrd 与 TraincrdSnapshot 的队列共用
+func (exe *Executor) processNextItem(ctx context.Context, queue workqueue.RateLimitingInterface, sync func(key string) error) bool {
+	key, quit := queue.Get()
+	if quit {
+		return false
+	}
+	defer queue.Done(key)
+	// 关闭后队列中剩余的 key 不再处理
+	if ctx.Err() != nil {
+		return false
+	}
+	// 单个 Traincrd 的 panic 不能影响其他租户，记录后按失败重试
+	defer func() {
+		if r := recover(); r != nil {
+			utilruntime.HandleError(fmt.Errorf("处理 %v panic: %v", key, r))
+			queue.AddRateLimited(key)
+		}
+	}()
+
+	err := sync(key.(string))
+	exe.handleErr(queue, err, key)
+	return true
+}
+
+// handleErr 成功或永久错误时清除退避记录，其余错误按指数退避重新入队，冲突不计入 maxRetries
+func (exe *Executor) handleErr(queue workqueue.RateLimitingInterface, err error, key interface{}) {
+	if err == nil {
+		queue.Forget(key)
+		return
+	}
+
+	switch classify(err) {
+	case errPermanent:
+		klog.Errorf("处理 %v 失败, 需要修改 spec, 不再重试: %v", key, err)
+		queue.Forget(key)
+		return
+	case errConflict:
+		klog.V(2).Infof("处理 %v 冲突, 重新入队: %v", key, err)
+		queue.AddRateLimited(key)
+		return
+	}
+
+	if queue.NumRequeues(key) < maxRetries {
+		klog.Errorf("处理 %v 失败, 稍后重试: %v", key, err)
+		queue.AddRateLimited(key)
+		return
+	}
+
+	queue.Forget(key)
+	utilruntime.HandleError(fmt.Errorf("处理 %v 失败, 放弃重试: %v", key, err))
+}
+
+func (exe *Executor) sync(key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return nil
+	}
+
+	return exe.Reconcile(namespace, name)
+}
@@ -0,0 +1,53 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	trainfake "finupgroup.com/decision/traincrd/pkg/client/clientset/versioned/fake"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/util/workqueue"
+)
+
+func TestRetryUntilMaxRetries(t *testing.T) {
+	exe := New(trainfake.NewSimpleClientset(), k8sfake.NewSimpleClientset(), nil, 0)
+	// 零延迟的限速器让 AddRateLimited 立即入队
+	queue := workqueue.NewRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(0, 0))
+	defer queue.ShutDown()
+
+	calls := 0
+	failing := func(key string) error {
+		calls++
+		return fmt.Errorf("connection refused")
+	}
+	queue.Add("wangxx/notebook")
+	for queue.Len() > 0 {
+		exe.processNextItem(context.Background(), queue, failing)
+	}
+	if calls != maxRetries+1 {
+		t.Errorf("expected %d attempts before giving up, got %d", maxRetries+1, calls)
+	}
+	if n := queue.NumRequeues("wangxx/notebook"); n != 0 {
+		t.Errorf("expected the key to be forgotten after giving up, got %d requeues", n)
+	}
+
+	// 重试中成功后清除退避记录
+	calls = 0
+	flaky := func(key string) error {
+		if calls++; calls < 3 {
+			return fmt.Errorf("connection refused")
+		}
+		return nil
+	}
+	queue.Add("wangxx/notebook")
+	for queue.Len() > 0 {
+		exe.processNextItem(context.Background(), queue, flaky)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls)
+	}
+	if n := queue.NumRequeues("wangxx/notebook"); n != 0 {
+		t.Errorf("expected the key to be forgotten after success, got %d requeues", n)
+	}
+}
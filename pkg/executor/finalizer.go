@@ -0,0 +1,267 @@
+package executor
+
+import (
+	"fmt"
+	"time"
+
+	v1 "finupgroup.com/decision/traincrd/pkg/apis/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog"
+)
+
+// CLEANUP_FINALIZER 保证 Traincrd 删除前 executor 已经按 retainPolicy 处理完用户数据
+const CLEANUP_FINALIZER = "decision.finupgroup.com/cleanup"
+
+// archivePollInterval 归档 Job 未完成时重新检查的间隔
+const archivePollInterval = 15 * time.Second
+
+func (exe *Executor) addFinalizer(train *v1.Traincrd) (*v1.Traincrd, error) {
+	updated := train.DeepCopy()
+	updated.Finalizers = append(updated.Finalizers, CLEANUP_FINALIZER)
+	return exe.clientTrain.DecisionV1().Traincrds(train.Namespace).Update(updated)
+}
+
+func (exe *Executor) removeFinalizer(train *v1.Traincrd) error {
+	updated := train.DeepCopy()
+	updated.Finalizers = removeString(train.Finalizers, CLEANUP_FINALIZER)
+	_, err := exe.clientTrain.DecisionV1().Traincrds(train.Namespace).Update(updated)
+	return err
+}
+
+/**
+Traincrd 正在删除：按 retainPolicy 处理 PVC，完成后移除 finalizer。
+失败时保留 finalizer 阻止删除，并把原因写入 status 的 CleanedUp condition。
+*/
+func (exe *Executor) finalize(train *v1.Traincrd, t *Traindeploy) error {
+	if !containsString(train.Finalizers, CLEANUP_FINALIZER) {
+		return nil
+	}
+
+	done, err := t.teardown()
+	if err == nil && done {
+		klog.Infof("清理完成，移除 finalizer %s", t.toString())
+		return exe.removeFinalizer(train)
+	}
+
+	status := train.Status.DeepCopy()
+	status.Phase = v1.TraincrdTerminating
+	reason, failed := permanentReason(err)
+	switch {
+	case failed:
+		// 需要用户处理，例如删除失败的归档 Job 后重试，原因变化时记录一次事件
+		if old := getCondition(&train.Status, v1.TraincrdCleanedUp); old == nil || old.Reason != reason {
+			exe.recorder.Event(train, corev1.EventTypeWarning, reason, err.Error())
+		}
+		setCondition(status, v1.TraincrdCleanedUp, corev1.ConditionFalse, reason, err.Error())
+	case err != nil:
+		setCondition(status, v1.TraincrdCleanedUp, corev1.ConditionFalse, "TeardownFailed", err.Error())
+	case t.cleaningClone:
+		setCondition(status, v1.TraincrdCleanedUp, corev1.ConditionFalse, "RemovingClone",
+			fmt.Sprintf("waiting for the clone jobs to stop and job %s to remove the staged data", t.cloneCleanupJobName()))
+	default:
+		setCondition(status, v1.TraincrdCleanedUp, corev1.ConditionFalse, "Archiving",
+			fmt.Sprintf("waiting for the workspace pods to stop and job %s to archive the workspace volume", t.archiveJobName()))
+	}
+	if uerr := exe.updateStatus(train, *status); uerr != nil {
+		klog.Errorf("更新 status 失败，%s: %v", t.toString(), uerr)
+	}
+
+	if err != nil && !failed {
+		return err
+	}
+	// 归档与清理 Job 被删除后不会触发 Traincrd 的事件，定期检查
+	exe.queue.AddAfter(train.Namespace+"/"+train.Name, archivePollInterval)
+	return nil
+}
+
+// teardown 返回 true 表示 PVC 已按 retainPolicy 处理完毕，可以释放 finalizer
+func (t *Traindeploy) teardown() (bool, error) {
+	if done, err := t.cleanupClone(); !done || err != nil {
+		return done, err
+	}
+	switch t.policy {
+	case v1.RetainPolicyRetain:
+		return true, t.releasePersistentVolumeClaim()
+	case v1.RetainPolicyArchive:
+		if done, err := t.archive(); !done || err != nil {
+			return done, err
+		}
+	}
+	// Delete 与 Archive: PVC 带 OwnerReference，Traincrd 删除后由垃圾回收清理，PVC 之外的存储由存储实现清理
+	return true, t.releaseStorage()
+}
+
+// releaseStorage 静态卷的 PV 不随 PVC 回收，在这里删除
+func (t *Traindeploy) releaseStorage() error {
+	pvc, err := t.clientK8s.CoreV1().PersistentVolumeClaims(t.namespace).Get(t.name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	// Retain 之后被其他 Traincrd 接管的 PVC 不属于本工作区
+	if !t.controlled(pvc) {
+		return nil
+	}
+	return providerOf(pvc).release(t, pvc)
+}
+
+func (t *Traindeploy) releasePersistentVolumeClaim() error {
+	pvc, err := t.clientK8s.CoreV1().PersistentVolumeClaims(t.namespace).Get(t.name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_, err = t.setPersistentVolumeClaimOwner(pvc)
+	return err
+}
+
+/**
+归档：先删除 Deployment 并等待工作区 Pod 退出，再启动 Job 把工作目录打包到公共存储，Job 成功后才允许删除
+*/
+func (t *Traindeploy) archive() (bool, error) {
+	_, err := t.clientK8s.CoreV1().PersistentVolumeClaims(t.namespace).Get(t.name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		klog.Infof("PVC 不存在，无需归档 %s", t.toString())
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	job, err := t.clientK8s.BatchV1().Jobs(t.namespace).Get(t.archiveJobName(), metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		if stopped, err := t.quiesce(); !stopped || err != nil {
+			return false, err
+		}
+		klog.Infof("创建归档 Job %s", t.archiveJobName())
+		_, err = t.clientK8s.BatchV1().Jobs(t.namespace).Create(t.makeArchiveJobSpec())
+		return false, err
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if job.Status.Succeeded > 0 {
+		return true, nil
+	}
+	for _, cond := range job.Status.Conditions {
+		if cond.Type == batchv1.JobFailed && cond.Status == corev1.ConditionTrue {
+			return false, permanent("ArchiveFailed", fmt.Errorf("archive job %s failed: %s, delete the job to retry", job.Name, cond.Message))
+		}
+	}
+	return false, nil
+}
+
+/**
+quiesce 删除工作区的 Deployment，返回 true 表示工作区 Pod 都已退出：
+Deployment 要等 Traincrd 删除后才由垃圾回收清理，归档时工作区仍在写入会得到不一致的归档
+*/
+func (t *Traindeploy) quiesce() (bool, error) {
+	background := metav1.DeletePropagationBackground
+	err := t.clientK8s.AppsV1().Deployments(t.namespace).Delete(t.name, &metav1.DeleteOptions{PropagationPolicy: &background})
+	if err != nil && !errors.IsNotFound(err) {
+		return false, err
+	}
+	selector := labels.SelectorFromSet(map[string]string{"app": t.name, "username": t.username, "channel": t.channel})
+	pods, err := t.clientK8s.CoreV1().Pods(t.namespace).List(metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return false, err
+	}
+	if len(pods.Items) > 0 {
+		klog.Infof("等待 %d 个工作区 Pod 退出后归档 %s", len(pods.Items), t.toString())
+		return false, nil
+	}
+	return true, nil
+}
+
+func (t *Traindeploy) archiveJobName() string {
+	return t.name + "-archive"
+}
+
+// archivePath 以 Traincrd 的 UID 命名，Job 重试时覆盖同一个文件
+func (t *Traindeploy) archivePath() string {
+	return fmt.Sprintf("%s/%s/%s/%s-%s.tar.gz", t.cfg.Archive.Dir, t.channel, t.username, t.name, t.uid)
+}
+
+func (t *Traindeploy) makeArchiveJobSpec() *batchv1.Job {
+	backoffLimit := int32(3)
+	// 超时后 Job 失败，不会无限期阻塞删除
+	deadline := int64(t.cfg.Archive.Timeout.Seconds())
+	public := t.cfg.Workspace.PublicStorage
+	archivePath := t.archivePath()
+	script := fmt.Sprintf("mkdir -p $(dirname %[1]s) && tar -czf %[1]s.tmp -C /workspace . && mv %[1]s.tmp %[1]s", archivePath)
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            t.archiveJobName(),
+			Labels:          map[string]string{"app": t.name, "username": t.username, "channel": t.channel},
+			OwnerReferences: t.ownerReferences(),
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:          &backoffLimit,
+			ActiveDeadlineSeconds: &deadline,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{
+						{
+							Name:    "archive",
+							Image:   t.cfg.Archive.Image,
+							Command: []string{"sh", "-c", script},
+							VolumeMounts: []corev1.VolumeMount{
+								{Name: "workspace", MountPath: "/workspace", ReadOnly: true},
+								{Name: public.ClaimName, MountPath: public.MountPath},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "workspace",
+							VolumeSource: corev1.VolumeSource{
+								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+									ClaimName: t.name,
+									ReadOnly:  true,
+								},
+							},
+						},
+						{
+							Name: public.ClaimName,
+							VolumeSource: corev1.VolumeSource{
+								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+									ClaimName: public.ClaimName,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func containsString(slice []string, s string) bool {
+	for _, item := range slice {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func removeString(slice []string, s string) []string {
+	var result []string
+	for _, item := range slice {
+		if item != s {
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -0,0 +1,114 @@
+package executor
+
+import (
+	"strings"
+	"testing"
+
+	v1 "finupgroup.com/decision/traincrd/pkg/apis/v1"
+	trainfake "finupgroup.com/decision/traincrd/pkg/client/clientset/versioned/fake"
+	"finupgroup.com/decision/traincrd/pkg/config"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+// deleteTraincrd 模拟 kubectl delete：设置 deletionTimestamp 后 reconcile
+func deleteTraincrd(t *testing.T, exe *Executor, namespace, name string) *v1.Traincrd {
+	train, err := exe.clientTrain.DecisionV1().Traincrds(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if train.DeletionTimestamp == nil {
+		now := metav1.Now()
+		train.DeletionTimestamp = &now
+		if _, err := exe.clientTrain.DecisionV1().Traincrds(namespace).Update(train); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return reconcileOnce(t, exe, namespace, name)
+}
+
+func TestArchiveWaitsForWorkspace(t *testing.T) {
+	train := &v1.Traincrd{
+		ObjectMeta: metav1.ObjectMeta{Name: "notebook", Namespace: "wangxx", UID: "6c1e4f3a", Labels: map[string]string{"username": "wangxx", "channel": "risk"}},
+		Spec: v1.TraincrdSpec{Image: "jupyter:1.0", Cpu: "1", Memory: "1Gi", ReqCpu: "500m", ReqMemory: "512Mi", Replicas: 1, Capacity: "1Gi",
+			RetainPolicy: v1.RetainPolicyArchive},
+	}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "notebook-5d8f7-x2k4q", Namespace: "wangxx",
+		Labels: map[string]string{"app": "notebook", "username": "wangxx", "channel": "risk"}}}
+	clientK8s := k8sfake.NewSimpleClientset(pod)
+	exe := New(trainfake.NewSimpleClientset(train), clientK8s, nil, 0)
+	recorder := record.NewFakeRecorder(10)
+	exe.recorder = recorder
+	reconcileOnce(t, exe, "wangxx", "notebook")
+
+	// 工作区 Pod 仍在运行时只删除 Deployment，不创建归档 Job
+	train = deleteTraincrd(t, exe, "wangxx", "notebook")
+	if _, err := clientK8s.AppsV1().Deployments("wangxx").Get("notebook", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("expected the Deployment to be deleted before archiving, got %v", err)
+	}
+	if _, err := clientK8s.BatchV1().Jobs("wangxx").Get("notebook-archive", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("expected no archive Job while the workspace pod runs, got %v", err)
+	}
+	if cond := getCondition(&train.Status, v1.TraincrdCleanedUp); cond == nil || cond.Reason != "Archiving" {
+		t.Errorf("expected CleanedUp with reason Archiving, got %+v", cond)
+	}
+
+	if err := clientK8s.CoreV1().Pods("wangxx").Delete(pod.Name, &metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	deleteTraincrd(t, exe, "wangxx", "notebook")
+	job, err := clientK8s.BatchV1().Jobs("wangxx").Get("notebook-archive", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected the archive Job once the workspace pods are gone: %v", err)
+	}
+	if deadline := job.Spec.ActiveDeadlineSeconds; deadline == nil || *deadline != 3600 {
+		t.Errorf("expected the archive Job to time out after archive.timeout, got %v", deadline)
+	}
+
+	// 失败的归档 Job 记录在 CleanedUp condition 中，删除 Job 后重新归档
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue,
+		Reason: "DeadlineExceeded", Message: "Job was active longer than specified deadline"}}
+	if _, err := clientK8s.BatchV1().Jobs("wangxx").UpdateStatus(job); err != nil {
+		t.Fatal(err)
+	}
+	train = deleteTraincrd(t, exe, "wangxx", "notebook")
+	cond := getCondition(&train.Status, v1.TraincrdCleanedUp)
+	if cond == nil || cond.Reason != "ArchiveFailed" || !strings.Contains(cond.Message, "delete the job to retry") {
+		t.Errorf("expected CleanedUp with reason ArchiveFailed, got %+v", cond)
+	}
+	if !containsString(train.Finalizers, CLEANUP_FINALIZER) {
+		t.Errorf("expected the finalizer to be kept while the archive failed")
+	}
+	if event := <-recorder.Events; !strings.HasPrefix(event, "Warning ArchiveFailed archive job notebook-archive failed") {
+		t.Errorf("unexpected event %q", event)
+	}
+	if err := clientK8s.BatchV1().Jobs("wangxx").Delete(job.Name, &metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	deleteTraincrd(t, exe, "wangxx", "notebook")
+	if job, err = clientK8s.BatchV1().Jobs("wangxx").Get("notebook-archive", metav1.GetOptions{}); err != nil {
+		t.Fatalf("expected the archive Job to be created again: %v", err)
+	}
+
+	job.Status.Succeeded = 1
+	if _, err := clientK8s.BatchV1().Jobs("wangxx").UpdateStatus(job); err != nil {
+		t.Fatal(err)
+	}
+	if train = deleteTraincrd(t, exe, "wangxx", "notebook"); containsString(train.Finalizers, CLEANUP_FINALIZER) {
+		t.Errorf("expected the finalizer to be removed once archived")
+	}
+}
+
+func TestUnknownRetainPolicyKeepsVolume(t *testing.T) {
+	train := &v1.Traincrd{
+		ObjectMeta: metav1.ObjectMeta{Name: "notebook", Namespace: "wangxx", UID: "6c1e4f3a", Labels: map[string]string{"username": "wangxx", "channel": "risk"}},
+		Spec:       v1.TraincrdSpec{Image: "jupyter:1.0", Cpu: "1", Memory: "1Gi", ReqCpu: "500m", ReqMemory: "512Mi", Replicas: 1, RetainPolicy: "Keep"},
+	}
+	if traindeploy := traindeployBuild(train, config.Default()); traindeploy.policy != v1.RetainPolicyRetain || !traindeploy.keepPVC {
+		t.Errorf("expected an unknown retainPolicy to keep the volume, got %s", traindeploy.policy)
+	}
+}
@@ -0,0 +1,123 @@
+package executor
+
+import (
+	"fmt"
+
+	v1 "finupgroup.com/decision/traincrd/pkg/apis/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	k8v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+)
+
+// PROFILE_INDEX 按 spec.profile 索引 Traincrd，profile 变化时据此找到依赖它的 Traincrd
+const PROFILE_INDEX = "profile"
+
+func profileIndexFunc(obj interface{}) ([]string, error) {
+	train, ok := obj.(*v1.Traincrd)
+	if !ok || train.Spec.Profile == "" {
+		return nil, nil
+	}
+	return []string{train.Spec.Profile}, nil
+}
+
+// newProfileInformer 监听集群级的 ClusterTraincrd，新增、修改、删除时重新入队引用它的 Traincrd
+func (exe *Executor) newProfileInformer() cache.SharedIndexInformer {
+	informer := cache.NewSharedIndexInformer(&cache.ListWatch{
+		ListFunc: func(options k8v1.ListOptions) (runtime.Object, error) {
+			return exe.clientTrain.DecisionV1().ClusterTraincrds().List(options)
+		},
+		WatchFunc: func(options k8v1.ListOptions) (watch.Interface, error) {
+			return exe.clientTrain.DecisionV1().ClusterTraincrds().Watch(options)
+		},
+	},
+		&v1.ClusterTraincrd{},
+		0,
+		cache.Indexers{},
+	)
+
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: exe.enqueueProfileDependents,
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			exe.enqueueProfileDependents(newObj)
+		},
+		DeleteFunc: exe.enqueueProfileDependents,
+	})
+	return informer
+}
+
+func (exe *Executor) enqueueProfileDependents(obj interface{}) {
+	name, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		klog.Errorf("profile key: %v", err)
+		return
+	}
+	dependents, err := exe.informer.GetIndexer().ByIndex(PROFILE_INDEX, name)
+	if err != nil {
+		klog.Errorf("查找引用 profile %s 的 train 失败: %v", name, err)
+		return
+	}
+	klog.V(2).Infof("profile %s 变化，重新入队 %d 个 train", name, len(dependents))
+	for _, train := range dependents {
+		exe.enqueue(train)
+	}
+}
+
+/**
+applyProfile 返回合并 spec.profile 之后的 Traincrd，用户填写的字段优先；未引用 profile 时原样返回
+*/
+func (exe *Executor) applyProfile(train *v1.Traincrd) (*v1.Traincrd, error) {
+	if train.Spec.Profile == "" {
+		return train, nil
+	}
+	obj, exists, err := exe.profileInformer.GetStore().GetByKey(train.Spec.Profile)
+	if err != nil {
+		return train, err
+	}
+	if !exists {
+		return train, permanent("ProfileNotFound", fmt.Errorf("spec.profile %q: ClusterTraincrd not found", train.Spec.Profile))
+	}
+	return mergeProfile(train, obj.(*v1.ClusterTraincrd))
+}
+
+func mergeProfile(train *v1.Traincrd, profile *v1.ClusterTraincrd) (*v1.Traincrd, error) {
+	if channels := profile.Spec.AllowedChannels; len(channels) > 0 && !containsString(channels, train.Labels["channel"]) {
+		return train, permanent("ProfileNotAllowed", fmt.Errorf("spec.profile %q is not allowed for channel %q, allowed: %v", profile.Name, train.Labels["channel"], channels))
+	}
+
+	merged := train.DeepCopy()
+	spec, defaults := &merged.Spec, &profile.Spec
+	fill := func(field *string, value string) {
+		if *field == "" {
+			*field = value
+		}
+	}
+	fill(&spec.Image, defaults.Image)
+	fill(&spec.Cpu, defaults.Cpu)
+	fill(&spec.Memory, defaults.Memory)
+	fill(&spec.Capacity, defaults.Capacity)
+	fill(&spec.Template, defaults.Template)
+	spec.ReqCpu = defaultRequest(spec.ReqCpu, defaults.ReqCpu, spec.Cpu)
+	spec.ReqMemory = defaultRequest(spec.ReqMemory, defaults.ReqMemory, spec.Memory)
+	return merged, nil
+}
+
+// defaultRequest 用户未填写 request 时取 profile 的值，但不能超过最终的 limit，否则 request 等于 limit
+func defaultRequest(request, profileRequest, limit string) string {
+	if request != "" {
+		return request
+	}
+	if profileRequest == "" {
+		return limit
+	}
+	req, err := resource.ParseQuantity(profileRequest)
+	if err != nil {
+		return profileRequest
+	}
+	if lim, err := resource.ParseQuantity(limit); err == nil && req.Cmp(lim) > 0 {
+		return limit
+	}
+	return profileRequest
+}
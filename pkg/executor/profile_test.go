@@ -0,0 +1,73 @@
+package executor
+
+import (
+	"testing"
+
+	v1 "finupgroup.com/decision/traincrd/pkg/apis/v1"
+	trainfake "finupgroup.com/decision/traincrd/pkg/client/clientset/versioned/fake"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+var gpuProfile = &v1.ClusterTraincrd{
+	ObjectMeta: metav1.ObjectMeta{Name: "gpu-small"},
+	Spec: v1.ClusterTraincrdSpec{
+		Image: "jupyter-gpu:1.0", Cpu: "4", Memory: "16Gi", ReqCpu: "2", ReqMemory: "8Gi",
+		Capacity: "50Gi", Template: "tensorboard", AllowedChannels: []string{"risk", "quant"},
+	},
+}
+
+func TestMergeProfile(t *testing.T) {
+	train := &v1.Traincrd{
+		ObjectMeta: metav1.ObjectMeta{Name: "notebook", Namespace: "wangxx", Labels: map[string]string{"channel": "risk"}},
+		Spec:       v1.TraincrdSpec{Profile: "gpu-small", Cpu: "1", Memory: "32Gi", Capacity: "10Gi"},
+	}
+	merged, err := mergeProfile(train, gpuProfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := v1.TraincrdSpec{
+		Profile: "gpu-small", Image: "jupyter-gpu:1.0", Template: "tensorboard",
+		// the user's values win, the profile request may not exceed the user's limit
+		Cpu: "1", ReqCpu: "1", Memory: "32Gi", ReqMemory: "8Gi", Capacity: "10Gi",
+	}
+	if !equality.Semantic.DeepEqual(merged.Spec, expected) {
+		t.Errorf("expected %+v, got %+v", expected, merged.Spec)
+	}
+	if train.Spec.Image != "" {
+		t.Errorf("the cached Traincrd must not be modified")
+	}
+
+	train.Labels["channel"] = "qz"
+	if _, err := mergeProfile(train, gpuProfile); err == nil {
+		t.Errorf("expected channel qz to be rejected")
+	} else if reason, _ := permanentReason(err); reason != "ProfileNotAllowed" {
+		t.Errorf("expected ProfileNotAllowed, got %v", err)
+	}
+}
+
+func TestProfileChangeEnqueuesDependents(t *testing.T) {
+	exe := New(trainfake.NewSimpleClientset(), k8sfake.NewSimpleClientset(), nil, 0)
+	for _, train := range []*v1.Traincrd{
+		{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "wangxx"}, Spec: v1.TraincrdSpec{Profile: "gpu-small"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "lisi"}, Spec: v1.TraincrdSpec{Profile: "gpu-small"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "c", Namespace: "lisi"}, Spec: v1.TraincrdSpec{Profile: "cpu"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "d", Namespace: "lisi"}},
+	} {
+		if err := exe.informer.GetIndexer().Add(train); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	exe.enqueueProfileDependents(gpuProfile)
+	keys := map[interface{}]bool{}
+	for exe.queue.Len() > 0 {
+		key, _ := exe.queue.Get()
+		keys[key] = true
+		exe.queue.Done(key)
+	}
+	if len(keys) != 2 || !keys["wangxx/a"] || !keys["lisi/b"] {
+		t.Errorf("expected wangxx/a and lisi/b to be enqueued, got %v", keys)
+	}
+}
@@ -0,0 +1,410 @@
+package executor
+
+import (
+	"fmt"
+	"time"
+
+	v1 "finupgroup.com/decision/traincrd/pkg/apis/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+)
+
+// Reconcile 读取 Traincrd 的期望状态，让 Deployment、Service、Ingress、PVC 收敛到该状态。
+// 每次 resync 都会调用，可重复执行；手工修改或删除的子资源会被修复。
+func (exe *Executor) Reconcile(namespace, name string) error {
+	obj, exists, err := exe.informer.GetIndexer().GetByKey(namespace + "/" + name)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		// 子资源带有指向 Traincrd 的 OwnerReference，由垃圾回收级联删除
+		klog.Infof("train %s/%s 已删除，子资源交由垃圾回收清理", namespace, name)
+		exe.scheduler.schedule(namespace+"/"+name, time.Time{})
+		return nil
+	}
+
+	train := obj.(*v1.Traincrd)
+	// spec.cloneFrom 继承的字段写回 Traincrd，spec.profile 的默认值只参与构建子资源，不写回
+	train, cloneErr := exe.inheritClone(train)
+	desired, profileErr := exe.applyProfile(train)
+	lc, lifecycleErr := exe.lifecycle(train)
+	traindeploy := traindeployBuild(desired, exe.config())
+	traindeploy.clientK8s = exe.clientK8s
+	traindeploy.clientTrain = exe.clientTrain
+
+	if train.DeletionTimestamp != nil {
+		return exe.finalize(train, traindeploy)
+	}
+	if !containsString(train.Finalizers, CLEANUP_FINALIZER) {
+		if train, err = exe.addFinalizer(train); err != nil {
+			return err
+		}
+	}
+
+	if lc.expired && train.Spec.ExpireAction == v1.ExpireActionDelete {
+		return exe.expire(train, lc)
+	}
+	// 在过期、schedule 的 start 或 stop 时重新 reconcile
+	exe.scheduler.schedule(namespace+"/"+name, lc.next)
+	traindeploy.applyLifecycle(lc)
+
+	c, reconcileErr := &children{}, cloneErr
+	if reconcileErr == nil {
+		reconcileErr = profileErr
+	}
+	if reconcileErr == nil {
+		reconcileErr = lifecycleErr
+	}
+	if reconcileErr == nil {
+		c, reconcileErr = traindeploy.reconcile()
+	}
+	if reason, ok := permanentReason(reconcileErr); ok {
+		exe.recorder.Event(train, corev1.EventTypeWarning, reason, reconcileErr.Error())
+	}
+
+	status := traindeploy.computeStatus(train, c, reconcileErr)
+	if err := exe.updateStatus(train, status); err != nil {
+		klog.Errorf("更新 status 失败，%s: %v", traindeploy.toString(), err)
+		if reconcileErr == nil {
+			return err
+		}
+	} else {
+		exe.recordSuspension(train, &status)
+		exe.recordResize(train, &status)
+	}
+	if traindeploy.cloning {
+		exe.queue.AddAfter(namespace+"/"+name, clonePollInterval)
+	}
+	return reconcileErr
+}
+
+// recordResize 在 StorageResized condition 的 reason 变化并写入 status 后记录扩容被拒绝或完成的事件
+func (exe *Executor) recordResize(train *v1.Traincrd, status *v1.TraincrdStatus) {
+	cond := getCondition(status, v1.TraincrdStorageResized)
+	if cond == nil {
+		return
+	}
+	if old := getCondition(&train.Status, v1.TraincrdStorageResized); old != nil && old.Reason == cond.Reason {
+		return
+	}
+	switch cond.Reason {
+	case "ShrinkNotSupported", "ExpansionNotSupported":
+		exe.recorder.Event(train, corev1.EventTypeWarning, cond.Reason, cond.Message)
+	case "Resized":
+		exe.recorder.Eventf(train, corev1.EventTypeNormal, "Resized", "workspace volume resized to %s", status.Capacity)
+	}
+}
+
+// recordSuspension 在 Suspended condition 变化并写入 status 后记录事件，冲突重试不会重复记录
+func (exe *Executor) recordSuspension(train *v1.Traincrd, status *v1.TraincrdStatus) {
+	cond := getCondition(status, v1.TraincrdSuspendedCondition)
+	if cond == nil {
+		return
+	}
+	if old := getCondition(&train.Status, v1.TraincrdSuspendedCondition); old != nil && old.Status == cond.Status {
+		return
+	}
+	if cond.Status == corev1.ConditionTrue {
+		message := "workspace suspended, scaled to 0 replicas, storage kept"
+		if cond.Reason != "Suspended" {
+			message += ": " + cond.Message
+		}
+		exe.recorder.Event(train, corev1.EventTypeNormal, "Suspended", message)
+		return
+	}
+	exe.recorder.Eventf(train, corev1.EventTypeNormal, "Resumed", "workspace resumed with %d replicas", train.Spec.Replicas)
+}
+
+// expire 删除过期的 Traincrd，PVC 由 finalizer 按 retainPolicy 处理
+func (exe *Executor) expire(train *v1.Traincrd, lc lifecycle) error {
+	message := fmt.Sprintf("workspace expired at %s, deleting", lc.expiresAt.Format(time.RFC3339))
+	klog.Infof("train %s/%s %s", train.Namespace, train.Name, message)
+	exe.recorder.Event(train, corev1.EventTypeNormal, "Expired", message)
+	err := exe.clientTrain.DecisionV1().Traincrds(train.Namespace).Delete(train.Name, &metav1.DeleteOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// children 记录一次 reconcile 后各子资源的最新状态，未能获取的为 nil
+type children struct {
+	pvc        *corev1.PersistentVolumeClaim
+	deployment *appsv1.Deployment
+	svc        *corev1.Service
+	ingress    *v1beta1.Ingress
+}
+
+func (t *Traindeploy) reconcile() (*children, error) {
+	c := &children{}
+	var err error
+
+	if t.templateName != "" {
+		if t.template, err = getWorkspaceTemplate(t.clientTrain, t.clientK8s, t.cfg.Templates.Namespace, t.templateName); err != nil {
+			return c, err
+		}
+	}
+	if c.pvc, err = t.reconcilePersistentVolumeClaim(); err != nil {
+		return c, err
+	}
+	if c.pvc, err = t.populateClone(c.pvc); err != nil {
+		return c, err
+	}
+	if c.deployment, err = t.reconcileDeployment(); err != nil {
+		return c, err
+	}
+	if c.svc, err = t.reconcileSvc(); err != nil {
+		return c, err
+	}
+	c.ingress, err = t.reconcileIngress()
+	return c, err
+}
+
+// reconcilePersistentVolumeClaim 只维护 OwnerReference：retainPolicy 为 Retain 时解除归属，否则由 Traincrd 接管
+func (t *Traindeploy) reconcilePersistentVolumeClaim() (*corev1.PersistentVolumeClaim, error) {
+	existing, err := t.createOrGetPersistentVolumeClaim()
+	if err != nil {
+		return nil, err
+	}
+	pvc, err := t.setPersistentVolumeClaimOwner(existing)
+	if err != nil {
+		return nil, err
+	}
+	return t.resizePersistentVolumeClaim(pvc)
+}
+
+/**
+spec.capacity 大于 PVC 的 request 时在线扩容，需要存储实现支持扩容，见 storageProvider.expandable；
+缩容或不支持扩容时不修改 PVC，原因记录在 resizeErr 中。未填写 capacity 时不跟随 storage.defaultCapacity 变化
+*/
+func (t *Traindeploy) resizePersistentVolumeClaim(pvc *corev1.PersistentVolumeClaim) (*corev1.PersistentVolumeClaim, error) {
+	if t.capacity == "" {
+		return pvc, nil
+	}
+	desired, err := t.desiredCapacity()
+	if err != nil {
+		return pvc, err
+	}
+	current := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	switch desired.Cmp(current) {
+	case 0:
+		return pvc, nil
+	case -1:
+		// 从快照恢复或复制的卷至少为源卷的大小，小于它的 spec.capacity 不算缩容
+		if pvcLineage(pvc) != nil {
+			return pvc, nil
+		}
+		t.resizeErr = permanent("ShrinkNotSupported", fmt.Errorf("spec.capacity %s is smaller than the volume size %s, volumes cannot shrink", desired.String(), current.String()))
+		return pvc, nil
+	}
+
+	if err := providerOf(pvc).expandable(t, pvc); err != nil {
+		if _, ok := asPermanent(err); ok {
+			t.resizeErr = err
+			return pvc, nil
+		}
+		return pvc, err
+	}
+
+	klog.Infof("PVC 扩容 %s -> %s, %s", current.String(), desired.String(), t.toString())
+	updated := pvc.DeepCopy()
+	updated.Spec.Resources.Requests[corev1.ResourceStorage] = desired
+	return t.clientK8s.CoreV1().PersistentVolumeClaims(t.namespace).Update(updated)
+}
+
+func (t *Traindeploy) setPersistentVolumeClaimOwner(existing *corev1.PersistentVolumeClaim) (*corev1.PersistentVolumeClaim, error) {
+	if t.controlled(existing) != t.keepPVC {
+		return existing, nil
+	}
+
+	updated := existing.DeepCopy()
+	if t.keepPVC {
+		// 此时 controller 一定是当前 Traincrd，只去掉它，保留其他 owner
+		updated.OwnerReferences = nil
+		for _, ref := range existing.OwnerReferences {
+			if ref.Controller == nil || !*ref.Controller {
+				updated.OwnerReferences = append(updated.OwnerReferences, ref)
+			}
+		}
+	} else {
+		owners, err := t.adopt(existing, "PersistentVolumeClaim")
+		if err != nil {
+			return existing, err
+		}
+		updated.OwnerReferences = owners
+	}
+	klog.Infof("PVC OwnerReference 与期望不一致，更新 %s keep-pvc: %v", t.toString(), t.keepPVC)
+	return t.clientK8s.CoreV1().PersistentVolumeClaims(t.namespace).Update(updated)
+}
+
+func (t *Traindeploy) reconcileDeployment() (*appsv1.Deployment, error) {
+	existing, err := t.createOrGetDeployment()
+	if err != nil {
+		return nil, err
+	}
+	owners, err := t.adopt(existing, "Deployment")
+	if err != nil {
+		return existing, err
+	}
+	desired, err := t.makeDeploymentSpec()
+	if err != nil {
+		return existing, err
+	}
+	if t.controlled(existing) && deploymentInSync(existing, desired) {
+		return existing, nil
+	}
+
+	klog.Infof("Deployment 与期望状态不一致，更新 %s", t.toString())
+	updated := existing.DeepCopy()
+	updated.Labels = desired.Labels
+	updated.OwnerReferences = owners
+	updated.Spec.Replicas = desired.Spec.Replicas
+	updated.Spec.Template = desired.Spec.Template
+	if existing.Spec.Strategy.Type != desired.Spec.Strategy.Type {
+		// Recreate 不能带 rollingUpdate 参数，整体替换，RollingUpdate 的参数由 apiserver 补默认值
+		updated.Spec.Strategy = desired.Spec.Strategy
+	}
+	return t.clientK8s.AppsV1().Deployments(t.namespace).Update(updated)
+}
+
+func (t *Traindeploy) reconcileSvc() (*corev1.Service, error) {
+	existing, err := t.createOrGetSvc()
+	if err != nil {
+		return nil, err
+	}
+	owners, err := t.adopt(existing, "Service")
+	if err != nil {
+		return existing, err
+	}
+	desired := t.makeServiceSpec()
+	if t.controlled(existing) &&
+		equality.Semantic.DeepEqual(existing.Labels, desired.Labels) &&
+		equality.Semantic.DeepEqual(existing.Spec.Ports, desired.Spec.Ports) &&
+		equality.Semantic.DeepEqual(existing.Spec.Selector, desired.Spec.Selector) &&
+		existing.Spec.Type == desired.Spec.Type &&
+		existing.Spec.ExternalName == desired.Spec.ExternalName {
+		return existing, nil
+	}
+
+	klog.Infof("Service 与期望状态不一致，更新 %s", t.toString())
+	updated := existing.DeepCopy()
+	updated.Labels = desired.Labels
+	updated.OwnerReferences = owners
+	updated.Spec.Ports = desired.Spec.Ports
+	updated.Spec.Selector = desired.Spec.Selector
+	if existing.Spec.Type != desired.Spec.Type &&
+		(existing.Spec.Type == corev1.ServiceTypeExternalName || desired.Spec.Type == corev1.ServiceTypeExternalName) {
+		// ExternalName 没有 clusterIP，与 ClusterIP 互相切换时清空，由 apiserver 重新分配
+		updated.Spec.ClusterIP = ""
+	}
+	updated.Spec.Type = desired.Spec.Type
+	updated.Spec.ExternalName = desired.Spec.ExternalName
+	return t.clientK8s.CoreV1().Services(t.namespace).Update(updated)
+}
+
+func (t *Traindeploy) reconcileIngress() (*v1beta1.Ingress, error) {
+	existing, err := t.createOrGetIngress()
+	if err != nil {
+		return nil, err
+	}
+	owners, err := t.adopt(existing, "Ingress")
+	if err != nil {
+		return existing, err
+	}
+	desired := t.makeIngressSpec()
+	if t.controlled(existing) && ingressInSync(existing, desired) {
+		return existing, nil
+	}
+
+	klog.Infof("Ingress 与期望状态不一致，更新 %s", t.toString())
+	updated := existing.DeepCopy()
+	updated.OwnerReferences = owners
+	updated.Spec = desired.Spec
+	return t.clientK8s.ExtensionsV1beta1().Ingresses(t.namespace).Update(updated)
+}
+
+// controlled 判断子资源的 controller 是否为当前 Traincrd，旧版本创建的子资源没有 OwnerReference，需要接管
+func (t *Traindeploy) controlled(obj metav1.Object) bool {
+	ref := metav1.GetControllerOf(obj)
+	return ref != nil && t.ownerRef != nil && ref.UID == t.ownerRef.UID
+}
+
+/**
+adopt 返回接管子资源后的 OwnerReferences：保留其他 owner，追加指向 Traincrd 的 controller OwnerReference。
+只接管没有 controller 的子资源，同名子资源已由其他 controller 管理时返回永久错误，不抢占
+*/
+func (t *Traindeploy) adopt(obj metav1.Object, kind string) ([]metav1.OwnerReference, error) {
+	if t.ownerRef == nil || t.controlled(obj) {
+		return obj.GetOwnerReferences(), nil
+	}
+	if ref := metav1.GetControllerOf(obj); ref != nil {
+		return nil, permanent("OwnedByAnotherController", fmt.Errorf("%s %s/%s is owned by another controller %s %s",
+			kind, obj.GetNamespace(), obj.GetName(), ref.Kind, ref.Name))
+	}
+
+	var owners []metav1.OwnerReference
+	for _, ref := range obj.GetOwnerReferences() {
+		// 同一个 Traincrd 的非 controller 引用由 controller 引用替换
+		if ref.UID != t.ownerRef.UID {
+			owners = append(owners, ref)
+		}
+	}
+	return append(owners, *t.ownerRef), nil
+}
+
+/**
+deploymentInSync 只比较 executor 负责的字段，apiserver 填充的默认值不算差异；
+模板渲染的 Pod 可能缺少任意的默认值，只比较 TEMPLATE_HASH_ANNOTATION，渲染结果变化时 hash 随之变化
+*/
+func deploymentInSync(existing, desired *appsv1.Deployment) bool {
+	if !equality.Semantic.DeepEqual(existing.Labels, desired.Labels) ||
+		!equality.Semantic.DeepEqual(existing.Spec.Replicas, desired.Spec.Replicas) ||
+		existing.Spec.Strategy.Type != desired.Spec.Strategy.Type {
+		return false
+	}
+
+	if !equality.Semantic.DeepEqual(existing.Spec.Template.Labels, desired.Spec.Template.Labels) ||
+		existing.Spec.Template.Annotations[TEMPLATE_HASH_ANNOTATION] != desired.Spec.Template.Annotations[TEMPLATE_HASH_ANNOTATION] {
+		return false
+	}
+	if _, templated := desired.Spec.Template.Annotations[TEMPLATE_HASH_ANNOTATION]; templated {
+		return true
+	}
+
+	es, ds := existing.Spec.Template.Spec, desired.Spec.Template.Spec
+	if !equality.Semantic.DeepEqual(es.Volumes, ds.Volumes) ||
+		!equality.Semantic.DeepEqual(es.TerminationGracePeriodSeconds, ds.TerminationGracePeriodSeconds) ||
+		es.ServiceAccountName != ds.ServiceAccountName ||
+		!equality.Semantic.DeepEqual(es.AutomountServiceAccountToken, ds.AutomountServiceAccountToken) ||
+		len(es.Containers) != len(ds.Containers) {
+		return false
+	}
+
+	for i := range ds.Containers {
+		if !containerInSync(&es.Containers[i], &ds.Containers[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func containerInSync(existing, desired *corev1.Container) bool {
+	return existing.Name == desired.Name &&
+		existing.Image == desired.Image &&
+		existing.ImagePullPolicy == desired.ImagePullPolicy &&
+		equality.Semantic.DeepEqual(existing.Resources, desired.Resources) &&
+		equality.Semantic.DeepEqual(existing.Env, desired.Env) &&
+		equality.Semantic.DeepEqual(existing.VolumeMounts, desired.VolumeMounts) &&
+		equality.Semantic.DeepEqual(existing.Ports, desired.Ports)
+}
+
+func ingressInSync(existing, desired *v1beta1.Ingress) bool {
+	return equality.Semantic.DeepEqual(existing.Spec.Rules, desired.Spec.Rules) &&
+		equality.Semantic.DeepEqual(existing.Spec.TLS, desired.Spec.TLS)
+}
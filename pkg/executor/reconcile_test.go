@@ -0,0 +1,341 @@
+package executor
+
+import (
+	"strings"
+	"testing"
+
+	v1 "finupgroup.com/decision/traincrd/pkg/apis/v1"
+	trainfake "finupgroup.com/decision/traincrd/pkg/client/clientset/versioned/fake"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+)
+
+// reconcileOnce 把 API 中最新的 Traincrd 放入缓存后执行一次 Reconcile
+func reconcileOnce(t *testing.T, exe *Executor, namespace, name string) *v1.Traincrd {
+	train, err := exe.clientTrain.DecisionV1().Traincrds(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := exe.informer.GetIndexer().Update(train); err != nil {
+		t.Fatal(err)
+	}
+	if err := exe.Reconcile(namespace, name); err != nil {
+		t.Fatal(err)
+	}
+	train, err = exe.clientTrain.DecisionV1().Traincrds(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return train
+}
+
+// applyDefaults 模拟 apiserver 在创建与更新时填充的默认值，fake clientset 不会填充
+func applyDefaults(client *k8sfake.Clientset) {
+	defaults := func(action k8stesting.Action) (bool, runtime.Object, error) {
+		var obj runtime.Object
+		switch action := action.(type) {
+		case k8stesting.CreateAction:
+			obj = action.GetObject()
+		case k8stesting.UpdateAction:
+			obj = action.GetObject()
+		}
+		if dep, ok := obj.(*appsv1.Deployment); ok {
+			for _, container := range dep.Spec.Template.Spec.Containers {
+				for i := range container.Ports {
+					if container.Ports[i].Protocol == "" {
+						container.Ports[i].Protocol = corev1.ProtocolTCP
+					}
+				}
+			}
+		}
+		if svc, ok := obj.(*corev1.Service); ok {
+			for i := range svc.Spec.Ports {
+				if port := &svc.Spec.Ports[i]; port.TargetPort == (intstr.IntOrString{}) {
+					port.TargetPort = intstr.FromInt(int(port.Port))
+				}
+			}
+		}
+		return false, nil, nil
+	}
+	for _, verb := range []string{"create", "update"} {
+		client.PrependReactor(verb, "*", defaults)
+	}
+}
+
+// expectNoUpdate 断言 reconcile 没有更新该类资源
+func expectNoUpdate(t *testing.T, client *k8sfake.Clientset, resource string) {
+	t.Helper()
+	for _, action := range client.Actions() {
+		if action.GetVerb() == "update" && action.GetResource().Resource == resource {
+			t.Errorf("unexpected %s update", resource)
+		}
+	}
+}
+
+func TestSuspendResume(t *testing.T) {
+	train := &v1.Traincrd{
+		ObjectMeta: metav1.ObjectMeta{Name: "notebook", Namespace: "wangxx", UID: "6c1e4f3a", Labels: map[string]string{"username": "wangxx", "channel": "risk"}},
+		Spec:       v1.TraincrdSpec{Image: "jupyter:1.0", Cpu: "1", Memory: "1Gi", ReqCpu: "500m", ReqMemory: "512Mi", Replicas: 2, Capacity: "1Gi", Suspended: true},
+	}
+	clientK8s := k8sfake.NewSimpleClientset()
+	applyDefaults(clientK8s)
+	exe := New(trainfake.NewSimpleClientset(train), clientK8s, nil, 0)
+	recorder := record.NewFakeRecorder(10)
+	exe.recorder = recorder
+
+	train = reconcileOnce(t, exe, "wangxx", "notebook")
+	// 暂停状态下再次 reconcile 不更新 Service
+	clientK8s.ClearActions()
+	train = reconcileOnce(t, exe, "wangxx", "notebook")
+	expectNoUpdate(t, clientK8s, "services")
+	dep, err := clientK8s.AppsV1().Deployments("wangxx").Get("notebook", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *dep.Spec.Replicas != 0 {
+		t.Errorf("expected a suspended workspace to have 0 replicas, got %d", *dep.Spec.Replicas)
+	}
+	svc, err := clientK8s.CoreV1().Services("wangxx").Get("notebook", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if svc.Spec.Type != corev1.ServiceTypeExternalName || svc.Spec.ExternalName != "decisiontrain-suspended.default.svc.cluster.local" {
+		t.Errorf("expected the Service to point at the suspended page, got %+v", svc.Spec)
+	}
+	if _, err := clientK8s.CoreV1().PersistentVolumeClaims("wangxx").Get("notebook", metav1.GetOptions{}); err != nil {
+		t.Errorf("expected the PVC to be kept: %v", err)
+	}
+	if train.Status.Phase != v1.TraincrdSuspended {
+		t.Errorf("expected phase Suspended, got %s", train.Status.Phase)
+	}
+	if event := <-recorder.Events; event != "Normal Suspended workspace suspended, scaled to 0 replicas, storage kept" {
+		t.Errorf("unexpected event %q", event)
+	}
+
+	train.Spec.Suspended = false
+	if _, err := exe.clientTrain.DecisionV1().Traincrds("wangxx").Update(train); err != nil {
+		t.Fatal(err)
+	}
+	train = reconcileOnce(t, exe, "wangxx", "notebook")
+	dep, _ = clientK8s.AppsV1().Deployments("wangxx").Get("notebook", metav1.GetOptions{})
+	if *dep.Spec.Replicas != 2 {
+		t.Errorf("expected the previous 2 replicas to be restored, got %d", *dep.Spec.Replicas)
+	}
+	svc, _ = clientK8s.CoreV1().Services("wangxx").Get("notebook", metav1.GetOptions{})
+	if svc.Spec.Type != corev1.ServiceTypeClusterIP || svc.Spec.ExternalName != "" || svc.Spec.Selector["app"] != "notebook" {
+		t.Errorf("expected the Service to select the workspace pods again, got %+v", svc.Spec)
+	}
+	if cond := getCondition(&train.Status, v1.TraincrdSuspendedCondition); cond == nil || cond.Status != corev1.ConditionFalse {
+		t.Errorf("expected Suspended=False, got %+v", cond)
+	}
+	if event := <-recorder.Events; event != "Normal Resumed workspace resumed with 2 replicas" {
+		t.Errorf("unexpected event %q", event)
+	}
+
+	// 状态不变时不重复记录事件
+	reconcileOnce(t, exe, "wangxx", "notebook")
+	select {
+	case event := <-recorder.Events:
+		t.Errorf("unexpected event %q", event)
+	default:
+	}
+}
+
+func TestExpandPersistentVolumeClaim(t *testing.T) {
+	expandable := true
+	train := &v1.Traincrd{
+		ObjectMeta: metav1.ObjectMeta{Name: "notebook", Namespace: "wangxx", UID: "6c1e4f3a", Labels: map[string]string{"username": "wangxx", "channel": "risk"}},
+		Spec:       v1.TraincrdSpec{Image: "jupyter:1.0", Cpu: "1", Memory: "1Gi", ReqCpu: "500m", ReqMemory: "512Mi", Replicas: 1, Capacity: "1Gi"},
+	}
+	clientK8s := k8sfake.NewSimpleClientset(&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "cephfs"}, AllowVolumeExpansion: &expandable})
+	exe := New(trainfake.NewSimpleClientset(train), clientK8s, nil, 0)
+	recorder := record.NewFakeRecorder(10)
+	exe.recorder = recorder
+	pvcs := clientK8s.CoreV1().PersistentVolumeClaims("wangxx")
+
+	// setCapacity 修改 spec.capacity 后 reconcile
+	setCapacity := func(capacity string) *v1.Traincrd {
+		train, err := exe.clientTrain.DecisionV1().Traincrds("wangxx").Get("notebook", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		train.Spec.Capacity = capacity
+		if _, err := exe.clientTrain.DecisionV1().Traincrds("wangxx").Update(train); err != nil {
+			t.Fatal(err)
+		}
+		return reconcileOnce(t, exe, "wangxx", "notebook")
+	}
+	// setPVCStatus 模拟 external-resizer 与 kubelet 更新 PVC 的 status
+	setPVCStatus := func(capacity string, conditions ...corev1.PersistentVolumeClaimCondition) {
+		pvc, err := pvcs.Get("notebook", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		pvc.Status = corev1.PersistentVolumeClaimStatus{
+			Phase:      corev1.ClaimBound,
+			Capacity:   corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(capacity)},
+			Conditions: conditions,
+		}
+		if _, err := pvcs.UpdateStatus(pvc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expectResized := func(train *v1.Traincrd, status corev1.ConditionStatus, reason string) {
+		t.Helper()
+		if cond := getCondition(&train.Status, v1.TraincrdStorageResized); cond == nil || cond.Status != status || cond.Reason != reason {
+			t.Errorf("expected StorageResized=%s with reason %s, got %+v", status, reason, cond)
+		}
+	}
+	requested := func() string {
+		pvc, err := pvcs.Get("notebook", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		q := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+		return q.String()
+	}
+
+	reconcileOnce(t, exe, "wangxx", "notebook")
+	setPVCStatus("1Gi")
+	if train = reconcileOnce(t, exe, "wangxx", "notebook"); getCondition(&train.Status, v1.TraincrdStorageResized) != nil || train.Status.Capacity != "1Gi" {
+		t.Errorf("expected no StorageResized condition and capacity 1Gi before a resize, got %+v", train.Status)
+	}
+
+	train = setCapacity("5Gi")
+	if requested() != "5Gi" {
+		t.Errorf("expected the PVC to request 5Gi, got %s", requested())
+	}
+	expectResized(train, corev1.ConditionFalse, "Resizing")
+
+	setPVCStatus("1Gi", corev1.PersistentVolumeClaimCondition{Type: corev1.PersistentVolumeClaimFileSystemResizePending, Status: corev1.ConditionTrue})
+	expectResized(reconcileOnce(t, exe, "wangxx", "notebook"), corev1.ConditionFalse, "FileSystemResizePending")
+
+	setPVCStatus("5Gi")
+	train = reconcileOnce(t, exe, "wangxx", "notebook")
+	expectResized(train, corev1.ConditionTrue, "Resized")
+	if train.Status.Capacity != "5Gi" {
+		t.Errorf("expected status.capacity 5Gi, got %s", train.Status.Capacity)
+	}
+	if event := <-recorder.Events; event != "Normal Resized workspace volume resized to 5Gi" {
+		t.Errorf("unexpected event %q", event)
+	}
+
+	train = setCapacity("2Gi")
+	expectResized(train, corev1.ConditionFalse, "ShrinkNotSupported")
+	if requested() != "5Gi" {
+		t.Errorf("expected the PVC to keep 5Gi, got %s", requested())
+	}
+	if event := <-recorder.Events; !strings.HasPrefix(event, "Warning ShrinkNotSupported spec.capacity 2Gi is smaller than the volume size 5Gi") {
+		t.Errorf("unexpected event %q", event)
+	}
+
+	expandable = false
+	if _, err := clientK8s.StorageV1().StorageClasses().Update(&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "cephfs"}, AllowVolumeExpansion: &expandable}); err != nil {
+		t.Fatal(err)
+	}
+	expectResized(setCapacity("10Gi"), corev1.ConditionFalse, "ExpansionNotSupported")
+	if requested() != "5Gi" {
+		t.Errorf("expected the PVC to keep 5Gi, got %s", requested())
+	}
+}
+
+func TestReconcileRepairsChildren(t *testing.T) {
+	train := &v1.Traincrd{
+		ObjectMeta: metav1.ObjectMeta{Name: "notebook", Namespace: "wangxx", UID: "6c1e4f3a", Labels: map[string]string{"username": "wangxx", "channel": "risk"}},
+		Spec:       v1.TraincrdSpec{Image: "jupyter:1.0", Cpu: "1", Memory: "1Gi", ReqCpu: "500m", ReqMemory: "512Mi", Replicas: 1, Capacity: "1Gi"},
+	}
+	clientK8s := k8sfake.NewSimpleClientset()
+	applyDefaults(clientK8s)
+	exe := New(trainfake.NewSimpleClientset(train), clientK8s, nil, 0)
+	reconcileOnce(t, exe, "wangxx", "notebook")
+
+	// 已收敛时再次 reconcile 不更新任何子资源
+	clientK8s.ClearActions()
+	reconcileOnce(t, exe, "wangxx", "notebook")
+	for _, resource := range []string{"deployments", "services", "ingresses"} {
+		expectNoUpdate(t, clientK8s, resource)
+	}
+
+	// 被手工修改的 Deployment 与被删除的 Service、Ingress 在下一次 reconcile 中恢复
+	dep, err := clientK8s.AppsV1().Deployments("wangxx").Get("notebook", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	replicas := int32(3)
+	dep.Spec.Replicas = &replicas
+	dep.Spec.Template.Spec.Containers[0].Image = "jupyter:latest"
+	if _, err := clientK8s.AppsV1().Deployments("wangxx").Update(dep); err != nil {
+		t.Fatal(err)
+	}
+	if err := clientK8s.CoreV1().Services("wangxx").Delete("notebook", &metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := clientK8s.ExtensionsV1beta1().Ingresses("wangxx").Delete("notebook", &metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	reconcileOnce(t, exe, "wangxx", "notebook")
+	dep, _ = clientK8s.AppsV1().Deployments("wangxx").Get("notebook", metav1.GetOptions{})
+	if *dep.Spec.Replicas != 1 || dep.Spec.Template.Spec.Containers[0].Image != "jupyter:1.0" {
+		t.Errorf("expected the Deployment to be reverted to 1 replica of jupyter:1.0, got %d of %s",
+			*dep.Spec.Replicas, dep.Spec.Template.Spec.Containers[0].Image)
+	}
+	if _, err := clientK8s.CoreV1().Services("wangxx").Get("notebook", metav1.GetOptions{}); err != nil {
+		t.Errorf("expected the Service to be recreated: %v", err)
+	}
+	if _, err := clientK8s.ExtensionsV1beta1().Ingresses("wangxx").Get("notebook", metav1.GetOptions{}); err != nil {
+		t.Errorf("expected the Ingress to be recreated: %v", err)
+	}
+}
+
+func TestAdoptChildren(t *testing.T) {
+	train := &v1.Traincrd{
+		ObjectMeta: metav1.ObjectMeta{Name: "notebook", Namespace: "wangxx", UID: "6c1e4f3a", Labels: map[string]string{"username": "wangxx", "channel": "risk"}},
+		Spec:       v1.TraincrdSpec{Image: "jupyter:1.0", Cpu: "1", Memory: "1Gi", ReqCpu: "500m", ReqMemory: "512Mi", Replicas: 1, Capacity: "1Gi"},
+	}
+	controller := true
+	foreign := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "notebook", Namespace: "wangxx",
+		OwnerReferences: []metav1.OwnerReference{{APIVersion: "argoproj.io/v1alpha1", Kind: "Rollout", Name: "notebook", UID: "9b2d7e01", Controller: &controller}}}}
+	owner := metav1.OwnerReference{APIVersion: "v1", Kind: "ConfigMap", Name: "notebook-settings", UID: "41f0c8d2"}
+	svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "notebook", Namespace: "wangxx", OwnerReferences: []metav1.OwnerReference{owner}}}
+	clientK8s := k8sfake.NewSimpleClientset(foreign, svc)
+	applyDefaults(clientK8s)
+	exe := New(trainfake.NewSimpleClientset(train), clientK8s, nil, 0)
+	if err := exe.informer.GetIndexer().Add(train); err != nil {
+		t.Fatal(err)
+	}
+
+	// 由其他 controller 管理的 Deployment 不接管
+	err := exe.Reconcile("wangxx", "notebook")
+	if reason, ok := permanentReason(err); !ok || reason != "OwnedByAnotherController" {
+		t.Fatalf("expected OwnedByAnotherController, got %v", err)
+	}
+	dep, _ := clientK8s.AppsV1().Deployments("wangxx").Get("notebook", metav1.GetOptions{})
+	if ref := metav1.GetControllerOf(dep); ref == nil || ref.UID != "9b2d7e01" {
+		t.Errorf("expected the Deployment to keep its controller, got %v", dep.OwnerReferences)
+	}
+
+	// 没有 controller 的 Service 被接管，原有 owner 保留
+	if err := clientK8s.AppsV1().Deployments("wangxx").Delete("notebook", &metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	reconcileOnce(t, exe, "wangxx", "notebook")
+	svc, err = clientK8s.CoreV1().Services("wangxx").Get("notebook", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(svc.OwnerReferences) != 2 || svc.OwnerReferences[0] != owner {
+		t.Errorf("expected the existing owner to be kept, got %v", svc.OwnerReferences)
+	}
+	if ref := metav1.GetControllerOf(svc); ref == nil || ref.UID != train.UID {
+		t.Errorf("expected the Service to be adopted, got %v", svc.OwnerReferences)
+	}
+}
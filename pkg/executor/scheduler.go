@@ -0,0 +1,162 @@
+package executor
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	v1 "finupgroup.com/decision/traincrd/pkg/apis/v1"
+	"finupgroup.com/decision/traincrd/pkg/cron"
+	"k8s.io/apimachinery/pkg/util/clock"
+)
+
+// scheduler 在指定时间把 key 重新放入队列，用于 spec.ttl、spec.expiresAt 与 spec.schedule；
+// 时间来自 clock，测试中用 fake clock 推进
+type scheduler struct {
+	clock   clock.Clock
+	enqueue func(key string)
+
+	mu     sync.Mutex
+	timers map[string]time.Time
+	// changed 在新增或提前定时后唤醒 run
+	changed chan struct{}
+}
+
+func newScheduler(c clock.Clock, enqueue func(key string)) *scheduler {
+	return &scheduler{clock: c, enqueue: enqueue, timers: map[string]time.Time{}, changed: make(chan struct{}, 1)}
+}
+
+// schedule 在 at 时重新入队 key，替换之前的定时；at 为零值时取消
+func (s *scheduler) schedule(key string, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if at.IsZero() {
+		delete(s.timers, key)
+		return
+	}
+	s.timers[key] = at
+	select {
+	case s.changed <- struct{}{}:
+	default:
+	}
+}
+
+// run 等待最早的定时到期后入队，直到 stopCh 关闭
+func (s *scheduler) run(stopCh <-chan struct{}) {
+	for {
+		var timer <-chan time.Time
+		if next := s.fire(); !next.IsZero() {
+			timer = s.clock.After(next.Sub(s.clock.Now()))
+		}
+		select {
+		case <-stopCh:
+			return
+		case <-s.changed:
+		case <-timer:
+		}
+	}
+}
+
+// fire 入队所有到期的 key，返回下一个定时，没有时为零值
+func (s *scheduler) fire() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock.Now()
+	var next time.Time
+	for key, at := range s.timers {
+		if !at.After(now) {
+			delete(s.timers, key)
+			s.enqueue(key)
+			continue
+		}
+		if next.IsZero() || at.Before(next) {
+			next = at
+		}
+	}
+	return next
+}
+
+// lifecycle 是 spec.ttl、spec.expiresAt 与 spec.schedule 在某一时刻的结果
+type lifecycle struct {
+	// expired 为 true 时按 spec.expireAction 暂停或删除
+	expired   bool
+	expiresAt time.Time
+	// offSchedule 为 true 时处于 stop 之后、下一次 start 之前，resumeAt 为下一次 start
+	offSchedule bool
+	resumeAt    time.Time
+	// next 是结果下一次变化的时间，需要在那时重新 reconcile
+	next time.Time
+}
+
+func (exe *Executor) lifecycle(train *v1.Traincrd) (lifecycle, error) {
+	loc, err := exe.config().Scheduling.Location()
+	if err != nil {
+		return lifecycle{}, err
+	}
+	return computeLifecycle(train, exe.clock.Now(), loc)
+}
+
+/**
+计算 Traincrd 在 now 时的生命周期：ttl 从创建时开始计算，与 expiresAt 取较早者；
+schedule 的下一次触发是 stop 时说明正处于 start 与 stop 之间，否则工作区应当暂停
+*/
+func computeLifecycle(train *v1.Traincrd, now time.Time, loc *time.Location) (lifecycle, error) {
+	var lc lifecycle
+	if train.Spec.TTL != nil {
+		lc.expiresAt = train.CreationTimestamp.Add(train.Spec.TTL.Duration)
+	}
+	if at := train.Spec.ExpiresAt; at != nil && (lc.expiresAt.IsZero() || at.Time.Before(lc.expiresAt)) {
+		lc.expiresAt = at.Time
+	}
+	if !lc.expiresAt.IsZero() {
+		lc.expired = !now.Before(lc.expiresAt)
+		if !lc.expired {
+			lc.next = lc.expiresAt
+		}
+	}
+
+	if train.Spec.Schedule == nil || lc.expired {
+		return lc, nil
+	}
+	start, err := cron.Parse(train.Spec.Schedule.Start)
+	if err != nil {
+		return lc, permanent("InvalidSchedule", fmt.Errorf("spec.schedule.start: %v", err))
+	}
+	stop, err := cron.Parse(train.Spec.Schedule.Stop)
+	if err != nil {
+		return lc, permanent("InvalidSchedule", fmt.Errorf("spec.schedule.stop: %v", err))
+	}
+
+	local := now.In(loc)
+	nextStart, nextStop := start.Next(local), stop.Next(local)
+	// 永不触发的 stop 表示一直运行，永不触发的 start 表示一直暂停
+	lc.offSchedule = !nextStop.IsZero() && (nextStart.IsZero() || nextStart.Before(nextStop))
+	if lc.offSchedule {
+		lc.resumeAt = nextStart
+	}
+	for _, at := range []time.Time{nextStart, nextStop} {
+		if !at.IsZero() && (lc.next.IsZero() || at.Before(lc.next)) {
+			lc.next = at
+		}
+	}
+	return lc, nil
+}
+
+// applyLifecycle 过期或处于 schedule 之外时暂停工作区，spec.suspended 优先
+func (t *Traindeploy) applyLifecycle(lc lifecycle) {
+	t.lifecycle = lc
+	if t.suspended {
+		return
+	}
+	switch {
+	case lc.expired:
+		t.suspended, t.suspendReason = true, "Expired"
+		t.suspendMessage = fmt.Sprintf("expired at %s", lc.expiresAt.Format(time.RFC3339))
+	case lc.offSchedule:
+		t.suspended, t.suspendReason = true, "OffSchedule"
+		t.suspendMessage = "outside spec.schedule"
+		if !lc.resumeAt.IsZero() {
+			t.suspendMessage += ", starts again at " + lc.resumeAt.Format(time.RFC3339)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package executor
+
+import (
+	"testing"
+	"time"
+
+	v1 "finupgroup.com/decision/traincrd/pkg/apis/v1"
+	trainfake "finupgroup.com/decision/traincrd/pkg/client/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/util/wait"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+var officeHours = &v1.TraincrdSchedule{Start: "0 9 * * 1-5", Stop: "0 19 * * 1-5"}
+
+func shanghai(t *testing.T) *time.Location {
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func TestComputeLifecycle(t *testing.T) {
+	loc := shanghai(t)
+	created := metav1.Date(2019, 12, 2, 8, 0, 0, 0, loc)
+	ttl := &metav1.Duration{Duration: 7 * 24 * time.Hour}
+	expiresAt := metav1.Date(2019, 12, 5, 12, 0, 0, 0, loc)
+
+	tests := []struct {
+		name        string
+		spec        v1.TraincrdSpec
+		now         time.Time
+		expired     bool
+		offSchedule bool
+		next        time.Time
+	}{
+		{"monday before start", v1.TraincrdSpec{Schedule: officeHours}, time.Date(2019, 12, 2, 8, 30, 0, 0, loc), false, true, time.Date(2019, 12, 2, 9, 0, 0, 0, loc)},
+		{"monday office hours", v1.TraincrdSpec{Schedule: officeHours}, time.Date(2019, 12, 2, 9, 0, 0, 0, loc), false, false, time.Date(2019, 12, 2, 19, 0, 0, 0, loc)},
+		{"friday evening", v1.TraincrdSpec{Schedule: officeHours}, time.Date(2019, 12, 6, 20, 0, 0, 0, loc), false, true, time.Date(2019, 12, 9, 9, 0, 0, 0, loc)},
+		// 按 Asia/Shanghai 计算，UTC 01:00 即北京时间 09:00
+		{"utc clock", v1.TraincrdSpec{Schedule: officeHours}, time.Date(2019, 12, 3, 1, 30, 0, 0, time.UTC), false, false, time.Date(2019, 12, 3, 19, 0, 0, 0, loc)},
+		{"ttl pending", v1.TraincrdSpec{TTL: ttl}, time.Date(2019, 12, 3, 0, 0, 0, 0, loc), false, false, time.Date(2019, 12, 9, 8, 0, 0, 0, loc)},
+		{"ttl passed", v1.TraincrdSpec{TTL: ttl, Schedule: officeHours}, time.Date(2019, 12, 9, 10, 0, 0, 0, loc), true, false, time.Time{}},
+		{"expiresAt before ttl", v1.TraincrdSpec{TTL: ttl, ExpiresAt: &expiresAt}, time.Date(2019, 12, 3, 0, 0, 0, 0, loc), false, false, expiresAt.Time},
+		{"expiresAt passed", v1.TraincrdSpec{ExpiresAt: &expiresAt}, expiresAt.Time, true, false, time.Time{}},
+	}
+	for _, test := range tests {
+		train := &v1.Traincrd{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: created}, Spec: test.spec}
+		lc, err := computeLifecycle(train, test.now, loc)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if lc.expired != test.expired || lc.offSchedule != test.offSchedule || !lc.next.Equal(test.next) {
+			t.Errorf("%s: expected expired=%v offSchedule=%v next=%s, got %+v", test.name, test.expired, test.offSchedule, test.next, lc)
+		}
+	}
+
+	train := &v1.Traincrd{Spec: v1.TraincrdSpec{Schedule: &v1.TraincrdSchedule{Start: "0 9 * * 1-5", Stop: "at seven"}}}
+	if _, err := computeLifecycle(train, created.Time, loc); err == nil {
+		t.Errorf("expected an invalid schedule to be rejected")
+	} else if reason, _ := permanentReason(err); reason != "InvalidSchedule" {
+		t.Errorf("expected InvalidSchedule, got %v", err)
+	}
+}
+
+func TestSchedulerRun(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Date(2019, 12, 2, 18, 0, 0, 0, time.UTC))
+	fired := make(chan string, 2)
+	s := newScheduler(fakeClock, func(key string) { fired <- key })
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go s.run(stopCh)
+
+	s.schedule("wangxx/a", fakeClock.Now().Add(time.Hour))
+	s.schedule("wangxx/b", fakeClock.Now().Add(2*time.Hour))
+	s.schedule("wangxx/b", time.Time{})
+	waitForTimer := func() {
+		if err := wait.PollImmediate(time.Millisecond, time.Second, func() (bool, error) { return fakeClock.HasWaiters(), nil }); err != nil {
+			t.Fatal("scheduler is not waiting on the clock")
+		}
+	}
+
+	waitForTimer()
+	fakeClock.Step(59 * time.Minute)
+	select {
+	case key := <-fired:
+		t.Fatalf("%s fired too early", key)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	waitForTimer()
+	fakeClock.Step(2 * time.Hour)
+	select {
+	case key := <-fired:
+		if key != "wangxx/a" {
+			t.Errorf("expected wangxx/a, got %s", key)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("wangxx/a did not fire")
+	}
+	select {
+	case key := <-fired:
+		t.Errorf("cancelled %s fired", key)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestScheduleAndExpire(t *testing.T) {
+	loc := shanghai(t)
+	train := &v1.Traincrd{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "course", Namespace: "wangxx", UID: "3f2a9c1e", Labels: map[string]string{"username": "wangxx", "channel": "course"},
+			CreationTimestamp: metav1.Date(2019, 12, 2, 8, 0, 0, 0, loc),
+		},
+		Spec: v1.TraincrdSpec{
+			Image: "jupyter:1.0", Cpu: "1", Memory: "1Gi", ReqCpu: "500m", ReqMemory: "512Mi", Replicas: 1, Capacity: "1Gi",
+			Schedule: officeHours, TTL: &metav1.Duration{Duration: 14 * 24 * time.Hour}, ExpireAction: v1.ExpireActionDelete,
+		},
+	}
+	clientK8s := k8sfake.NewSimpleClientset()
+	exe := New(trainfake.NewSimpleClientset(train), clientK8s, nil, 0)
+	fakeClock := clock.NewFakeClock(time.Date(2019, 12, 2, 20, 0, 0, 0, loc))
+	exe.clock, exe.scheduler.clock = fakeClock, fakeClock
+
+	replicas := func() int32 {
+		dep, err := clientK8s.AppsV1().Deployments("wangxx").Get("course", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return *dep.Spec.Replicas
+	}
+
+	train = reconcileOnce(t, exe, "wangxx", "course")
+	if replicas() != 0 || train.Status.Phase != v1.TraincrdSuspended {
+		t.Errorf("expected the workspace to be suspended after office hours, got %d replicas, phase %s", replicas(), train.Status.Phase)
+	}
+	if cond := getCondition(&train.Status, v1.TraincrdSuspendedCondition); cond == nil || cond.Reason != "OffSchedule" {
+		t.Errorf("expected Suspended with reason OffSchedule, got %+v", cond)
+	}
+	if at := exe.scheduler.timers["wangxx/course"]; !at.Equal(time.Date(2019, 12, 3, 9, 0, 0, 0, loc)) {
+		t.Errorf("expected a reconcile at the next start, got %s", at)
+	}
+
+	fakeClock.SetTime(time.Date(2019, 12, 3, 9, 0, 0, 0, loc))
+	train = reconcileOnce(t, exe, "wangxx", "course")
+	if replicas() != 1 {
+		t.Errorf("expected the workspace to start at 09:00, got %d replicas", replicas())
+	}
+	if cond := getCondition(&train.Status, v1.TraincrdSuspendedCondition); cond == nil || cond.Status != corev1.ConditionFalse {
+		t.Errorf("expected Suspended=False, got %+v", cond)
+	}
+
+	fakeClock.SetTime(time.Date(2019, 12, 16, 10, 0, 0, 0, loc))
+	if err := exe.Reconcile("wangxx", "course"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := exe.clientTrain.DecisionV1().Traincrds("wangxx").Get("course", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("expected the expired Traincrd to be deleted, got %v", err)
+	}
+}
@@ -0,0 +1,239 @@
+package executor
+
+import (
+	"fmt"
+	"time"
+
+	v1 "finupgroup.com/decision/traincrd/pkg/apis/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	k8v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+)
+
+// RESTORE_INDEX 按 spec.restoreFrom 索引 Traincrd，快照可用时据此找到等待它的 Traincrd
+const RESTORE_INDEX = "restoreFrom"
+
+// snapshotPollInterval VolumeSnapshot 未就绪时重新检查的间隔，external-snapshotter 的更新没有事件通知到这里
+const snapshotPollInterval = 10 * time.Second
+
+// volumeSnapshotResource 为 external-snapshotter 的 VolumeSnapshot，与 k8s 1.17 的 v1beta1 API 对应
+var volumeSnapshotResource = schema.GroupVersionResource{Group: "snapshot.storage.k8s.io", Version: "v1beta1", Resource: "volumesnapshots"}
+
+func restoreIndexFunc(obj interface{}) ([]string, error) {
+	train, ok := obj.(*v1.Traincrd)
+	if !ok || train.Spec.RestoreFrom == "" {
+		return nil, nil
+	}
+	return []string{train.Namespace + "/" + train.Spec.RestoreFrom}, nil
+}
+
+// newSnapshotInformer 监听 TraincrdSnapshot，变化时放入 snapshotQueue，并重新入队等待它恢复的 Traincrd
+func (exe *Executor) newSnapshotInformer() cache.SharedIndexInformer {
+	informer := cache.NewSharedIndexInformer(&cache.ListWatch{
+		ListFunc: func(options k8v1.ListOptions) (runtime.Object, error) {
+			return exe.clientTrain.DecisionV1().TraincrdSnapshots(k8v1.NamespaceAll).List(options)
+		},
+		WatchFunc: func(options k8v1.ListOptions) (watch.Interface, error) {
+			return exe.clientTrain.DecisionV1().TraincrdSnapshots(k8v1.NamespaceAll).Watch(options)
+		},
+	},
+		&v1.TraincrdSnapshot{},
+		0,
+		cache.Indexers{},
+	)
+
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: exe.enqueueSnapshot,
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			exe.enqueueSnapshot(newObj)
+			if !oldObj.(*v1.TraincrdSnapshot).Status.ReadyToUse && newObj.(*v1.TraincrdSnapshot).Status.ReadyToUse {
+				exe.enqueueRestoreDependents(newObj)
+			}
+		},
+	})
+	return informer
+}
+
+func (exe *Executor) enqueueSnapshot(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	exe.snapshotQueue.Add(key)
+}
+
+func (exe *Executor) enqueueRestoreDependents(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		klog.Errorf("snapshot key: %v", err)
+		return
+	}
+	dependents, err := exe.informer.GetIndexer().ByIndex(RESTORE_INDEX, key)
+	if err != nil {
+		klog.Errorf("查找从快照 %s 恢复的 train 失败: %v", key, err)
+		return
+	}
+	klog.V(2).Infof("快照 %s 可用，重新入队 %d 个 train", key, len(dependents))
+	for _, train := range dependents {
+		exe.enqueue(train)
+	}
+}
+
+func (exe *Executor) syncSnapshot(key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return nil
+	}
+	return exe.ReconcileSnapshot(namespace, name)
+}
+
+/**
+ReconcileSnapshot 为 TraincrdSnapshot 创建同名的 VolumeSnapshot，并把就绪状态与大小同步到 status；
+VolumeSnapshot 带有指向 TraincrdSnapshot 的 OwnerReference，删除时由垃圾回收级联清理
+*/
+func (exe *Executor) ReconcileSnapshot(namespace, name string) error {
+	obj, exists, err := exe.snapshotInformer.GetIndexer().GetByKey(namespace + "/" + name)
+	if err != nil || !exists {
+		return err
+	}
+	snap := obj.(*v1.TraincrdSnapshot)
+
+	vs, err := exe.createOrGetVolumeSnapshot(snap)
+	if err != nil {
+		reason, ok := permanentReason(err)
+		if !ok {
+			return err
+		}
+		exe.recorder.Event(snap, corev1.EventTypeWarning, reason, err.Error())
+		status := snap.Status.DeepCopy()
+		status.Error = err.Error()
+		return exe.updateSnapshotStatus(snap, *status)
+	}
+
+	status := volumeSnapshotStatus(vs)
+	if err := exe.updateSnapshotStatus(snap, status); err != nil {
+		return err
+	}
+	if status.ReadyToUse && !snap.Status.ReadyToUse {
+		exe.recorder.Eventf(snap, corev1.EventTypeNormal, "SnapshotReady", "snapshot of workspace %s is ready, restore size %s", snap.Spec.Traincrd, status.RestoreSize)
+	}
+	if !status.ReadyToUse {
+		exe.snapshotQueue.AddAfter(namespace+"/"+name, snapshotPollInterval)
+	}
+	return nil
+}
+
+// createOrGetVolumeSnapshot 快照只在创建时读取工作区的 PVC，之后删除 Traincrd 不影响快照
+func (exe *Executor) createOrGetVolumeSnapshot(snap *v1.TraincrdSnapshot) (*unstructured.Unstructured, error) {
+	client := exe.clientDynamic.Resource(volumeSnapshotResource).Namespace(snap.Namespace)
+	existing, err := client.Get(snap.Name, k8v1.GetOptions{})
+	if err == nil {
+		if ref := k8v1.GetControllerOf(existing); ref == nil || ref.UID != snap.UID {
+			return nil, permanent("VolumeSnapshotExists", fmt.Errorf("VolumeSnapshot %s already exists and is not owned by this TraincrdSnapshot", snap.Name))
+		}
+		return existing, nil
+	}
+	if !errors.IsNotFound(err) {
+		return nil, err
+	}
+
+	if _, exists, err := exe.informer.GetIndexer().GetByKey(snap.Namespace + "/" + snap.Spec.Traincrd); err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, permanent("TraincrdNotFound", fmt.Errorf("spec.traincrd %q: Traincrd not found", snap.Spec.Traincrd))
+	}
+	if _, err := exe.clientK8s.CoreV1().PersistentVolumeClaims(snap.Namespace).Get(snap.Spec.Traincrd, k8v1.GetOptions{}); err != nil {
+		return nil, err
+	}
+
+	vs := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"source": map[string]interface{}{"persistentVolumeClaimName": snap.Spec.Traincrd},
+		},
+	}}
+	vs.SetAPIVersion(volumeSnapshotResource.GroupVersion().String())
+	vs.SetKind("VolumeSnapshot")
+	vs.SetName(snap.Name)
+	vs.SetNamespace(snap.Namespace)
+	vs.SetLabels(map[string]string{"traincrd": snap.Spec.Traincrd})
+	vs.SetOwnerReferences([]k8v1.OwnerReference{*k8v1.NewControllerRef(snap, v1.SchemeGroupVersion.WithKind("TraincrdSnapshot"))})
+	className := snap.Spec.VolumeSnapshotClassName
+	if className == "" {
+		className = exe.config().Storage.VolumeSnapshotClassName
+	}
+	if className != "" {
+		if err := unstructured.SetNestedField(vs.Object, className, "spec", "volumeSnapshotClassName"); err != nil {
+			return nil, err
+		}
+	}
+
+	klog.Infof("创建 VolumeSnapshot %s/%s, pvc: %s", snap.Namespace, snap.Name, snap.Spec.Traincrd)
+	return client.Create(vs, k8v1.CreateOptions{})
+}
+
+// volumeSnapshotStatus 读取 VolumeSnapshot 的 status，字段未填写时保持零值
+func volumeSnapshotStatus(vs *unstructured.Unstructured) v1.TraincrdSnapshotStatus {
+	status := v1.TraincrdSnapshotStatus{VolumeSnapshotName: vs.GetName()}
+	status.ReadyToUse, _, _ = unstructured.NestedBool(vs.Object, "status", "readyToUse")
+	status.RestoreSize, _, _ = unstructured.NestedString(vs.Object, "status", "restoreSize")
+	if created, _, _ := unstructured.NestedString(vs.Object, "status", "creationTime"); created != "" {
+		if t, err := time.Parse(time.RFC3339, created); err == nil {
+			creationTime := k8v1.NewTime(t)
+			status.CreationTime = &creationTime
+		}
+	}
+	status.Error, _, _ = unstructured.NestedString(vs.Object, "status", "error", "message")
+	return status
+}
+
+func (exe *Executor) updateSnapshotStatus(snap *v1.TraincrdSnapshot, status v1.TraincrdSnapshotStatus) error {
+	if equality.Semantic.DeepEqual(snap.Status, status) {
+		return nil
+	}
+	updated := snap.DeepCopy()
+	updated.Status = status
+	_, err := exe.clientTrain.DecisionV1().TraincrdSnapshots(snap.Namespace).UpdateStatus(updated)
+	return err
+}
+
+/**
+restoreSource 解析 spec.restoreFrom，新建的 PVC 以快照为 dataSource，容量不小于快照的 restoreSize；
+快照不存在时为永久错误，尚未就绪时稍后重试，快照就绪后也会由 snapshotInformer 重新入队
+*/
+func (t *Traindeploy) restoreSource() (*volumeSource, error) {
+	snap, err := t.clientTrain.DecisionV1().TraincrdSnapshots(t.namespace).Get(t.restoreFrom, k8v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, permanent("SnapshotNotFound", fmt.Errorf("spec.restoreFrom %q: TraincrdSnapshot not found", t.restoreFrom))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !snap.Status.ReadyToUse {
+		return nil, fmt.Errorf("spec.restoreFrom %q: snapshot is not ready to use yet", t.restoreFrom)
+	}
+
+	apiGroup := volumeSnapshotResource.Group
+	source := &volumeSource{
+		dataSource: &corev1.TypedLocalObjectReference{APIGroup: &apiGroup, Kind: "VolumeSnapshot", Name: snap.Status.VolumeSnapshotName},
+		lineage:    &v1.TraincrdLineage{Kind: "TraincrdSnapshot", Namespace: snap.Namespace, Name: snap.Name, UID: string(snap.UID), Method: LINEAGE_METHOD_SNAPSHOT},
+	}
+	if snap.Status.RestoreSize != "" {
+		size, err := resource.ParseQuantity(snap.Status.RestoreSize)
+		if err != nil {
+			return nil, err
+		}
+		source.size = &size
+	}
+	return source, nil
+}
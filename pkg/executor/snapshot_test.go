@@ -0,0 +1,126 @@
+package executor
+
+import (
+	"testing"
+
+	v1 "finupgroup.com/decision/traincrd/pkg/apis/v1"
+	trainfake "finupgroup.com/decision/traincrd/pkg/client/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+// reconcileSnapshotOnce 把 API 中最新的 TraincrdSnapshot 放入缓存后执行一次 ReconcileSnapshot
+func reconcileSnapshotOnce(t *testing.T, exe *Executor, namespace, name string) *v1.TraincrdSnapshot {
+	snap, err := exe.clientTrain.DecisionV1().TraincrdSnapshots(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := exe.snapshotInformer.GetIndexer().Update(snap); err != nil {
+		t.Fatal(err)
+	}
+	if err := exe.ReconcileSnapshot(namespace, name); err != nil {
+		t.Fatal(err)
+	}
+	snap, err = exe.clientTrain.DecisionV1().TraincrdSnapshots(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return snap
+}
+
+func TestSnapshotAndRestore(t *testing.T) {
+	train := &v1.Traincrd{
+		ObjectMeta: metav1.ObjectMeta{Name: "notebook", Namespace: "wangxx", UID: "6c1e4f3a", Labels: map[string]string{"username": "wangxx", "channel": "risk"}},
+		Spec:       v1.TraincrdSpec{Image: "jupyter:1.0", Cpu: "1", Memory: "1Gi", ReqCpu: "500m", ReqMemory: "512Mi", Replicas: 1, Capacity: "5Gi"},
+	}
+	restored := train.DeepCopy()
+	restored.Name, restored.UID = "notebook-copy", "8d2b5e7f"
+	restored.Spec.Capacity, restored.Spec.RestoreFrom = "1Gi", "notebook-20191202"
+	snap := &v1.TraincrdSnapshot{
+		ObjectMeta: metav1.ObjectMeta{Name: "notebook-20191202", Namespace: "wangxx", UID: "1b9d7c2e"},
+		Spec:       v1.TraincrdSnapshotSpec{Traincrd: "notebook", VolumeSnapshotClassName: "csi-cephfsplugin-snapclass"},
+	}
+	orphan := &v1.TraincrdSnapshot{
+		ObjectMeta: metav1.ObjectMeta{Name: "gone-20191202", Namespace: "wangxx", UID: "4e6a0b8d"},
+		Spec:       v1.TraincrdSnapshotSpec{Traincrd: "gone"},
+	}
+	clientK8s := k8sfake.NewSimpleClientset()
+	clientDynamic := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	exe := New(trainfake.NewSimpleClientset(train, restored, snap, orphan), clientK8s, clientDynamic, 0)
+	recorder := record.NewFakeRecorder(10)
+	exe.recorder = recorder
+	volumeSnapshots := clientDynamic.Resource(volumeSnapshotResource).Namespace("wangxx")
+
+	reconcileOnce(t, exe, "wangxx", "notebook")
+	snap = reconcileSnapshotOnce(t, exe, "wangxx", "notebook-20191202")
+	vs, err := volumeSnapshots.Get("notebook-20191202", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if source, _, _ := unstructured.NestedString(vs.Object, "spec", "source", "persistentVolumeClaimName"); source != "notebook" {
+		t.Errorf("expected the VolumeSnapshot to copy PVC notebook, got %q", source)
+	}
+	if class, _, _ := unstructured.NestedString(vs.Object, "spec", "volumeSnapshotClassName"); class != "csi-cephfsplugin-snapclass" {
+		t.Errorf("expected volumeSnapshotClassName csi-cephfsplugin-snapclass, got %q", class)
+	}
+	if ref := metav1.GetControllerOf(vs); ref == nil || ref.UID != snap.UID {
+		t.Errorf("expected the VolumeSnapshot to be owned by the TraincrdSnapshot, got %v", vs.GetOwnerReferences())
+	}
+	if snap.Status.VolumeSnapshotName != "notebook-20191202" || snap.Status.ReadyToUse {
+		t.Errorf("expected a pending snapshot, got %+v", snap.Status)
+	}
+
+	// 从未就绪的快照恢复时不创建 PVC，稍后重试
+	if err := exe.informer.GetIndexer().Update(restored); err != nil {
+		t.Fatal(err)
+	}
+	if err := exe.Reconcile("wangxx", "notebook-copy"); err == nil {
+		t.Errorf("expected the restore to wait for the snapshot")
+	} else if _, ok := permanentReason(err); ok {
+		t.Errorf("expected a transient error, got %v", err)
+	}
+
+	// 模拟 external-snapshotter 更新 VolumeSnapshot 的 status
+	if err := unstructured.SetNestedMap(vs.Object, map[string]interface{}{
+		"readyToUse": true, "restoreSize": "5Gi", "creationTime": "2019-12-02T18:30:00Z",
+	}, "status"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := volumeSnapshots.Update(vs, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	snap = reconcileSnapshotOnce(t, exe, "wangxx", "notebook-20191202")
+	if !snap.Status.ReadyToUse || snap.Status.RestoreSize != "5Gi" || snap.Status.CreationTime == nil {
+		t.Errorf("expected a ready snapshot of 5Gi, got %+v", snap.Status)
+	}
+	if event := <-recorder.Events; event != "Normal SnapshotReady snapshot of workspace notebook is ready, restore size 5Gi" {
+		t.Errorf("unexpected event %q", event)
+	}
+
+	restored = reconcileOnce(t, exe, "wangxx", "notebook-copy")
+	pvc, err := clientK8s.CoreV1().PersistentVolumeClaims("wangxx").Get("notebook-copy", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ds := pvc.Spec.DataSource; ds == nil || ds.Kind != "VolumeSnapshot" || ds.Name != "notebook-20191202" || ds.APIGroup == nil || *ds.APIGroup != "snapshot.storage.k8s.io" {
+		t.Errorf("expected the PVC to be provisioned from the VolumeSnapshot, got %+v", ds)
+	}
+	// spec.capacity 小于快照时按快照大小创建，不算缩容
+	if size := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; size.Cmp(resource.MustParse("5Gi")) != 0 {
+		t.Errorf("expected the PVC to request the 5Gi restore size, got %s", size.String())
+	}
+	if cond := getCondition(&restored.Status, v1.TraincrdStorageResized); cond != nil {
+		t.Errorf("expected no StorageResized condition, got %+v", cond)
+	}
+
+	orphan = reconcileSnapshotOnce(t, exe, "wangxx", "gone-20191202")
+	if orphan.Status.Error != `spec.traincrd "gone": Traincrd not found` {
+		t.Errorf("expected TraincrdNotFound in status.error, got %+v", orphan.Status)
+	}
+}
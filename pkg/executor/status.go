@@ -0,0 +1,220 @@
+package executor
+
+import (
+	"fmt"
+	"time"
+
+	v1 "finupgroup.com/decision/traincrd/pkg/apis/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var extensionsGroup = "extensions"
+var appsGroup = "apps"
+
+/**
+根据子资源的实际状态计算 Traincrd 的 status
+*/
+func (t *Traindeploy) computeStatus(train *v1.Traincrd, c *children, reconcileErr error) v1.TraincrdStatus {
+	status := *train.Status.DeepCopy()
+	status.ObservedGeneration = train.Generation
+	status.Children = nil
+	status.Replicas = 0
+	status.ReadyReplicas = 0
+	status.Selector = ""
+	status.URL = ""
+	status.Capacity = ""
+	status.Lineage = nil
+
+	if c.pvc != nil {
+		status.Children = append(status.Children, corev1.TypedLocalObjectReference{Kind: "PersistentVolumeClaim", Name: c.pvc.Name})
+		if c.pvc.Status.Phase == corev1.ClaimBound {
+			setCondition(&status, v1.TraincrdStorageBound, corev1.ConditionTrue, "Bound", "")
+		} else {
+			setCondition(&status, v1.TraincrdStorageBound, corev1.ConditionFalse, string(c.pvc.Status.Phase),
+				fmt.Sprintf("PVC %s is %s", c.pvc.Name, c.pvc.Status.Phase))
+		}
+		if capacity, ok := c.pvc.Status.Capacity[corev1.ResourceStorage]; ok {
+			status.Capacity = capacity.String()
+		}
+		t.setResizeCondition(&status, c.pvc)
+		status.Lineage = pvcLineage(c.pvc)
+		t.setCloneCondition(&status, c.pvc)
+	} else {
+		setCondition(&status, v1.TraincrdStorageBound, corev1.ConditionFalse, "NotFound", "PVC has not been created")
+	}
+
+	if c.deployment != nil {
+		status.Children = append(status.Children, corev1.TypedLocalObjectReference{APIGroup: &appsGroup, Kind: "Deployment", Name: c.deployment.Name})
+		status.Replicas = c.deployment.Status.Replicas
+		status.ReadyReplicas = c.deployment.Status.ReadyReplicas
+		if selector, err := metav1.LabelSelectorAsSelector(c.deployment.Spec.Selector); err == nil {
+			status.Selector = selector.String()
+		}
+		setDeploymentCondition(&status, c.deployment)
+	} else {
+		setCondition(&status, v1.TraincrdDeploymentReady, corev1.ConditionFalse, "NotFound", "Deployment has not been created")
+	}
+
+	if c.svc != nil {
+		status.Children = append(status.Children, corev1.TypedLocalObjectReference{Kind: "Service", Name: c.svc.Name})
+	}
+
+	if c.ingress != nil && len(c.ingress.Spec.Rules) > 0 && c.ingress.Spec.Rules[0].HTTP != nil &&
+		len(c.ingress.Spec.Rules[0].HTTP.Paths) > 0 {
+		rule := c.ingress.Spec.Rules[0]
+		status.Children = append(status.Children, corev1.TypedLocalObjectReference{APIGroup: &extensionsGroup, Kind: "Ingress", Name: c.ingress.Name})
+		status.URL = fmt.Sprintf("http://%s%s", rule.Host, rule.HTTP.Paths[0].Path)
+		setCondition(&status, v1.TraincrdIngressReady, corev1.ConditionTrue, "Routed", "")
+	} else {
+		setCondition(&status, v1.TraincrdIngressReady, corev1.ConditionFalse, "NotFound", "Ingress has not been created")
+	}
+
+	if t.suspended {
+		setCondition(&status, v1.TraincrdSuspendedCondition, corev1.ConditionTrue, t.suspendReason, t.suspendMessage)
+	} else if getCondition(&status, v1.TraincrdSuspendedCondition) != nil {
+		setCondition(&status, v1.TraincrdSuspendedCondition, corev1.ConditionFalse, "Resumed", "")
+	}
+	if t.lifecycle.expired {
+		setCondition(&status, v1.TraincrdExpired, corev1.ConditionTrue, "Expired", fmt.Sprintf("expired at %s", t.lifecycle.expiresAt.Format(time.RFC3339)))
+	} else if getCondition(&status, v1.TraincrdExpired) != nil {
+		setCondition(&status, v1.TraincrdExpired, corev1.ConditionFalse, "Extended", "")
+	}
+
+	// 被 culling 的工作区在 replicas 改回大于 0 后恢复
+	if cond := getCondition(&status, v1.TraincrdCulled); cond != nil && cond.Status == corev1.ConditionTrue && train.Spec.Replicas > 0 {
+		setCondition(&status, v1.TraincrdCulled, corev1.ConditionFalse, "Resumed", "")
+	}
+
+	if reason, ok := permanentReason(reconcileErr); ok {
+		setCondition(&status, v1.TraincrdReconciled, corev1.ConditionFalse, reason, reconcileErr.Error())
+	} else if reconcileErr != nil {
+		setCondition(&status, v1.TraincrdReconciled, corev1.ConditionFalse, "ReconcileError", reconcileErr.Error())
+	} else {
+		setCondition(&status, v1.TraincrdReconciled, corev1.ConditionTrue, "Synced", "")
+	}
+
+	status.Phase = computePhase(train, &status, c)
+	return status
+}
+
+func setDeploymentCondition(status *v1.TraincrdStatus, dep *appsv1.Deployment) {
+	for _, cond := range dep.Status.Conditions {
+		if cond.Type == appsv1.DeploymentProgressing && cond.Status == corev1.ConditionFalse {
+			setCondition(status, v1.TraincrdDeploymentReady, corev1.ConditionFalse, cond.Reason, cond.Message)
+			return
+		}
+	}
+
+	desired := int32(1)
+	if dep.Spec.Replicas != nil {
+		desired = *dep.Spec.Replicas
+	}
+	if dep.Status.ReadyReplicas >= desired && dep.Status.ObservedGeneration >= dep.Generation {
+		setCondition(status, v1.TraincrdDeploymentReady, corev1.ConditionTrue, "ReplicasReady", "")
+		return
+	}
+	setCondition(status, v1.TraincrdDeploymentReady, corev1.ConditionFalse, "ReplicasNotReady",
+		fmt.Sprintf("%d/%d replicas ready", dep.Status.ReadyReplicas, desired))
+}
+
+// phaseIgnoredConditions 不表示子资源的就绪状态，为 False 时工作区仍然是 Running
+var phaseIgnoredConditions = map[v1.TraincrdConditionType]bool{
+	v1.TraincrdCulled:             true,
+	v1.TraincrdSuspendedCondition: true,
+	v1.TraincrdExpired:            true,
+	v1.TraincrdStorageResized:     true,
+}
+
+// setResizeCondition 报告 PVC 扩容的进度，或无法扩容的原因；从未扩容过的工作区没有该 condition
+func (t *Traindeploy) setResizeCondition(status *v1.TraincrdStatus, pvc *corev1.PersistentVolumeClaim) {
+	if reason, ok := permanentReason(t.resizeErr); ok {
+		setCondition(status, v1.TraincrdStorageResized, corev1.ConditionFalse, reason, t.resizeErr.Error())
+		return
+	}
+	for _, cond := range pvc.Status.Conditions {
+		if cond.Type == corev1.PersistentVolumeClaimFileSystemResizePending && cond.Status == corev1.ConditionTrue {
+			message := cond.Message
+			if message == "" {
+				message = "waiting for the file system to be resized when the workspace pod starts"
+			}
+			setCondition(status, v1.TraincrdStorageResized, corev1.ConditionFalse, "FileSystemResizePending", message)
+			return
+		}
+	}
+	requested := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	if actual, ok := pvc.Status.Capacity[corev1.ResourceStorage]; ok && actual.Cmp(requested) < 0 {
+		setCondition(status, v1.TraincrdStorageResized, corev1.ConditionFalse, "Resizing",
+			fmt.Sprintf("expanding the volume from %s to %s", actual.String(), requested.String()))
+		return
+	}
+	if getCondition(status, v1.TraincrdStorageResized) != nil {
+		setCondition(status, v1.TraincrdStorageResized, corev1.ConditionTrue, "Resized", "")
+	}
+}
+
+func computePhase(train *v1.Traincrd, status *v1.TraincrdStatus, c *children) v1.TraincrdPhase {
+	if train.DeletionTimestamp != nil {
+		return v1.TraincrdTerminating
+	}
+	if cond := getCondition(status, v1.TraincrdReconciled); cond != nil && cond.Status == corev1.ConditionFalse && cond.Reason != "ReconcileError" {
+		return v1.TraincrdFailed
+	}
+	if cond := getCondition(status, v1.TraincrdSuspendedCondition); cond != nil && cond.Status == corev1.ConditionTrue {
+		return v1.TraincrdSuspended
+	}
+	if c.pvc == nil && c.deployment == nil {
+		return v1.TraincrdPending
+	}
+	if cond := getCondition(status, v1.TraincrdDeploymentReady); cond != nil && cond.Reason == "ProgressDeadlineExceeded" {
+		return v1.TraincrdFailed
+	}
+	for _, cond := range status.Conditions {
+		if !phaseIgnoredConditions[cond.Type] && cond.Status != corev1.ConditionTrue {
+			return v1.TraincrdProvisioning
+		}
+	}
+	return v1.TraincrdRunning
+}
+
+func getCondition(status *v1.TraincrdStatus, condType v1.TraincrdConditionType) *v1.TraincrdCondition {
+	for i := range status.Conditions {
+		if status.Conditions[i].Type == condType {
+			return &status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// setCondition 更新或追加 condition，状态未变化时保留原来的 LastTransitionTime
+func setCondition(status *v1.TraincrdStatus, condType v1.TraincrdConditionType, condStatus corev1.ConditionStatus, reason, message string) {
+	cond := v1.TraincrdCondition{
+		Type:               condType,
+		Status:             condStatus,
+		LastTransitionTime: metav1.Now(),
+		Reason:             reason,
+		Message:            message,
+	}
+	if existing := getCondition(status, condType); existing != nil {
+		if existing.Status == condStatus {
+			cond.LastTransitionTime = existing.LastTransitionTime
+		}
+		*existing = cond
+		return
+	}
+	status.Conditions = append(status.Conditions, cond)
+}
+
+// updateStatus 通过 status 子资源写回，status 未变化时不发请求
+func (exe *Executor) updateStatus(train *v1.Traincrd, status v1.TraincrdStatus) error {
+	if equality.Semantic.DeepEqual(train.Status, status) {
+		return nil
+	}
+
+	updated := train.DeepCopy()
+	updated.Status = status
+	_, err := exe.clientTrain.DecisionV1().Traincrds(train.Namespace).UpdateStatus(updated)
+	return err
+}
@@ -0,0 +1,92 @@
+package executor
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	v1 "finupgroup.com/decision/traincrd/pkg/apis/v1"
+	"finupgroup.com/decision/traincrd/pkg/config"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestComputeStatus(t *testing.T) {
+	train := &v1.Traincrd{
+		ObjectMeta: metav1.ObjectMeta{Name: "notebook", Namespace: "wangxx", UID: "6c1e4f3a", Generation: 3, Labels: map[string]string{"username": "wangxx", "channel": "risk"}},
+		Spec:       v1.TraincrdSpec{Image: "jupyter:1.0", Cpu: "1", Memory: "1Gi", ReqCpu: "500m", ReqMemory: "512Mi", Replicas: 1, Capacity: "1Gi"},
+	}
+	traindeploy := traindeployBuild(train, config.Default())
+
+	pvc := func(phase corev1.PersistentVolumeClaimPhase) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "notebook"}, Status: corev1.PersistentVolumeClaimStatus{Phase: phase}}
+	}
+	deployment := func(ready int32, conditions ...appsv1.DeploymentCondition) *appsv1.Deployment {
+		replicas := int32(1)
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "notebook"},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas, Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "notebook"}}},
+			Status:     appsv1.DeploymentStatus{Replicas: 1, ReadyReplicas: ready, Conditions: conditions},
+		}
+	}
+	svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "notebook"}}
+	ingress := traindeploy.makeIngressSpec()
+	running := &children{pvc: pvc(corev1.ClaimBound), deployment: deployment(1), svc: svc, ingress: ingress}
+	deadline := appsv1.DeploymentCondition{Type: appsv1.DeploymentProgressing, Status: corev1.ConditionFalse,
+		Reason: "ProgressDeadlineExceeded", Message: `ReplicaSet "notebook-5d8f7" has timed out progressing.`}
+
+	tests := []struct {
+		name       string
+		children   *children
+		err        error
+		phase      v1.TraincrdPhase
+		conditions map[v1.TraincrdConditionType]string
+	}{
+		{"nothing created", &children{}, nil, v1.TraincrdPending, map[v1.TraincrdConditionType]string{
+			v1.TraincrdStorageBound: "NotFound", v1.TraincrdDeploymentReady: "NotFound", v1.TraincrdIngressReady: "NotFound", v1.TraincrdReconciled: "Synced"}},
+		{"pvc pending", &children{pvc: pvc(corev1.ClaimPending), deployment: deployment(0), svc: svc, ingress: ingress}, nil, v1.TraincrdProvisioning,
+			map[v1.TraincrdConditionType]string{v1.TraincrdStorageBound: "Pending", v1.TraincrdDeploymentReady: "ReplicasNotReady"}},
+		{"running", running, nil, v1.TraincrdRunning, map[v1.TraincrdConditionType]string{
+			v1.TraincrdStorageBound: "Bound", v1.TraincrdDeploymentReady: "ReplicasReady", v1.TraincrdIngressReady: "Routed", v1.TraincrdReconciled: "Synced"}},
+		{"progress deadline", &children{pvc: pvc(corev1.ClaimBound), deployment: deployment(0, deadline), svc: svc, ingress: ingress}, nil, v1.TraincrdFailed,
+			map[v1.TraincrdConditionType]string{v1.TraincrdDeploymentReady: "ProgressDeadlineExceeded"}},
+		{"retriable error", running, fmt.Errorf("connection refused"), v1.TraincrdProvisioning,
+			map[v1.TraincrdConditionType]string{v1.TraincrdReconciled: "ReconcileError"}},
+		{"permanent error", running, permanent("InvalidResources", fmt.Errorf("spec.cpu: invalid")), v1.TraincrdFailed,
+			map[v1.TraincrdConditionType]string{v1.TraincrdReconciled: "InvalidResources"}},
+	}
+
+	for _, test := range tests {
+		status := traindeploy.computeStatus(train, test.children, test.err)
+		if status.Phase != test.phase {
+			t.Errorf("%s: expected phase %s, got %s", test.name, test.phase, status.Phase)
+		}
+		if status.ObservedGeneration != 3 {
+			t.Errorf("%s: expected observedGeneration 3, got %d", test.name, status.ObservedGeneration)
+		}
+		for condType, reason := range test.conditions {
+			if cond := getCondition(&status, condType); cond == nil || cond.Reason != reason {
+				t.Errorf("%s: expected %s with reason %s, got %+v", test.name, condType, reason, cond)
+			}
+		}
+	}
+
+	status := traindeploy.computeStatus(train, running, nil)
+	if len(status.Children) != 4 || status.URL == "" || status.Selector != "app=notebook" || status.ReadyReplicas != 1 {
+		t.Errorf("unexpected status for a running workspace: %+v", status)
+	}
+
+	// 状态不变时保留 LastTransitionTime
+	train.Status = status
+	train.Status.Conditions[0].LastTransitionTime = metav1.NewTime(time.Now().Add(-time.Hour))
+	status = traindeploy.computeStatus(train, running, nil)
+	if !status.Conditions[0].LastTransitionTime.Equal(&train.Status.Conditions[0].LastTransitionTime) {
+		t.Errorf("expected the transition time of an unchanged condition to be kept")
+	}
+
+	train.DeletionTimestamp = &metav1.Time{}
+	if status = traindeploy.computeStatus(train, running, nil); status.Phase != v1.TraincrdTerminating {
+		t.Errorf("expected phase Terminating, got %s", status.Phase)
+	}
+}
@@ -0,0 +1,356 @@
+package executor
+
+import (
+	"fmt"
+	"path"
+
+	"finupgroup.com/decision/traincrd/pkg/config"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+)
+
+// STORAGE_PROVIDER_ANNOTATION 记录创建 PVC 时的存储实现，扩容与清理按它处理，之后修改平台配置不影响已有的卷
+const STORAGE_PROVIDER_ANNOTATION = "decision.finupgroup.com/storage-provider"
+
+/**
+storageProvider 是工作区卷的存储实现，决定 PVC 的存储类、访问模式、注解与能否在线扩容。
+CephFS 与 CSI 由存储类动态创建卷；HostPath 与 NFS 为每个工作区创建一个静态的 PV，目录为 {path}/{namespace}/{name}-{uid}
+*/
+type storageProvider interface {
+	name() config.StorageProvider
+	// dynamic 为 true 时卷由存储类创建，可以从快照恢复或 CSI 克隆
+	dynamic() bool
+	// configure 填写 PVC 的存储类、访问模式与注解，容量与 dataSource 由调用方填写
+	configure(t *Traindeploy, pvc *corev1.PersistentVolumeClaim)
+	// provision 在创建 PVC 之前准备存储
+	provision(t *Traindeploy, pvc *corev1.PersistentVolumeClaim) error
+	// expandable 返回 nil 表示 PVC 可以在线扩容，否则为 ExpansionNotSupported
+	expandable(t *Traindeploy, pvc *corev1.PersistentVolumeClaim) error
+	// release 在 PVC 随 Traincrd 删除时清理 PVC 之外的存储
+	release(t *Traindeploy, pvc *corev1.PersistentVolumeClaim) error
+}
+
+func newStorageProvider(class config.VolumeClass) storageProvider {
+	switch class.Provider {
+	case config.ProviderCSI:
+		return &classProvider{class: class, accessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}}
+	case config.ProviderHostPath:
+		return &staticProvider{class: class, accessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}}
+	case config.ProviderNFS:
+		return &staticProvider{class: class, accessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany}}
+	default:
+		class.Provider = config.ProviderCephFS
+		return &classProvider{
+			class:       class,
+			accessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany},
+			annotations: map[string]string{
+				corev1.BetaStorageClassAnnotation:               class.StorageClassName,
+				"volume.beta.kubernetes.io/storage-provisioner": "ceph.com/cephfs",
+			},
+		}
+	}
+}
+
+// provider 解析 spec.storage.class，未填写时为 channel 的存储类，再为平台默认的存储类
+func (t *Traindeploy) provider() (storageProvider, error) {
+	class, ok := t.cfg.Storage.ClassFor(t.storageClass, t.channel)
+	if !ok {
+		return nil, permanent("StorageClassNotFound", fmt.Errorf("spec.storage.class %q: not in storage.classes of the controller config", t.storageClass))
+	}
+	return newStorageProvider(class), nil
+}
+
+// providerOf 已有 PVC 的存储实现，旧版本创建的 PVC 没有 STORAGE_PROVIDER_ANNOTATION，为 CephFS
+func providerOf(pvc *corev1.PersistentVolumeClaim) storageProvider {
+	return newStorageProvider(config.VolumeClass{Provider: config.StorageProvider(pvc.Annotations[STORAGE_PROVIDER_ANNOTATION])})
+}
+
+// configureClaim 设置访问模式与注解，存储类配置的 accessModes 优先于存储实现的默认值
+func configureClaim(pvc *corev1.PersistentVolumeClaim, class config.VolumeClass, accessModes []corev1.PersistentVolumeAccessMode, annotations map[string]string) {
+	if len(class.AccessModes) > 0 {
+		accessModes = class.AccessModes
+	}
+	pvc.Spec.AccessModes = append([]corev1.PersistentVolumeAccessMode(nil), accessModes...)
+	if pvc.Annotations == nil {
+		pvc.Annotations = map[string]string{}
+	}
+	for k, v := range annotations {
+		pvc.Annotations[k] = v
+	}
+	for k, v := range class.Annotations {
+		pvc.Annotations[k] = v
+	}
+	pvc.Annotations[STORAGE_PROVIDER_ANNOTATION] = string(class.Provider)
+}
+
+// classProvider CephFS 与 CSI：PVC 指定存储类，由 provisioner 创建卷
+type classProvider struct {
+	class       config.VolumeClass
+	accessModes []corev1.PersistentVolumeAccessMode
+	annotations map[string]string
+}
+
+func (p *classProvider) name() config.StorageProvider {
+	return p.class.Provider
+}
+
+func (p *classProvider) dynamic() bool {
+	return true
+}
+
+func (p *classProvider) configure(t *Traindeploy, pvc *corev1.PersistentVolumeClaim) {
+	className := p.class.StorageClassName
+	pvc.Spec.StorageClassName = &className
+	configureClaim(pvc, p.class, p.accessModes, p.annotations)
+}
+
+func (p *classProvider) provision(t *Traindeploy, pvc *corev1.PersistentVolumeClaim) error {
+	return nil
+}
+
+// expandable 存储类需要设置 allowVolumeExpansion
+func (p *classProvider) expandable(t *Traindeploy, pvc *corev1.PersistentVolumeClaim) error {
+	className := ""
+	if pvc.Spec.StorageClassName != nil {
+		className = *pvc.Spec.StorageClassName
+	} else {
+		className = pvc.Annotations[corev1.BetaStorageClassAnnotation]
+	}
+	if className == "" {
+		return permanent("ExpansionNotSupported", fmt.Errorf("PVC %s has no storage class to expand it", pvc.Name))
+	}
+	class, err := t.clientK8s.StorageV1().StorageClasses().Get(className, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return permanent("ExpansionNotSupported", fmt.Errorf("storage class %s of PVC %s not found", className, pvc.Name))
+	}
+	if err != nil {
+		return err
+	}
+	if class.AllowVolumeExpansion == nil || !*class.AllowVolumeExpansion {
+		return permanent("ExpansionNotSupported", fmt.Errorf("storage class %s does not allow volume expansion", className))
+	}
+	return nil
+}
+
+func (p *classProvider) release(t *Traindeploy, pvc *corev1.PersistentVolumeClaim) error {
+	return nil
+}
+
+/**
+staticProvider HostPath 与 NFS：没有 provisioner，创建 PVC 前先创建预先绑定到它的 PV。
+PV 是集群级别的资源，不能以 Traincrd 为 owner，PVC 随 Traincrd 删除时由 release 删除；目录中的数据保留，由管理员清理
+*/
+type staticProvider struct {
+	class       config.VolumeClass
+	accessModes []corev1.PersistentVolumeAccessMode
+}
+
+func (p *staticProvider) name() config.StorageProvider {
+	return p.class.Provider
+}
+
+func (p *staticProvider) dynamic() bool {
+	return false
+}
+
+func (p *staticProvider) configure(t *Traindeploy, pvc *corev1.PersistentVolumeClaim) {
+	// 空的存储类避免 DefaultStorageClass 准入插件为 PVC 填上默认存储类
+	className := ""
+	pvc.Spec.StorageClassName = &className
+	pvc.Spec.VolumeName = t.persistentVolumeName()
+	configureClaim(pvc, p.class, p.accessModes, nil)
+}
+
+func (p *staticProvider) provision(t *Traindeploy, pvc *corev1.PersistentVolumeClaim) error {
+	dir := path.Join(p.class.Path, t.namespace, t.name+"-"+t.uid)
+	var source corev1.PersistentVolumeSource
+	if p.class.Provider == config.ProviderNFS {
+		source.NFS = &corev1.NFSVolumeSource{Server: p.class.Server, Path: dir}
+		// NFS 不会创建不存在的目录，挂载失败时 kubelet 会重试，直到 Job 创建好目录
+		if err := t.createStorageJob(p.class, dir); err != nil {
+			return err
+		}
+	} else {
+		directoryOrCreate := corev1.HostPathDirectoryOrCreate
+		source.HostPath = &corev1.HostPathVolumeSource{Path: dir, Type: &directoryOrCreate}
+	}
+
+	var nodeAffinity *corev1.VolumeNodeAffinity
+	if source.HostPath != nil {
+		// 目录只在一个节点上，工作区 Pod 必须调度到该节点
+		nodeAffinity = &corev1.VolumeNodeAffinity{
+			Required: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+					MatchExpressions: []corev1.NodeSelectorRequirement{
+						{Key: corev1.LabelHostname, Operator: corev1.NodeSelectorOpIn, Values: []string{p.class.Node}},
+					},
+				}},
+			},
+		}
+	}
+
+	pv := &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   pvc.Spec.VolumeName,
+			Labels: map[string]string{"app": t.name, "username": t.username, "channel": t.channel},
+		},
+		Spec: corev1.PersistentVolumeSpec{
+			Capacity:                      pvc.Spec.Resources.Requests,
+			AccessModes:                   pvc.Spec.AccessModes,
+			ClaimRef:                      &corev1.ObjectReference{Kind: "PersistentVolumeClaim", Namespace: t.namespace, Name: pvc.Name},
+			PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimRetain,
+			StorageClassName:              "",
+			PersistentVolumeSource:        source,
+			NodeAffinity:                  nodeAffinity,
+		},
+	}
+	klog.Infof("创建 %s PV %s, 目录: %s, %s", p.class.Provider, pv.Name, dir, t.toString())
+	_, err := t.clientK8s.CoreV1().PersistentVolumes().Create(pv)
+	if errors.IsAlreadyExists(err) {
+		return nil
+	}
+	return err
+}
+
+func (p *staticProvider) expandable(t *Traindeploy, pvc *corev1.PersistentVolumeClaim) error {
+	return permanent("ExpansionNotSupported", fmt.Errorf("%s volumes cannot be expanded", p.class.Provider))
+}
+
+// release 只删除绑定到该 PVC 的 PV；PVC 仍存在时 pv-protection 会推迟删除，直到 PVC 被回收
+func (p *staticProvider) release(t *Traindeploy, pvc *corev1.PersistentVolumeClaim) error {
+	if pvc.Spec.VolumeName == "" {
+		return nil
+	}
+	pv, err := t.clientK8s.CoreV1().PersistentVolumes().Get(pvc.Spec.VolumeName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if ref := pv.Spec.ClaimRef; ref == nil || ref.Namespace != pvc.Namespace || ref.Name != pvc.Name {
+		return nil
+	}
+	klog.Infof("删除 PV %s, %s", pv.Name, t.toString())
+	err = t.clientK8s.CoreV1().PersistentVolumes().Delete(pv.Name, &metav1.DeleteOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+/**
+pinToClaimNode 让挂载 ReadWriteOnce PVC 的 Job 调度到正在使用该 PVC 的 Pod 所在的节点，
+否则卷无法挂载到第二个节点，Job 一直 Pending；可以多节点挂载或没有 Pod 使用时不限制
+*/
+func (t *Traindeploy) pinToClaimNode(pod *corev1.PodSpec, namespace, claimName string) error {
+	pvc, err := t.clientK8s.CoreV1().PersistentVolumeClaims(namespace).Get(claimName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, mode := range pvc.Spec.AccessModes {
+		if mode == corev1.ReadWriteMany || mode == corev1.ReadOnlyMany {
+			return nil
+		}
+	}
+
+	pods, err := t.clientK8s.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, p := range pods.Items {
+		if p.Spec.NodeName == "" || p.Status.Phase == corev1.PodSucceeded || p.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		for _, volume := range p.Spec.Volumes {
+			if volume.PersistentVolumeClaim == nil || volume.PersistentVolumeClaim.ClaimName != claimName {
+				continue
+			}
+			pod.Affinity = &corev1.Affinity{
+				NodeAffinity: &corev1.NodeAffinity{
+					RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+						NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+							MatchFields: []corev1.NodeSelectorRequirement{
+								{Key: "metadata.name", Operator: corev1.NodeSelectorOpIn, Values: []string{p.Spec.NodeName}},
+							},
+						}},
+					},
+				},
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+/**
+deploymentStrategy 工作区 PVC 不能多节点挂载时使用 Recreate：滚动更新的新 Pod 调度到其他节点时，
+在旧 Pod 释放卷之前无法挂载，更新一直卡住直到超过 progressDeadline；存储类不在配置中时同样使用 Recreate
+*/
+func (t *Traindeploy) deploymentStrategy() appsv1.DeploymentStrategyType {
+	provider, err := t.provider()
+	if err != nil {
+		return appsv1.RecreateDeploymentStrategyType
+	}
+	pvc := &corev1.PersistentVolumeClaim{}
+	provider.configure(t, pvc)
+	for _, mode := range pvc.Spec.AccessModes {
+		if mode == corev1.ReadWriteMany {
+			return appsv1.RollingUpdateDeploymentStrategyType
+		}
+	}
+	return appsv1.RecreateDeploymentStrategyType
+}
+
+// persistentVolumeName 静态卷的 PV 以 Traincrd 的 UID 命名，同名的工作区重建时不会复用旧的 PV
+func (t *Traindeploy) persistentVolumeName() string {
+	return "traincrd-" + t.uid
+}
+
+// createStorageJob 挂载 NFS 的导出目录，为工作区创建子目录
+func (t *Traindeploy) createStorageJob(class config.VolumeClass, dir string) error {
+	backoffLimit := int32(3)
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            t.name + "-storage",
+			Labels:          map[string]string{"app": t.name, "username": t.username, "channel": t.channel},
+			OwnerReferences: t.ownerReferences(),
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{
+						{
+							Name:         "mkdir",
+							Image:        t.cfg.Archive.Image,
+							Command:      []string{"mkdir", "-p", path.Join("/export", dir[len(path.Clean(class.Path)):])},
+							VolumeMounts: []corev1.VolumeMount{{Name: "export", MountPath: "/export"}},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "export",
+							VolumeSource: corev1.VolumeSource{
+								NFS: &corev1.NFSVolumeSource{Server: class.Server, Path: class.Path},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	_, err := t.clientK8s.BatchV1().Jobs(t.namespace).Create(job)
+	if errors.IsAlreadyExists(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,247 @@
+package executor
+
+import (
+	"testing"
+
+	v1 "finupgroup.com/decision/traincrd/pkg/apis/v1"
+	trainfake "finupgroup.com/decision/traincrd/pkg/client/clientset/versioned/fake"
+	"finupgroup.com/decision/traincrd/pkg/config"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func storageConfig() *config.Config {
+	cfg := config.Default()
+	cfg.Storage.Classes = map[string]config.VolumeClass{
+		"rbd":   {Provider: config.ProviderCSI, StorageClassName: "csi-rbd"},
+		"local": {Provider: config.ProviderHostPath, Path: "/data/traincrd", Node: "node-1"},
+		"nfs":   {Provider: config.ProviderNFS, Server: "10.0.0.5", Path: "/exports/traincrd"},
+	}
+	cfg.Storage.Channels = map[string]string{"risk": "rbd"}
+	return cfg
+}
+
+func TestStorageProviders(t *testing.T) {
+	for _, test := range []struct {
+		name         string
+		channel      string
+		class        string
+		provider     config.StorageProvider
+		storageClass string
+		accessMode   corev1.PersistentVolumeAccessMode
+		source       corev1.PersistentVolumeSource
+	}{
+		{"platform default", "qz", "", config.ProviderCephFS, "cephfs", corev1.ReadWriteMany, corev1.PersistentVolumeSource{}},
+		{"channel default", "risk", "", config.ProviderCSI, "csi-rbd", corev1.ReadWriteOnce, corev1.PersistentVolumeSource{}},
+		{"hostPath", "risk", "local", config.ProviderHostPath, "", corev1.ReadWriteOnce, corev1.PersistentVolumeSource{
+			HostPath: &corev1.HostPathVolumeSource{Path: "/data/traincrd/wangxx/notebook-6c1e4f3a"}}},
+		{"nfs", "qz", "nfs", config.ProviderNFS, "", corev1.ReadWriteMany, corev1.PersistentVolumeSource{
+			NFS: &corev1.NFSVolumeSource{Server: "10.0.0.5", Path: "/exports/traincrd/wangxx/notebook-6c1e4f3a"}}},
+	} {
+		train := &v1.Traincrd{
+			ObjectMeta: metav1.ObjectMeta{Name: "notebook", Namespace: "wangxx", UID: "6c1e4f3a", Labels: map[string]string{"username": "wangxx", "channel": test.channel}},
+			Spec:       v1.TraincrdSpec{Image: "jupyter:1.0", Cpu: "1", Memory: "1Gi", ReqCpu: "500m", ReqMemory: "512Mi", Replicas: 1, Capacity: "1Gi"},
+		}
+		if test.class != "" {
+			train.Spec.Storage = &v1.TraincrdStorage{Class: test.class}
+		}
+		clientK8s := k8sfake.NewSimpleClientset()
+		exe := New(trainfake.NewSimpleClientset(train), clientK8s, nil, 0)
+		exe.cfg.Store(storageConfig())
+
+		reconcileOnce(t, exe, "wangxx", "notebook")
+		pvc, err := clientK8s.CoreV1().PersistentVolumeClaims("wangxx").Get("notebook", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pvc.Annotations[STORAGE_PROVIDER_ANNOTATION] != string(test.provider) {
+			t.Errorf("%s: expected provider %s, got %q", test.name, test.provider, pvc.Annotations[STORAGE_PROVIDER_ANNOTATION])
+		}
+		if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName != test.storageClass {
+			t.Errorf("%s: expected storage class %q, got %v", test.name, test.storageClass, pvc.Spec.StorageClassName)
+		}
+		if len(pvc.Spec.AccessModes) != 1 || pvc.Spec.AccessModes[0] != test.accessMode {
+			t.Errorf("%s: expected access mode %s, got %v", test.name, test.accessMode, pvc.Spec.AccessModes)
+		}
+		// 不能多节点挂载的卷滚动更新会卡住
+		strategy := appsv1.RecreateDeploymentStrategyType
+		if test.accessMode == corev1.ReadWriteMany {
+			strategy = appsv1.RollingUpdateDeploymentStrategyType
+		}
+		deployment, err := clientK8s.AppsV1().Deployments("wangxx").Get("notebook", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if deployment.Spec.Strategy.Type != strategy {
+			t.Errorf("%s: expected strategy %s, got %s", test.name, strategy, deployment.Spec.Strategy.Type)
+		}
+		if test.provider == config.ProviderCephFS && pvc.Annotations["volume.beta.kubernetes.io/storage-provisioner"] != "ceph.com/cephfs" {
+			t.Errorf("%s: expected the cephfs provisioner annotation, got %v", test.name, pvc.Annotations)
+		}
+
+		pvs, err := clientK8s.CoreV1().PersistentVolumes().List(metav1.ListOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if test.source.HostPath == nil && test.source.NFS == nil {
+			if len(pvs.Items) != 0 || pvc.Spec.VolumeName != "" {
+				t.Errorf("%s: expected the provisioner to create the volume, got %+v", test.name, pvs.Items)
+			}
+			continue
+		}
+		if len(pvs.Items) != 1 || pvc.Spec.VolumeName != "traincrd-6c1e4f3a" {
+			t.Fatalf("%s: expected a PV bound to the PVC, got %+v", test.name, pvs.Items)
+		}
+		pv := pvs.Items[0]
+		if ref := pv.Spec.ClaimRef; ref == nil || ref.Namespace != "wangxx" || ref.Name != "notebook" {
+			t.Errorf("%s: expected the PV to be bound to wangxx/notebook, got %+v", test.name, ref)
+		}
+		if source := test.source.HostPath; source != nil {
+			if pv.Spec.HostPath == nil || pv.Spec.HostPath.Path != source.Path {
+				t.Errorf("%s: expected hostPath %s, got %+v", test.name, source.Path, pv.Spec.PersistentVolumeSource)
+			}
+			if affinity := pv.Spec.NodeAffinity; affinity == nil || affinity.Required == nil ||
+				affinity.Required.NodeSelectorTerms[0].MatchExpressions[0].Values[0] != "node-1" {
+				t.Errorf("%s: expected the PV to be pinned to node-1, got %+v", test.name, affinity)
+			}
+		}
+		if source := test.source.NFS; source != nil {
+			if pv.Spec.NFS == nil || *pv.Spec.NFS != *source {
+				t.Errorf("%s: expected nfs %+v, got %+v", test.name, source, pv.Spec.PersistentVolumeSource)
+			}
+			job, err := clientK8s.BatchV1().Jobs("wangxx").Get("notebook-storage", metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if command := job.Spec.Template.Spec.Containers[0].Command; command[len(command)-1] != "/export/wangxx/notebook-6c1e4f3a" {
+				t.Errorf("%s: expected the Job to create the workspace directory, got %v", test.name, command)
+			}
+		}
+	}
+}
+
+func TestStaticStorageLifecycle(t *testing.T) {
+	train := &v1.Traincrd{
+		ObjectMeta: metav1.ObjectMeta{Name: "notebook", Namespace: "wangxx", UID: "6c1e4f3a", Labels: map[string]string{"username": "wangxx", "channel": "qz"}},
+		Spec: v1.TraincrdSpec{Image: "jupyter:1.0", Cpu: "1", Memory: "1Gi", ReqCpu: "500m", ReqMemory: "512Mi", Replicas: 1, Capacity: "1Gi",
+			Storage: &v1.TraincrdStorage{Class: "local"}},
+	}
+	clientK8s := k8sfake.NewSimpleClientset()
+	exe := New(trainfake.NewSimpleClientset(train), clientK8s, nil, 0)
+	exe.cfg.Store(storageConfig())
+	reconcileOnce(t, exe, "wangxx", "notebook")
+
+	// 静态卷不能扩容
+	train, err := exe.clientTrain.DecisionV1().Traincrds("wangxx").Get("notebook", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	train.Spec.Capacity = "5Gi"
+	if _, err := exe.clientTrain.DecisionV1().Traincrds("wangxx").Update(train); err != nil {
+		t.Fatal(err)
+	}
+	train = reconcileOnce(t, exe, "wangxx", "notebook")
+	if cond := getCondition(&train.Status, v1.TraincrdStorageResized); cond == nil || cond.Reason != "ExpansionNotSupported" {
+		t.Errorf("expected StorageResized with reason ExpansionNotSupported, got %+v", cond)
+	}
+
+	// 修改平台的存储类不影响已有的 PVC
+	cfg := storageConfig()
+	delete(cfg.Storage.Classes, "local")
+	exe.cfg.Store(cfg)
+
+	now := metav1.Now()
+	train.DeletionTimestamp = &now
+	if _, err := exe.clientTrain.DecisionV1().Traincrds("wangxx").Update(train); err != nil {
+		t.Fatal(err)
+	}
+	reconcileOnce(t, exe, "wangxx", "notebook")
+	if _, err := clientK8s.CoreV1().PersistentVolumes().Get("traincrd-6c1e4f3a", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("expected the PV to be deleted with the workspace, got %v", err)
+	}
+
+	// 不存在的存储类
+	train = train.DeepCopy()
+	train.DeletionTimestamp = nil
+	if err := exe.informer.GetIndexer().Update(train); err != nil {
+		t.Fatal(err)
+	}
+	if err := clientK8s.CoreV1().PersistentVolumeClaims("wangxx").Delete("notebook", &metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if reason, _ := permanentReason(exe.Reconcile("wangxx", "notebook")); reason != "StorageClassNotFound" {
+		t.Errorf("expected StorageClassNotFound, got %q", reason)
+	}
+}
+
+func TestReadWriteOnceJobs(t *testing.T) {
+	workspacePod := func(namespace, claimName string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: claimName + "-5d8f7-x2k4q", Namespace: namespace,
+				Labels: map[string]string{"app": claimName, "username": namespace, "channel": "risk"}},
+			Spec: corev1.PodSpec{
+				NodeName: "node-1",
+				Volumes: []corev1.Volume{{Name: "workspace", VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: claimName}}}},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+	}
+
+	// 归档前停止工作区，Job 不会与工作区 Pod 争用 ReadWriteOnce 的卷
+	train := &v1.Traincrd{
+		ObjectMeta: metav1.ObjectMeta{Name: "notebook", Namespace: "wangxx", UID: "6c1e4f3a", Labels: map[string]string{"username": "wangxx", "channel": "risk"}},
+		Spec: v1.TraincrdSpec{Image: "jupyter:1.0", Cpu: "1", Memory: "1Gi", ReqCpu: "500m", ReqMemory: "512Mi", Replicas: 1, Capacity: "1Gi",
+			RetainPolicy: v1.RetainPolicyArchive},
+	}
+	pod := workspacePod("wangxx", "notebook")
+	clientK8s := k8sfake.NewSimpleClientset(pod)
+	exe := New(trainfake.NewSimpleClientset(train), clientK8s, nil, 0)
+	exe.cfg.Store(storageConfig())
+	reconcileOnce(t, exe, "wangxx", "notebook")
+	pvc, err := clientK8s.CoreV1().PersistentVolumeClaims("wangxx").Get("notebook", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pvc.Spec.AccessModes[0] != corev1.ReadWriteOnce {
+		t.Fatalf("expected the rbd class of channel risk to be ReadWriteOnce, got %v", pvc.Spec.AccessModes)
+	}
+
+	deleteTraincrd(t, exe, "wangxx", "notebook")
+	if _, err := clientK8s.BatchV1().Jobs("wangxx").Get("notebook-archive", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("expected no archive Job while the workspace pod holds the volume, got %v", err)
+	}
+	if err := clientK8s.CoreV1().Pods("wangxx").Delete(pod.Name, &metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	deleteTraincrd(t, exe, "wangxx", "notebook")
+	if _, err := clientK8s.BatchV1().Jobs("wangxx").Get("notebook-archive", metav1.GetOptions{}); err != nil {
+		t.Errorf("expected the archive Job once the workspace pod is gone: %v", err)
+	}
+
+	// 复制仍在运行的源工作区时，导出 Job 调度到源 Pod 所在的节点
+	source, sourcePVC := lesson("teacher", true)
+	sourcePVC.Spec.AccessModes = []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}
+	clone := &v1.Traincrd{
+		ObjectMeta: metav1.ObjectMeta{Name: "notebook", Namespace: "wangxx", UID: "6c1e4f3a", Labels: map[string]string{"username": "wangxx", "channel": "course"}},
+		Spec:       v1.TraincrdSpec{Cpu: "1", Replicas: 1, CloneFrom: &v1.TraincrdCloneSource{Namespace: "teacher", Name: "lesson-1"}},
+	}
+	clientK8s = k8sfake.NewSimpleClientset(sourcePVC, workspacePod("teacher", "lesson-1"))
+	exe = New(trainfake.NewSimpleClientset(source, clone), clientK8s, nil, 0)
+	if err := exe.informer.GetIndexer().Add(source); err != nil {
+		t.Fatal(err)
+	}
+	reconcileOnce(t, exe, "wangxx", "notebook")
+	job, err := clientK8s.BatchV1().Jobs("teacher").Get("clone-6c1e4f3a", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	affinity := job.Spec.Template.Spec.Affinity
+	if affinity == nil || affinity.NodeAffinity == nil || affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil ||
+		affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchFields[0].Values[0] != "node-1" {
+		t.Errorf("expected the export Job to be pinned to node-1, got %+v", affinity)
+	}
+}
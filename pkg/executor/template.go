@@ -0,0 +1,213 @@
+package executor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"text/template"
+
+	v1 "finupgroup.com/decision/traincrd/pkg/apis/v1"
+	clientsetT "finupgroup.com/decision/traincrd/pkg/client/clientset/versioned"
+	"finupgroup.com/decision/traincrd/pkg/config"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+// TEMPLATE_LABEL 标记 templates.namespace 中注册为工作区模板的 ConfigMap，data 中 type、template、port 与 WorkspaceTemplate 的 spec 相同
+const TEMPLATE_LABEL = "decision.finupgroup.com/workspace-template"
+
+// TEMPLATE_HASH_ANNOTATION 记录渲染结果的 hash，模板或其参数变化时据此更新 Deployment
+const TEMPLATE_HASH_ANNOTATION = "decision.finupgroup.com/template-hash"
+
+// workspaceTemplate 是从 WorkspaceTemplate 或 ConfigMap 中读取的模板
+type workspaceTemplate struct {
+	name string
+	kind v1.WorkspaceTemplateType
+	body string
+	port int32
+}
+
+// templateValues 是模板中可以引用的值
+type templateValues struct {
+	Name      string
+	Namespace string
+	Username  string
+	Channel   string
+	Image     string
+	WorkDir   string
+	Replicas  int
+	Resources corev1.ResourceRequirements
+	Port      int32
+	Workspace config.WorkspaceConfig
+}
+
+var templateFuncs = template.FuncMap{
+	"toYaml": func(v interface{}) (string, error) {
+		data, err := yaml.Marshal(v)
+		return strings.TrimSuffix(string(data), "\n"), err
+	},
+	"indent": func(n int, s string) string {
+		pad := strings.Repeat(" ", n)
+		return pad + strings.Replace(s, "\n", "\n"+pad, -1)
+	},
+	"quote": strconv.Quote,
+}
+
+/**
+按名称查找模板：先查集群级的 WorkspaceTemplate，再查 templates.namespace 中带 TEMPLATE_LABEL 的 ConfigMap
+*/
+func getWorkspaceTemplate(clientTrain clientsetT.Interface, clientK8s kubernetes.Interface, namespace, name string) (*workspaceTemplate, error) {
+	wt, err := clientTrain.DecisionV1().WorkspaceTemplates().Get(name, metav1.GetOptions{})
+	if err == nil {
+		return &workspaceTemplate{name: name, kind: wt.Spec.Type, body: wt.Spec.Template, port: wt.Spec.Port}, nil
+	}
+	if !errors.IsNotFound(err) {
+		return nil, err
+	}
+
+	cm, err := clientK8s.CoreV1().ConfigMaps(namespace).Get(name, metav1.GetOptions{})
+	if errors.IsNotFound(err) || (err == nil && cm.Labels[TEMPLATE_LABEL] != "true") {
+		return nil, permanent("TemplateNotFound", fmt.Errorf("spec.template %q: no WorkspaceTemplate or ConfigMap %s/%s labelled %s=true", name, namespace, name, TEMPLATE_LABEL))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl := &workspaceTemplate{name: name, kind: v1.WorkspaceTemplateType(cm.Data["type"]), body: cm.Data["template"]}
+	if port := cm.Data["port"]; port != "" {
+		value, err := strconv.ParseInt(port, 10, 32)
+		if err != nil {
+			return nil, permanent("InvalidTemplate", fmt.Errorf("template %q port %q: %v", name, port, err))
+		}
+		tmpl.port = int32(value)
+	}
+	return tmpl, nil
+}
+
+// port 是 Service 转发到的容器端口
+func (t *Traindeploy) port() int32 {
+	if t.template != nil && t.template.port > 0 {
+		return t.template.port
+	}
+	return t.cfg.Workspace.Port
+}
+
+/**
+生成工作区的 PodTemplateSpec：未选择模板时为内置的 Jupyter 工作区，
+GoTemplate 渲染出完整的 PodTemplateSpec，StrategicMerge 渲染出的 patch 合并到内置工作区之上；
+spec.volumes 与 v1beta2 的 spec.env、spec.ports 在最后加入，对所有模板都生效
+*/
+func (t *Traindeploy) makePodTemplate(labels map[string]string) (corev1.PodTemplateSpec, error) {
+	if t.extraErr != nil {
+		return corev1.PodTemplateSpec{}, t.extraErr
+	}
+	base, err := t.basePodTemplate()
+	if err != nil || t.template == nil {
+		base.Labels = labels
+		if err == nil {
+			err = t.addVolumes(&base.Spec)
+		}
+		if err == nil {
+			err = t.addExtraFields(&base.Spec)
+		}
+		return base, err
+	}
+
+	rendered, err := t.renderTemplate()
+	if err != nil {
+		return corev1.PodTemplateSpec{}, permanent("InvalidTemplate", fmt.Errorf("template %q: %v", t.template.name, err))
+	}
+
+	var pod corev1.PodTemplateSpec
+	switch t.template.kind {
+	case v1.WorkspaceTemplateGoTemplate:
+		err = yaml.UnmarshalStrict(rendered, &pod)
+	case v1.WorkspaceTemplateStrategicMerge:
+		pod, err = mergePodTemplate(base, rendered)
+	default:
+		err = fmt.Errorf("unsupported type %q, expect %s or %s", t.template.kind, v1.WorkspaceTemplateGoTemplate, v1.WorkspaceTemplateStrategicMerge)
+	}
+	if err != nil {
+		return corev1.PodTemplateSpec{}, permanent("InvalidTemplate", fmt.Errorf("template %q: %v", t.template.name, err))
+	}
+	if len(pod.Spec.Containers) == 0 {
+		return corev1.PodTemplateSpec{}, permanent("InvalidTemplate", fmt.Errorf("template %q renders no container", t.template.name))
+	}
+	if err := t.addVolumes(&pod.Spec); err != nil {
+		return corev1.PodTemplateSpec{}, err
+	}
+	if err := t.addExtraFields(&pod.Spec); err != nil {
+		return corev1.PodTemplateSpec{}, err
+	}
+
+	// Deployment 的 selector 依赖这些 label，模板不能覆盖
+	if pod.Labels == nil {
+		pod.Labels = map[string]string{}
+	}
+	for k, v := range labels {
+		pod.Labels[k] = v
+	}
+	if pod.Annotations == nil {
+		pod.Annotations = map[string]string{}
+	}
+	pod.Annotations[TEMPLATE_HASH_ANNOTATION] = hashPodTemplate(&pod)
+	return pod, nil
+}
+
+func (t *Traindeploy) renderTemplate() ([]byte, error) {
+	tmpl, err := template.New(t.template.name).Option("missingkey=error").Funcs(templateFuncs).Parse(t.template.body)
+	if err != nil {
+		return nil, err
+	}
+	resources, err := getContainerResources(t)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, templateValues{
+		Name:      t.name,
+		Namespace: t.namespace,
+		Username:  t.username,
+		Channel:   t.channel,
+		Image:     t.image,
+		WorkDir:   t.workDir,
+		Replicas:  t.replicas,
+		Resources: resources,
+		Port:      t.port(),
+		Workspace: t.cfg.Workspace,
+	})
+	return buf.Bytes(), err
+}
+
+func mergePodTemplate(base corev1.PodTemplateSpec, patch []byte) (corev1.PodTemplateSpec, error) {
+	var merged corev1.PodTemplateSpec
+	original, err := json.Marshal(base)
+	if err != nil {
+		return merged, err
+	}
+	patchJSON, err := yaml.YAMLToJSON(patch)
+	if err != nil {
+		return merged, err
+	}
+	data, err := strategicpatch.StrategicMergePatch(original, patchJSON, corev1.PodTemplateSpec{})
+	if err != nil {
+		return merged, err
+	}
+	err = json.Unmarshal(data, &merged)
+	return merged, err
+}
+
+func hashPodTemplate(pod *corev1.PodTemplateSpec) string {
+	data, _ := json.Marshal(pod)
+	h := fnv.New32a()
+	h.Write(data)
+	return strconv.FormatUint(uint64(h.Sum32()), 16)
+}
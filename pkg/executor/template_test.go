@@ -0,0 +1,225 @@
+package executor
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	v1 "finupgroup.com/decision/traincrd/pkg/apis/v1"
+	"finupgroup.com/decision/traincrd/pkg/apis/v1beta2"
+	trainfake "finupgroup.com/decision/traincrd/pkg/client/clientset/versioned/fake"
+	"finupgroup.com/decision/traincrd/pkg/config"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/yaml"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata/golden with the rendered Deployments")
+
+// templateObjects 读取 testdata/templates 中的 WorkspaceTemplate 与 ConfigMap
+func templateObjects(t *testing.T) (trainObjects, k8sObjects []runtime.Object) {
+	files, err := filepath.Glob("testdata/templates/*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var meta metav1.TypeMeta
+		if err := yaml.Unmarshal(data, &meta); err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		switch meta.Kind {
+		case "WorkspaceTemplate":
+			wt := &v1.WorkspaceTemplate{}
+			err = yaml.UnmarshalStrict(data, wt)
+			trainObjects = append(trainObjects, wt)
+		case "ConfigMap":
+			cm := &corev1.ConfigMap{}
+			err = yaml.UnmarshalStrict(data, cm)
+			k8sObjects = append(k8sObjects, cm)
+		default:
+			t.Fatalf("%s: unexpected kind %q", file, meta.Kind)
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+	}
+	return trainObjects, k8sObjects
+}
+
+// newTemplateTraindeploy 用 testdata/templates 中的 WorkspaceTemplate 与 ConfigMap 构建 fake client
+func newTemplateTraindeploy(t *testing.T, template string) *Traindeploy {
+	trainObjects, k8sObjects := templateObjects(t)
+	train := &v1.Traincrd{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "notebook",
+			Namespace: "wangxx",
+			UID:       "6c1e4f3a-0d2b-4b7e-9a51-2f0c8d9e7b10",
+			Labels:    map[string]string{"username": "wangxx", "channel": "risk"},
+		},
+		Spec: v1.TraincrdSpec{
+			Image: "jupyter:1.0", Cpu: "2", Memory: "4Gi", ReqCpu: "500m", ReqMemory: "1Gi",
+			Replicas: 1, Template: template,
+		},
+	}
+	traindeploy := traindeployBuild(train, config.Default())
+	traindeploy.clientTrain = trainfake.NewSimpleClientset(trainObjects...)
+	traindeploy.clientK8s = k8sfake.NewSimpleClientset(k8sObjects...)
+	return traindeploy
+}
+
+func TestTemplateGolden(t *testing.T) {
+	cases := map[string]string{
+		"jupyter":       "",
+		"kodexplorer":   "kodexplorer",
+		"tensorboard":   "tensorboard",
+		"decisiontrain": "decisiontrain",
+	}
+	for name, template := range cases {
+		traindeploy := newTemplateTraindeploy(t, template)
+		if template != "" {
+			tmpl, err := getWorkspaceTemplate(traindeploy.clientTrain, traindeploy.clientK8s, "default", template)
+			if err != nil {
+				t.Errorf("%s: %v", name, err)
+				continue
+			}
+			traindeploy.template = tmpl
+		}
+
+		deployment, err := traindeploy.makeDeploymentSpec()
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		service := traindeploy.makeServiceSpec()
+		rendered, err := yaml.Marshal(deployment)
+		if err != nil {
+			t.Fatal(err)
+		}
+		serviceYAML, err := yaml.Marshal(service)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rendered = append(append(rendered, "---\n"...), serviceYAML...)
+
+		golden := filepath.Join("testdata/golden", name+".yaml")
+		if *update {
+			if err := ioutil.WriteFile(golden, rendered, 0644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		expected, err := ioutil.ReadFile(golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(rendered, expected) {
+			t.Errorf("%s: rendered Deployment differs from %s, rerun with -update if intended:\n%s", name, golden, rendered)
+		}
+	}
+}
+
+func TestTemplateReconcileInSync(t *testing.T) {
+	trainObjects, k8sObjects := templateObjects(t)
+	train := &v1.Traincrd{
+		ObjectMeta: metav1.ObjectMeta{Name: "notebook", Namespace: "wangxx", UID: "6c1e4f3a", Labels: map[string]string{"username": "wangxx", "channel": "risk"}},
+		Spec:       v1.TraincrdSpec{Image: "jupyter:1.0", Cpu: "1", Memory: "1Gi", ReqCpu: "500m", ReqMemory: "512Mi", Replicas: 1, Capacity: "1Gi", Template: "kodexplorer"},
+	}
+	clientK8s := k8sfake.NewSimpleClientset(k8sObjects...)
+	applyDefaults(clientK8s)
+	exe := New(trainfake.NewSimpleClientset(append(trainObjects, train)...), clientK8s, nil, 0)
+
+	reconcileOnce(t, exe, "wangxx", "notebook")
+	// apiserver 填充的默认值不触发更新
+	clientK8s.ClearActions()
+	reconcileOnce(t, exe, "wangxx", "notebook")
+	expectNoUpdate(t, clientK8s, "deployments")
+
+	// 渲染结果变化时更新
+	train, err := exe.clientTrain.DecisionV1().Traincrds("wangxx").Get("notebook", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	train.Spec.Cpu = "2"
+	if _, err := exe.clientTrain.DecisionV1().Traincrds("wangxx").Update(train); err != nil {
+		t.Fatal(err)
+	}
+	reconcileOnce(t, exe, "wangxx", "notebook")
+	dep, err := clientK8s.AppsV1().Deployments("wangxx").Get("notebook", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cpu := dep.Spec.Template.Spec.Containers[0].Resources.Limits[corev1.ResourceCPU]; cpu.String() != "2" {
+		t.Errorf("expected the Deployment to be updated to 2 cpu, got %s", cpu.String())
+	}
+}
+
+func TestTemplateErrors(t *testing.T) {
+	traindeploy := newTemplateTraindeploy(t, "")
+	for name, reason := range map[string]string{"missing": "TemplateNotFound", "unlabelled": "TemplateNotFound"} {
+		_, err := getWorkspaceTemplate(traindeploy.clientTrain, traindeploy.clientK8s, "default", name)
+		if got, ok := permanentReason(err); !ok || got != reason {
+			t.Errorf("%s: expected permanent error %s, got %v", name, reason, err)
+		}
+	}
+
+	tmpl, err := getWorkspaceTemplate(traindeploy.clientTrain, traindeploy.clientK8s, "default", "broken")
+	if err != nil {
+		t.Fatal(err)
+	}
+	traindeploy.template = tmpl
+	_, err = traindeploy.makeDeploymentSpec()
+	if reason, ok := permanentReason(err); !ok || reason != "InvalidTemplate" || !strings.Contains(err.Error(), "Registry") {
+		t.Errorf("expected InvalidTemplate naming the missing key, got %v", err)
+	}
+}
+
+func TestV1beta2Fields(t *testing.T) {
+	train := &v1.Traincrd{
+		ObjectMeta: metav1.ObjectMeta{Name: "notebook", Namespace: "wangxx", UID: "6c1e4f3a", Labels: map[string]string{"username": "wangxx", "channel": "risk"},
+			Annotations: map[string]string{v1beta2.V1beta2SpecAnnotation: `{"env":[{"name":"HF_HOME","value":"/data/hf"},{"name":"WORK_DIR","value":"/data"}],` +
+				`"ports":[{"name":"tensorboard","containerPort":6006}],"resources":{"limits":{"nvidia.com/gpu":"1"}}}`}},
+		Spec: v1.TraincrdSpec{Image: "jupyter:1.0", Cpu: "1", Memory: "1Gi", ReqCpu: "500m", ReqMemory: "512Mi", Replicas: 1, Capacity: "1Gi"},
+	}
+	clientK8s := k8sfake.NewSimpleClientset()
+	applyDefaults(clientK8s)
+	exe := New(trainfake.NewSimpleClientset(train), clientK8s, nil, 0)
+	reconcileOnce(t, exe, "wangxx", "notebook")
+
+	dep, err := clientK8s.AppsV1().Deployments("wangxx").Get("notebook", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	container := dep.Spec.Template.Spec.Containers[0]
+	env := map[string]string{}
+	for _, e := range container.Env {
+		env[e.Name] = e.Value
+	}
+	if len(container.Env) != 4 || env["HF_HOME"] != "/data/hf" || env["WORK_DIR"] != "/data" {
+		t.Errorf("expected spec.env to be added to the workspace container, got %v", container.Env)
+	}
+	if len(container.Ports) != 2 || container.Ports[1].Name != "tensorboard" || container.Ports[1].ContainerPort != 6006 {
+		t.Errorf("expected the tensorboard port, got %v", container.Ports)
+	}
+	if gpu := container.Resources.Limits["nvidia.com/gpu"]; gpu.String() != "1" {
+		t.Errorf("expected a gpu limit, got %v", container.Resources.Limits)
+	}
+	clientK8s.ClearActions()
+	reconcileOnce(t, exe, "wangxx", "notebook")
+	expectNoUpdate(t, clientK8s, "deployments")
+
+	// 与工作区端口冲突
+	train.Annotations[v1beta2.V1beta2SpecAnnotation] = `{"ports":[{"name":"lab","containerPort":8888}]}`
+	traindeploy := traindeployBuild(train, config.Default())
+	if _, err := traindeploy.makeDeploymentSpec(); classify(err) != errPermanent || !strings.Contains(err.Error(), "spec.ports[0]: port 8888") {
+		t.Errorf("expected a permanent error for a port used by the workspace, got %v", err)
+	}
+}
@@ -2,6 +2,10 @@ package executor
 
 import (
 	v1 "finupgroup.com/decision/traincrd/pkg/apis/v1"
+	"finupgroup.com/decision/traincrd/pkg/apis/v1beta2"
+	clientsetT "finupgroup.com/decision/traincrd/pkg/client/clientset/versioned"
+	"finupgroup.com/decision/traincrd/pkg/config"
+	"encoding/json"
 	"fmt"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -14,11 +18,9 @@ import (
 	"k8s.io/klog"
 )
 
-const INGRESS_HOST = "mt.10.10.184.25.nip.io"
-const INGRESS_HOST_PROD = "train-lab.finupgroup.com"
-const PUBLIC_STORAGE = "trainlabpublicstorage"
-const PUBLIC_LIBS_STORAGE = "trainlabpublic-libs-storage"
-const PUBLIC_LIBS_VOLUME = "/usr/crd/lib/"
+// KEEP_PVC_ANNOTATION 设置为 "true" 时 PVC 不挂 OwnerReference，删除 Traincrd 后保留用户数据，
+// 等同于 spec.retainPolicy: Retain
+const KEEP_PVC_ANNOTATION = "decision.finupgroup.com/keep-pvc"
 
 type Traindeploy struct {
 	name      string
@@ -33,13 +35,54 @@ type Traindeploy struct {
 	workDir   string
 	image     string
 	capacity  string
+	keepPVC   bool
+	policy    v1.TraincrdRetainPolicy
+	uid       string
+	ownerRef  *metav1.OwnerReference
 	clientK8s kubernetes.Interface
+	cfg       *config.Config
+
+	// templateName 为 spec.template，reconcile 时解析为 template
+	templateName string
+	template     *workspaceTemplate
+	clientTrain  clientsetT.Interface
+
+	// suspended 时 Deployment 缩为 0，Service 指向 ingress.suspendedService；
+	// 除 spec.suspended 外，过期或处于 spec.schedule 之外时同样暂停，原因记录在 Suspended condition 中
+	suspended      bool
+	suspendReason  string
+	suspendMessage string
+	lifecycle      lifecycle
+
+	// resizeErr 记录无法扩容 PVC 的原因，只体现在 StorageResized condition 中，不影响其余子资源
+	resizeErr error
+
+	// restoreFrom 与 cloneFrom 为 spec.restoreFrom 与 spec.cloneFrom，只在创建 PVC 时使用
+	restoreFrom string
+	cloneFrom   *v1.TraincrdCloneSource
+	// cloning 在复制 Job 完成前为 true，Deployment 保持 0 个副本
+	cloning bool
+	// cleaningClone 在删除时清理未完成的复制期间为 true
+	cleaningClone bool
+	// cloneErr 记录复制 Job 失败的原因，体现在 Cloned condition 中
+	cloneErr error
+
+	// volumes 为 spec.volumes，挂载在工作区容器中
+	volumes []v1.TraincrdVolume
+
+	// storageClass 为 spec.storage.class，未填写时使用 channel 或平台默认的存储类
+	storageClass string
+
+	// extra 为 v1beta2 中 v1 没有对应字段的 spec.env、spec.ports 与 cpu、memory 之外的 spec.resources，
+	// 保存在 V1beta2SpecAnnotation 中；extraErr 为该注解无法解析的原因
+	extra    v1beta2.ExtraFields
+	extraErr error
 }
 
 /**
 通过 CRD  类型 Traincrd 构建 traindeploy 配置
 */
-func traindeployBuild(obj *v1.Traincrd) *Traindeploy {
+func traindeployBuild(obj *v1.Traincrd, cfg *config.Config) *Traindeploy {
 	t := &Traindeploy{
 		name:      obj.Name,
 		namespace: obj.Namespace,
@@ -52,66 +95,49 @@ func traindeployBuild(obj *v1.Traincrd) *Traindeploy {
 		reqMemory: obj.Spec.ReqMemory,
 		replicas:  obj.Spec.Replicas,
 		capacity:  obj.Spec.Capacity,
+		policy:    obj.Spec.RetainPolicy,
+		uid:       string(obj.UID),
+		ownerRef:  metav1.NewControllerRef(obj, v1.SchemeGroupVersion.WithKind("Traincrd")),
+		cfg:       cfg,
+
+		templateName: obj.Spec.Template,
+		suspended:    obj.Spec.Suspended,
+		restoreFrom:  obj.Spec.RestoreFrom,
+		volumes:      obj.Spec.Volumes,
 	}
-	t.workDir = fmt.Sprintf("/%s/%s/%s/", t.channel, t.username, t.name)
-
-	return t
-}
-
-func (t *Traindeploy) trainCreate() error {
-	klog.Infoln("创建 Deployment, ", t.name)
-	_, err := t.createOrGetDeployment()
-	if err != nil {
-		return err
+	if obj.Spec.Storage != nil {
+		t.storageClass = obj.Spec.Storage.Class
 	}
-
-	klog.Infoln("创建 Service, ", t.name)
-	_, err = t.createOrGetSvc()
-	if err != nil {
-		return err
-	}
-
-	klog.Infoln("创建 Ingress, ", t.name)
-	_, err = t.createOrGetIngress()
-	if err != nil {
-		return err
-	}
-
-	klog.Infoln("创建 PVC, ", t.name)
-	_, err = t.createOrGetPersistentVolumeClaim()
-	if err != nil {
-		return err
-	}
-
-	return err
-}
-
-func (t *Traindeploy) deleteTrain() (err error) {
-	klog.Infoln("删除 Deployment, ", t.name)
-	err = t.deleteDeployment()
-	if errors.IsNotFound(err) {
-		return
+	if extra, err := v1beta2.ExtraFieldsOf(obj); err != nil {
+		t.extraErr = permanent("InvalidAnnotation", err)
+	} else {
+		t.extra = extra
 	}
-
-	klog.Infoln("删除 Service, ", t.name)
-	err = t.deleteSvc()
-	if errors.IsNotFound(err) {
-		return err
+	switch t.policy {
+	case "":
+		t.policy = v1.RetainPolicyDelete
+		if obj.Annotations[KEEP_PVC_ANNOTATION] == "true" {
+			t.policy = v1.RetainPolicyRetain
+		}
+	case v1.RetainPolicyDelete, v1.RetainPolicyRetain, v1.RetainPolicyArchive:
+	default:
+		// 绕过 webhook 写入的未知 retainPolicy 按 Retain 处理，不能因此删除用户数据
+		klog.Warningf("未知的 retainPolicy %q，按 Retain 处理 %s/%s", t.policy, obj.Namespace, obj.Name)
+		t.policy = v1.RetainPolicyRetain
 	}
-
-	klog.Infoln("删除 Ingress, ", t.name)
-	err = t.deleteIngress()
-	if errors.IsNotFound(err) {
-		return err
+	t.keepPVC = t.policy == v1.RetainPolicyRetain
+	if obj.Spec.CloneFrom != nil {
+		t.cloneFrom = obj.Spec.CloneFrom.DeepCopy()
+		if t.cloneFrom.Namespace == "" {
+			t.cloneFrom.Namespace = obj.Namespace
+		}
 	}
-
-	klog.Infoln("删除 PVC, ", t.name)
-	err = t.deletePVC()
-	if errors.IsNotFound(err) {
-		return err
+	if t.suspended {
+		t.suspendReason, t.suspendMessage = "Suspended", "spec.suspended is set"
 	}
+	t.workDir = fmt.Sprintf("/%s/%s/%s/", t.channel, t.username, t.name)
 
-	return
+	return t
 }
 
 /**
@@ -124,7 +150,7 @@ func (t *Traindeploy) createOrGetDeployment() (*appsv1.Deployment, error) {
 
 	//err is nil, exist
 	if err == nil {
-		klog.Infof("添加时发现已存在 deployment 不做任何操作，可能是restart 后 reload， ", t.toString())
+		klog.V(4).Infof("添加时发现已存在 deployment 不做任何操作，可能是restart 后 reload， %s", t.toString())
 		return existingDep, nil
 	}
 
@@ -142,154 +168,145 @@ func (t *Traindeploy) createOrGetDeployment() (*appsv1.Deployment, error) {
 	return nil, err
 }
 
-func (t *Traindeploy) updateOrGetDeployment(newTrain *Traindeploy) (*appsv1.Deployment, error) {
-
-	_, err := t.clientK8s.AppsV1().Deployments(t.namespace).Get(t.name, metav1.GetOptions{})
-	if errors.IsNotFound(err) {
-		return newTrain.createOrGetDeployment()
-	}
-	if err != nil {
-		return nil, err
-	}
-
-	dep, err := newTrain.makeDeploymentSpec()
-	if err != nil {
-		return nil, err
-	}
-	updatedDep, err := t.clientK8s.AppsV1().Deployments(t.namespace).Update(dep)
-
-	return updatedDep, err
-}
-
-func (t *Traindeploy) deleteDeployment() error {
-	_, err := t.clientK8s.AppsV1().Deployments(t.namespace).Get(t.name, metav1.GetOptions{})
-	if err != nil {
-		return err
-	} else {
-		err := t.clientK8s.AppsV1().Deployments(t.namespace).Delete(t.name, &metav1.DeleteOptions{})
-		return err
-	}
-}
-
 func (t *Traindeploy) makeDeploymentSpec() (*appsv1.Deployment, error) {
 
 	deployLabels := map[string]string{"app": t.name, "username": t.username, "channel": t.channel}
 
-	gracePeriodSeconds := int64(1 * 60) //优雅关闭等待时长
+	// 暂停时只缩容，spec.replicas 不变，恢复后按原来的副本数启动；复制 Job 完成前同样不启动
 	replicas := int32(t.replicas)
+	if t.suspended || t.cloning {
+		replicas = 0
+	}
 
-	resources, err := getContainerResources(t)
+	template, err := t.makePodTemplate(deployLabels)
 	if err != nil {
-		klog.Errorln("生成 Resources 出现异常，", err)
+		klog.Errorln("生成 Pod 模板出现异常，", err)
 		return nil, err
 	}
 
 	deployment := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:   t.name,
-			Labels: deployLabels,
+			Name:            t.name,
+			Labels:          deployLabels,
+			OwnerReferences: t.ownerReferences(),
 		},
 		Spec: appsv1.DeploymentSpec{
 			Replicas: &replicas,
 			Selector: &metav1.LabelSelector{
 				MatchLabels: deployLabels,
 			},
-			Template: corev1.PodTemplateSpec{
-				ObjectMeta: metav1.ObjectMeta{
-					Labels: deployLabels,
-				},
-				Spec: corev1.PodSpec{
-					Containers: []corev1.Container{
+			Template: template,
+			Strategy: appsv1.DeploymentStrategy{Type: t.deploymentStrategy()},
+		},
+	}
+
+	return deployment, nil
+}
+
+/**
+内置的 Jupyter 工作区，也是 StrategicMerge 模板的合并基础
+*/
+func (t *Traindeploy) basePodTemplate() (corev1.PodTemplateSpec, error) {
+	workspace := t.cfg.Workspace
+	gracePeriodSeconds := workspace.TerminationGracePeriodSeconds //优雅关闭等待时长
+	automountToken := false                                       //工作区不需要访问 apiserver
+
+	resources, err := getContainerResources(t)
+	if err != nil {
+		return corev1.PodTemplateSpec{}, err
+	}
+
+	return corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name:            t.name,
+					Image:           t.image,
+					ImagePullPolicy: workspace.ImagePullPolicy,
+					Resources:       resources,
+					Env: []corev1.EnvVar{
+						{Name: "NAME", Value: t.name},
+						{Name: "BASE_DIR", Value: t.name},
+						{Name: "WORK_DIR", Value: t.workDir},
+					},
+					VolumeMounts: []corev1.VolumeMount{
 						{
-							Name:            t.name,
-							Image:           t.image,
-							ImagePullPolicy: corev1.PullAlways,
-							Resources:       resources,
-							Env: []corev1.EnvVar{
-								{Name: "NAME", Value: t.name},
-								{Name: "BASE_DIR", Value: t.name},
-								{Name: "WORK_DIR", Value: t.workDir},
-							},
-							VolumeMounts: []corev1.VolumeMount{
-								{
-									Name:      t.name,
-									MountPath: fmt.Sprintf("/%s/%s/%s/", t.channel, t.username, t.name),
-								},
-								{
-									Name:      PUBLIC_STORAGE,
-									MountPath: "/public",
-								},
-								{
-									Name:      PUBLIC_LIBS_STORAGE,
-									MountPath: PUBLIC_LIBS_VOLUME,
-								},
-							},
-							Ports: []corev1.ContainerPort{
-								{
-									Name:          "http-env",
-									ContainerPort: int32(8888),
-								},
-							},
+							Name:      t.name,
+							MountPath: fmt.Sprintf("/%s/%s/%s/", t.channel, t.username, t.name),
 						},
-					},
-					ServiceAccountName: "fission-svc",
-					Volumes: []corev1.Volume{
 						{
-							Name: t.name,
-							VolumeSource: corev1.VolumeSource{
-								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
-									ClaimName: t.name,
-								},
-							},
+							Name:      workspace.PublicStorage.ClaimName,
+							MountPath: workspace.PublicStorage.MountPath,
 						},
 						{
-							Name: PUBLIC_STORAGE,
-							VolumeSource: corev1.VolumeSource{
-								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
-									ClaimName: PUBLIC_STORAGE,
-								},
-							},
+							Name:      workspace.PublicLibsStorage.ClaimName,
+							MountPath: workspace.PublicLibsStorage.MountPath,
 						},
+					},
+					Ports: []corev1.ContainerPort{
 						{
-							Name: PUBLIC_LIBS_STORAGE,
-							VolumeSource: corev1.VolumeSource{
-								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
-									ClaimName: PUBLIC_LIBS_STORAGE,
-								},
-							},
+							Name:          "http-env",
+							ContainerPort: workspace.Port,
+							Protocol:      corev1.ProtocolTCP,
+						},
+					},
+				},
+			},
+			ServiceAccountName:           workspace.ServiceAccountName,
+			AutomountServiceAccountToken: &automountToken,
+			Volumes: []corev1.Volume{
+				{
+					Name: t.name,
+					VolumeSource: corev1.VolumeSource{
+						PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+							ClaimName: t.name,
+						},
+					},
+				},
+				{
+					Name: workspace.PublicStorage.ClaimName,
+					VolumeSource: corev1.VolumeSource{
+						PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+							ClaimName: workspace.PublicStorage.ClaimName,
+						},
+					},
+				},
+				{
+					Name: workspace.PublicLibsStorage.ClaimName,
+					VolumeSource: corev1.VolumeSource{
+						PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+							ClaimName: workspace.PublicLibsStorage.ClaimName,
 						},
 					},
-					TerminationGracePeriodSeconds: &gracePeriodSeconds,
 				},
 			},
+			TerminationGracePeriodSeconds: &gracePeriodSeconds,
 		},
-	}
-
-	return deployment, nil
+	}, nil
 }
 
 func getContainerResources(t *Traindeploy) (corev1.ResourceRequirements, error) {
 	mincpu, err := resource.ParseQuantity(t.reqCpu)
 	if err != nil {
-		return corev1.ResourceRequirements{}, err
+		return corev1.ResourceRequirements{}, permanent("InvalidResources", fmt.Errorf("spec.reqcpu %q: %v", t.reqCpu, err))
 	}
 
 	minmem, err := resource.ParseQuantity(t.reqMemory)
 	if err != nil {
-		return corev1.ResourceRequirements{}, err
+		return corev1.ResourceRequirements{}, permanent("InvalidResources", fmt.Errorf("spec.reqmemory %q: %v", t.reqMemory, err))
 	}
 
 	maxcpu, err := resource.ParseQuantity(t.cpu)
 	if err != nil {
-		return corev1.ResourceRequirements{}, err
+		return corev1.ResourceRequirements{}, permanent("InvalidResources", fmt.Errorf("spec.cpu %q: %v", t.cpu, err))
 	}
 
 	maxmem, err := resource.ParseQuantity(t.memory)
 	if err != nil {
-		return corev1.ResourceRequirements{}, err
+		return corev1.ResourceRequirements{}, permanent("InvalidResources", fmt.Errorf("spec.memory %q: %v", t.memory, err))
 	}
 
-	return corev1.ResourceRequirements{
+	resources := corev1.ResourceRequirements{
 		Requests: map[corev1.ResourceName]resource.Quantity{
 			corev1.ResourceCPU:    mincpu,
 			corev1.ResourceMemory: minmem,
@@ -298,7 +315,52 @@ func getContainerResources(t *Traindeploy) (corev1.ResourceRequirements, error)
 			corev1.ResourceCPU:    maxcpu,
 			corev1.ResourceMemory: maxmem,
 		},
-	}, nil
+	}
+	// v1beta2 的 spec.resources 中 cpu、memory 之外的资源，例如 nvidia.com/gpu
+	for name, q := range t.extra.Resources.Requests {
+		resources.Requests[name] = q
+	}
+	for name, q := range t.extra.Resources.Limits {
+		resources.Limits[name] = q
+	}
+	return resources, nil
+}
+
+/**
+addExtraFields 把 v1beta2 的 spec.env 与 spec.ports 加入工作区容器，对所有模板都生效；
+与工作区同名的环境变量以 spec.env 为准，并补全 apiserver 的默认值，避免每次 reconcile 都更新 Deployment
+*/
+func (t *Traindeploy) addExtraFields(pod *corev1.PodSpec) error {
+	container := &pod.Containers[0]
+	for _, env := range t.extra.Env {
+		env = *env.DeepCopy()
+		if env.ValueFrom != nil && env.ValueFrom.FieldRef != nil && env.ValueFrom.FieldRef.APIVersion == "" {
+			env.ValueFrom.FieldRef.APIVersion = "v1"
+		}
+		replaced := false
+		for i := range container.Env {
+			if container.Env[i].Name == env.Name {
+				container.Env[i], replaced = env, true
+			}
+		}
+		if !replaced {
+			container.Env = append(container.Env, env)
+		}
+	}
+
+	for i, port := range t.extra.Ports {
+		if port.Protocol == "" {
+			port.Protocol = corev1.ProtocolTCP
+		}
+		for _, existing := range container.Ports {
+			if existing.ContainerPort == port.ContainerPort && existing.Protocol == port.Protocol ||
+				port.Name != "" && existing.Name == port.Name {
+				return permanent("InvalidPorts", fmt.Errorf("spec.ports[%d]: port %d (%s) is already used by the workspace", i, port.ContainerPort, port.Name))
+			}
+		}
+		container.Ports = append(container.Ports, port)
+	}
+	return nil
 }
 
 /**
@@ -307,49 +369,61 @@ Traincrd Service CRUDs
 
 func (t *Traindeploy) createOrGetSvc() (*corev1.Service, error) {
 	existingSvc, err := t.clientK8s.CoreV1().Services(t.namespace).Get(t.name, metav1.GetOptions{})
-	deployLabels := map[string]string{"app": t.name, "username": t.username, "channel": t.channel}
-
 	if err == nil {
 		return existingSvc, err
 	} else if errors.IsNotFound(err) {
-		service := &corev1.Service{
+		return t.clientK8s.CoreV1().Services(t.namespace).Create(t.makeServiceSpec())
+	}
+
+	return nil, err
+}
+
+func (t *Traindeploy) makeServiceSpec() *corev1.Service {
+	deployLabels := map[string]string{"app": t.name, "username": t.username, "channel": t.channel}
+
+	if t.suspended && t.cfg.Ingress.SuspendedService != "" {
+		// Ingress 不变，经 ExternalName 转发到展示“工作区已暂停”页面的服务
+		return &corev1.Service{
 			ObjectMeta: metav1.ObjectMeta{
-				Name:   t.name,
-				Labels: deployLabels,
+				Name:            t.name,
+				Labels:          deployLabels,
+				OwnerReferences: t.ownerReferences(),
 			},
 			Spec: corev1.ServiceSpec{
 				Ports: []corev1.ServicePort{
 					{
-						Name:       "http",
-						Port:       8888,
-						TargetPort: intstr.FromInt(8888),
+						Name:     "http",
+						Protocol: corev1.ProtocolTCP,
+						Port:     t.cfg.Workspace.Port,
+						// ExternalName 不使用 targetPort，与 apiserver 填充的默认值一致，避免每次 reconcile 都更新 Service
+						TargetPort: intstr.FromInt(int(t.cfg.Workspace.Port)),
 					},
 				},
-				Selector: deployLabels,
-				Type:     corev1.ServiceTypeClusterIP,
+				Type:         corev1.ServiceTypeExternalName,
+				ExternalName: t.cfg.Ingress.SuspendedService,
 			},
 		}
-
-		svc, err := t.clientK8s.CoreV1().Services(t.namespace).Create(service)
-		if err != nil {
-			return nil, err
-		}
-		return svc, nil
 	}
 
-	return nil, err
-}
-
-func (t *Traindeploy) deleteSvc() error {
-
-	_, err := t.clientK8s.CoreV1().Services(t.namespace).Get(t.name, metav1.GetOptions{})
-	if err != nil {
-		return err
-	} else {
-		err := t.clientK8s.CoreV1().Services(t.namespace).Delete(t.name, &metav1.DeleteOptions{})
-		return err
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            t.name,
+			Labels:          deployLabels,
+			OwnerReferences: t.ownerReferences(),
+		},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "http",
+					Protocol:   corev1.ProtocolTCP,
+					Port:       t.cfg.Workspace.Port,
+					TargetPort: intstr.FromInt(int(t.port())),
+				},
+			},
+			Selector: deployLabels,
+			Type:     corev1.ServiceTypeClusterIP,
+		},
 	}
-
 }
 
 /**
@@ -358,30 +432,37 @@ Traincrd Ingress CRUDs
 
 func (t *Traindeploy) createOrGetIngress() (*v1beta1.Ingress, error) {
 	existingIngs, err := t.clientK8s.ExtensionsV1beta1().Ingresses(t.namespace).Get(t.name, metav1.GetOptions{})
-	labels := map[string]string{}
 	if err == nil {
 		return existingIngs, err
 	} else if errors.IsNotFound(err) {
-		ingress := &v1beta1.Ingress{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:   t.name,
-				Labels: labels,
-			},
-			Spec: v1beta1.IngressSpec{
-				Rules: []v1beta1.IngressRule{
-					{
-						Host: INGRESS_HOST_PROD,
-						IngressRuleValue: v1beta1.IngressRuleValue{
-							HTTP: &v1beta1.HTTPIngressRuleValue{
-								Paths: []v1beta1.HTTPIngressPath{
-									{
-										Path: "/" + t.name,
-										Backend: v1beta1.IngressBackend{
-											ServiceName: t.name,
-											ServicePort: intstr.IntOrString{
-												Type:   intstr.Int,
-												IntVal: 8888,
-											},
+		return t.clientK8s.ExtensionsV1beta1().Ingresses(t.namespace).Create(t.makeIngressSpec())
+	}
+	return nil, err
+}
+
+func (t *Traindeploy) makeIngressSpec() *v1beta1.Ingress {
+	labels := map[string]string{}
+
+	return &v1beta1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            t.name,
+			Labels:          labels,
+			OwnerReferences: t.ownerReferences(),
+		},
+		Spec: v1beta1.IngressSpec{
+			Rules: []v1beta1.IngressRule{
+				{
+					Host: t.cfg.Ingress.Host,
+					IngressRuleValue: v1beta1.IngressRuleValue{
+						HTTP: &v1beta1.HTTPIngressRuleValue{
+							Paths: []v1beta1.HTTPIngressPath{
+								{
+									Path: "/" + t.name,
+									Backend: v1beta1.IngressBackend{
+										ServiceName: t.name,
+										ServicePort: intstr.IntOrString{
+											Type:   intstr.Int,
+											IntVal: t.cfg.Workspace.Port,
 										},
 									},
 								},
@@ -390,21 +471,7 @@ func (t *Traindeploy) createOrGetIngress() (*v1beta1.Ingress, error) {
 					},
 				},
 			},
-		}
-
-		ings, err := t.clientK8s.ExtensionsV1beta1().Ingresses(t.namespace).Create(ingress)
-		return ings, err
-	}
-	return nil, nil
-}
-
-func (t *Traindeploy) deleteIngress() error {
-	_, err := t.clientK8s.ExtensionsV1beta1().Ingresses(t.namespace).Get(t.name, metav1.GetOptions{})
-	if err != nil {
-		return err
-	} else {
-		err := t.clientK8s.ExtensionsV1beta1().Ingresses(t.namespace).Delete(t.name, &metav1.DeleteOptions{})
-		return err
+		},
 	}
 }
 
@@ -412,56 +479,99 @@ func (t *Traindeploy) deleteIngress() error {
 PVC  CRUDs
 */
 func (t *Traindeploy) createOrGetPersistentVolumeClaim() (*corev1.PersistentVolumeClaim, error) {
-	storageClassName := "cephfs"
-	capacity := "1Gi"
+	existingPVC, err := t.clientK8s.CoreV1().PersistentVolumeClaims(t.namespace).Get(t.name, metav1.GetOptions{})
+	if err == nil {
+		return existingPVC, err
+	}
+	if !errors.IsNotFound(err) {
+		return nil, err
+	}
+
+	provider, err := t.provider()
+	if err != nil {
+		return nil, err
+	}
+	persistentVolumeClaim, err := t.makePersistentVolumeClaimSpec(provider)
+	if err != nil {
+		return nil, err
+	}
+	if err := provider.provision(t, persistentVolumeClaim); err != nil {
+		return nil, err
+	}
+	return t.clientK8s.CoreV1().PersistentVolumeClaims(t.namespace).Create(persistentVolumeClaim)
+}
+
+// desiredCapacity 为 spec.capacity，未填写时为 storage.defaultCapacity
+func (t *Traindeploy) desiredCapacity() (resource.Quantity, error) {
+	capacity := t.cfg.Storage.DefaultCapacity
 	if t.capacity != "" {
 		capacity = t.capacity
 	}
-	storageQuantity, _ := resource.ParseQuantity(capacity)
+	storageQuantity, err := resource.ParseQuantity(capacity)
+	if err != nil {
+		return storageQuantity, permanent("InvalidCapacity", fmt.Errorf("spec.capacity %q: %v", capacity, err))
+	}
+	return storageQuantity, nil
+}
 
-	existingPVC, err := t.clientK8s.CoreV1().PersistentVolumeClaims(t.namespace).Get(t.name, metav1.GetOptions{})
-	if err == nil {
-		return existingPVC, err
+func (t *Traindeploy) makePersistentVolumeClaimSpec(provider storageProvider) (*corev1.PersistentVolumeClaim, error) {
+	storageQuantity, err := t.desiredCapacity()
+	if err != nil {
+		return nil, err
+	}
+	source, err := t.volumeSource(provider)
+	if err != nil {
+		return nil, err
 	}
 
-	pvcAnn := map[string]string{
-		"volume.beta.kubernetes.io/storage-class":       "cephfs",
-		"volume.beta.kubernetes.io/storage-provisioner": "ceph.com/cephfs",
+	pvcAnn := map[string]string{}
+	var dataSource *corev1.TypedLocalObjectReference
+	if source != nil {
+		// 从快照恢复或复制时容量不能小于源卷
+		if source.size != nil && source.size.Cmp(storageQuantity) > 0 {
+			storageQuantity = *source.size
+		}
+		dataSource = source.dataSource
+		data, err := json.Marshal(source.lineage)
+		if err != nil {
+			return nil, err
+		}
+		pvcAnn[LINEAGE_ANNOTATION] = string(data)
+	}
+	var ownerReferences []metav1.OwnerReference
+	if !t.keepPVC {
+		ownerReferences = t.ownerReferences()
 	}
-	persistentVolumeClaim := &corev1.PersistentVolumeClaim{
+	pvc := &corev1.PersistentVolumeClaim{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:        t.name,
-			Annotations: pvcAnn,
+			Name:            t.name,
+			Annotations:     pvcAnn,
+			OwnerReferences: ownerReferences,
 		},
 		Spec: corev1.PersistentVolumeClaimSpec{
-			AccessModes: []corev1.PersistentVolumeAccessMode{
-				// can be mounted in read/write mode to exactly 1 host
-				corev1.ReadWriteMany,
-			},
-			StorageClassName: &storageClassName,
 			Resources: corev1.ResourceRequirements{
 				Requests: corev1.ResourceList{
 					corev1.ResourceStorage: storageQuantity,
 				},
 			},
+			DataSource: dataSource,
 		},
 	}
-	pvc, err := t.clientK8s.CoreV1().PersistentVolumeClaims(t.namespace).Create(persistentVolumeClaim)
-	return pvc, err
+	// 存储类、访问模式与注解由存储实现决定
+	provider.configure(t, pvc)
+	return pvc, nil
 }
 
-func (t *Traindeploy) deletePVC() (err error) {
-	_, err = t.clientK8s.CoreV1().PersistentVolumeClaims(t.namespace).Get(t.name, metav1.GetOptions{})
-	if err != nil {
-		return
+// ownerReferences 子资源的 controller OwnerReference 指向 Traincrd，删除时由垃圾回收级联清理
+func (t *Traindeploy) ownerReferences() []metav1.OwnerReference {
+	if t.ownerRef == nil {
+		return nil
 	}
-
-	err = t.clientK8s.CoreV1().PersistentVolumeClaims(t.namespace).Delete(t.name, &metav1.DeleteOptions{})
-	return
+	return []metav1.OwnerReference{*t.ownerRef}
 }
 
 func (t *Traindeploy) toString() string {
 	return fmt.Sprintf(
-		" name:%s, username:%s, channel:s%, ns: %s, image:%s, cpu:%s, reqcpu:%s, mem:%s, reqmem:%s, replicas:%s, ",
+		" name:%s, username:%s, channel:%s, ns: %s, image:%s, cpu:%s, reqcpu:%s, mem:%s, reqmem:%s, replicas:%d, ",
 		t.name, t.username, t.channel, t.namespace, t.image, t.cpu, t.reqCpu, t.memory, t.reqMemory, t.replicas)
 }
@@ -0,0 +1,101 @@
+package executor
+
+import (
+	"fmt"
+	"path"
+
+	v1 "finupgroup.com/decision/traincrd/pkg/apis/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// 与 apiserver 填充的默认值一致，否则 deploymentInSync 每次都认为 Deployment 与期望不一致
+const (
+	defaultVolumeMode          = int32(0644)
+	defaultTokenExpirationSecs = int64(3600)
+	defaultDownwardAPIVersion  = "v1"
+)
+
+/**
+addVolumes 把 spec.volumes 挂载到工作区容器，即 Pod 的第一个容器；
+PVC 只能是 workspace.sharedClaims 中该 channel 允许的共享存储，名称与挂载路径不能与已有的卷重复
+*/
+func (t *Traindeploy) addVolumes(pod *corev1.PodSpec) error {
+	if len(t.volumes) == 0 {
+		return nil
+	}
+	container := &pod.Containers[0]
+	names, mountPaths := map[string]bool{}, map[string]bool{}
+	for _, volume := range pod.Volumes {
+		names[volume.Name] = true
+	}
+	for _, mount := range container.VolumeMounts {
+		mountPaths[path.Clean(mount.MountPath)] = true
+	}
+
+	for i, volume := range t.volumes {
+		fldPath := fmt.Sprintf("spec.volumes[%d]", i)
+		if names[volume.Name] {
+			return permanent("InvalidVolumes", fmt.Errorf("%s.name %q: already used by the workspace", fldPath, volume.Name))
+		}
+		if mountPaths[path.Clean(volume.MountPath)] {
+			return permanent("InvalidVolumes", fmt.Errorf("%s.mountPath %q: already used by the workspace", fldPath, volume.MountPath))
+		}
+		names[volume.Name], mountPaths[path.Clean(volume.MountPath)] = true, true
+
+		if claim := volume.PersistentVolumeClaim; claim != nil && !t.cfg.Workspace.SharedClaimAllowed(t.channel, claim.ClaimName) {
+			return permanent("VolumeNotAllowed", fmt.Errorf("%s: claim %q is not in workspace.sharedClaims of channel %q", fldPath, claim.ClaimName, t.channel))
+		}
+		source, err := volumeSourceOf(volume)
+		if err != nil {
+			return permanent("InvalidVolumes", fmt.Errorf("%s: %v", fldPath, err))
+		}
+		pod.Volumes = append(pod.Volumes, corev1.Volume{Name: volume.Name, VolumeSource: source})
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      volume.Name,
+			MountPath: volume.MountPath,
+			ReadOnly:  volume.ReadOnly,
+		})
+	}
+	return nil
+}
+
+// volumeSourceOf 转换为 Pod 的 VolumeSource，并补全 apiserver 的默认值
+func volumeSourceOf(volume v1.TraincrdVolume) (corev1.VolumeSource, error) {
+	volume = *volume.DeepCopy()
+	mode := defaultVolumeMode
+	switch {
+	case volume.PersistentVolumeClaim != nil:
+		return corev1.VolumeSource{PersistentVolumeClaim: volume.PersistentVolumeClaim}, nil
+	case volume.ConfigMap != nil:
+		if volume.ConfigMap.DefaultMode == nil {
+			volume.ConfigMap.DefaultMode = &mode
+		}
+		return corev1.VolumeSource{ConfigMap: volume.ConfigMap}, nil
+	case volume.Secret != nil:
+		if volume.Secret.DefaultMode == nil {
+			volume.Secret.DefaultMode = &mode
+		}
+		return corev1.VolumeSource{Secret: volume.Secret}, nil
+	case volume.EmptyDir != nil:
+		return corev1.VolumeSource{EmptyDir: volume.EmptyDir}, nil
+	case volume.Projected != nil:
+		if volume.Projected.DefaultMode == nil {
+			volume.Projected.DefaultMode = &mode
+		}
+		for _, source := range volume.Projected.Sources {
+			if token := source.ServiceAccountToken; token != nil && token.ExpirationSeconds == nil {
+				expiration := defaultTokenExpirationSecs
+				token.ExpirationSeconds = &expiration
+			}
+			if downward := source.DownwardAPI; downward != nil {
+				for i := range downward.Items {
+					if ref := downward.Items[i].FieldRef; ref != nil && ref.APIVersion == "" {
+						ref.APIVersion = defaultDownwardAPIVersion
+					}
+				}
+			}
+		}
+		return corev1.VolumeSource{Projected: volume.Projected}, nil
+	}
+	return corev1.VolumeSource{}, fmt.Errorf("volume %q has no source", volume.Name)
+}
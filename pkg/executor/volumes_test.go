@@ -0,0 +1,85 @@
+package executor
+
+import (
+	"testing"
+
+	v1 "finupgroup.com/decision/traincrd/pkg/apis/v1"
+	trainfake "finupgroup.com/decision/traincrd/pkg/client/clientset/versioned/fake"
+	"finupgroup.com/decision/traincrd/pkg/config"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestAdditionalVolumes(t *testing.T) {
+	sizeLimit := resource.MustParse("10Gi")
+	train := &v1.Traincrd{
+		ObjectMeta: metav1.ObjectMeta{Name: "notebook", Namespace: "wangxx", UID: "6c1e4f3a", Labels: map[string]string{"username": "wangxx", "channel": "risk"}},
+		Spec: v1.TraincrdSpec{
+			Image: "jupyter:1.0", Cpu: "1", Memory: "1Gi", ReqCpu: "500m", ReqMemory: "512Mi", Replicas: 1, Capacity: "1Gi",
+			Volumes: []v1.TraincrdVolume{
+				{Name: "datasets", MountPath: "/datasets", ReadOnly: true, PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "risk-datasets"}},
+				{Name: "pip", MountPath: "/etc/pip", ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: "pip-conf"}}},
+				{Name: "scratch", MountPath: "/scratch", EmptyDir: &corev1.EmptyDirVolumeSource{SizeLimit: &sizeLimit}},
+			},
+		},
+	}
+	clientK8s := k8sfake.NewSimpleClientset()
+	exe := New(trainfake.NewSimpleClientset(train), clientK8s, nil, 0)
+	cfg := config.Default()
+	cfg.Workspace.SharedClaims = map[string][]string{"risk": {"risk-datasets"}}
+	exe.cfg.Store(cfg)
+
+	reconcileOnce(t, exe, "wangxx", "notebook")
+	dep, err := clientK8s.AppsV1().Deployments("wangxx").Get("notebook", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pod := dep.Spec.Template.Spec
+	if len(pod.Volumes) != 6 || len(pod.Containers[0].VolumeMounts) != 6 {
+		t.Fatalf("expected the 3 volumes after the workspace and public volumes, got %+v", pod.Volumes)
+	}
+	if claim := pod.Volumes[3].PersistentVolumeClaim; claim == nil || claim.ClaimName != "risk-datasets" {
+		t.Errorf("expected the shared claim risk-datasets, got %+v", pod.Volumes[3])
+	}
+	if mount := pod.Containers[0].VolumeMounts[3]; mount.MountPath != "/datasets" || !mount.ReadOnly {
+		t.Errorf("expected /datasets to be mounted read-only, got %+v", mount)
+	}
+	if configMap := pod.Volumes[4].ConfigMap; configMap == nil || configMap.DefaultMode == nil || *configMap.DefaultMode != 0644 {
+		t.Errorf("expected the defaultMode of the apiserver, got %+v", pod.Volumes[4])
+	}
+	if emptyDir := pod.Volumes[5].EmptyDir; emptyDir == nil || emptyDir.SizeLimit.Cmp(sizeLimit) != 0 {
+		t.Errorf("expected a 10Gi emptyDir, got %+v", pod.Volumes[5])
+	}
+
+	// 与期望一致时不再更新 Deployment
+	clientK8s.ClearActions()
+	reconcileOnce(t, exe, "wangxx", "notebook")
+	for _, action := range clientK8s.Actions() {
+		if action.GetVerb() == "update" && action.GetResource().Resource == "deployments" {
+			t.Errorf("unexpected Deployment update")
+		}
+	}
+
+	for _, test := range []struct {
+		name    string
+		channel string
+		volume  v1.TraincrdVolume
+		reason  string
+	}{
+		{"other channel", "qz", train.Spec.Volumes[0], "VolumeNotAllowed"},
+		{"workspace claim", "risk", v1.TraincrdVolume{Name: "peek", MountPath: "/peek", PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "lisi-notebook"}}, "VolumeNotAllowed"},
+		{"public mount path", "risk", v1.TraincrdVolume{Name: "tmp", MountPath: "/public/", EmptyDir: &corev1.EmptyDirVolumeSource{}}, "InvalidVolumes"},
+	} {
+		invalid := train.DeepCopy()
+		invalid.Labels["channel"] = test.channel
+		invalid.Spec.Volumes = []v1.TraincrdVolume{test.volume}
+		if err := exe.informer.GetIndexer().Update(invalid); err != nil {
+			t.Fatal(err)
+		}
+		if reason, _ := permanentReason(exe.Reconcile("wangxx", "notebook")); reason != test.reason {
+			t.Errorf("%s: expected %s", test.name, test.reason)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	v1 "finupgroup.com/decision/traincrd/pkg/apis/v1"
+	"finupgroup.com/decision/traincrd/pkg/apis/v1beta2"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog"
+)
+
+// serveConversion answers a ConversionReview for the Traincrd CRD
+func serveConversion(w http.ResponseWriter, r *http.Request) {
+	if contentType := r.Header.Get("Content-Type"); contentType != "application/json" {
+		http.Error(w, fmt.Sprintf("unsupported content type %q, expect application/json", contentType), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	review := apiextensionsv1.ConversionReview{}
+	if err := json.Unmarshal(body, &review); err != nil || review.Request == nil {
+		http.Error(w, fmt.Sprintf("malformed ConversionReview: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	response := &apiextensionsv1.ConversionResponse{
+		UID:    review.Request.UID,
+		Result: metav1.Status{Status: metav1.StatusSuccess},
+	}
+	for _, obj := range review.Request.Objects {
+		converted, err := convertTraincrd(obj.Raw, review.Request.DesiredAPIVersion)
+		if err != nil {
+			klog.Infof("convert traincrd to %s: %v", review.Request.DesiredAPIVersion, err)
+			response.ConvertedObjects = nil
+			response.Result = metav1.Status{Status: metav1.StatusFailure, Message: err.Error()}
+			break
+		}
+		response.ConvertedObjects = append(response.ConvertedObjects, runtime.RawExtension{Raw: converted})
+	}
+	review.Response = response
+	review.Request = nil
+
+	out, err := json.Marshal(review)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(out)
+}
+
+// convertTraincrd converts one serialized Traincrd to desiredAPIVersion
+func convertTraincrd(raw []byte, desiredAPIVersion string) ([]byte, error) {
+	typeMeta := metav1.TypeMeta{}
+	if err := json.Unmarshal(raw, &typeMeta); err != nil {
+		return nil, err
+	}
+	if typeMeta.APIVersion == desiredAPIVersion {
+		return raw, nil
+	}
+
+	switch {
+	case typeMeta.APIVersion == v1.SchemeGroupVersion.String() && desiredAPIVersion == v1beta2.SchemeGroupVersion.String():
+		in, out := &v1.Traincrd{}, &v1beta2.Traincrd{}
+		if err := json.Unmarshal(raw, in); err != nil {
+			return nil, err
+		}
+		if err := v1beta2.ConvertFromV1(in, out); err != nil {
+			return nil, fmt.Errorf("%s/%s: %v", in.Namespace, in.Name, err)
+		}
+		return json.Marshal(out)
+	case typeMeta.APIVersion == v1beta2.SchemeGroupVersion.String() && desiredAPIVersion == v1.SchemeGroupVersion.String():
+		in, out := &v1beta2.Traincrd{}, &v1.Traincrd{}
+		if err := json.Unmarshal(raw, in); err != nil {
+			return nil, err
+		}
+		if err := v1beta2.ConvertToV1(in, out); err != nil {
+			return nil, fmt.Errorf("%s/%s: %v", in.Namespace, in.Name, err)
+		}
+		return json.Marshal(out)
+	}
+	return nil, fmt.Errorf("unsupported conversion from %s to %s", typeMeta.APIVersion, desiredAPIVersion)
+}
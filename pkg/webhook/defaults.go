@@ -0,0 +1,138 @@
+package webhook
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/yaml"
+)
+
+// ChannelPolicy holds the values filled into a Traincrd that omits them.
+type ChannelPolicy struct {
+	// RequestRatio derives reqcpu/reqmemory from the limits: request = limit × ratio.
+	// 0 leaves it unset: a channel inherits the default, the default requests the limits.
+	RequestRatio float64 `json:"requestRatio,omitempty"`
+	Replicas     *int    `json:"replicas,omitempty"`
+	Capacity     string  `json:"capacity,omitempty"`
+	// Namespaces may create workspaces in the channel, empty allows every namespace. The channel
+	// label decides which shared claims and profiles a workspace may use.
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+// DefaultingPolicy is the default ChannelPolicy plus per-channel overrides, e.g.
+//
+//   default:
+//     requestRatio: 0.5
+//     replicas: 1
+//     capacity: 1Gi
+//   channels:
+//     qz:
+//       capacity: 5Gi
+//     risk:
+//       namespaces: [risk-team]
+type DefaultingPolicy struct {
+	Default  ChannelPolicy            `json:"default"`
+	Channels map[string]ChannelPolicy `json:"channels,omitempty"`
+}
+
+var defaultReplicas = 1
+
+// DefaultDefaultingPolicy is used when no policy file is configured.
+var DefaultDefaultingPolicy = DefaultingPolicy{
+	Default: ChannelPolicy{
+		RequestRatio: 0.5,
+		Replicas:     &defaultReplicas,
+		Capacity:     "1Gi",
+	},
+}
+
+func LoadDefaultingPolicy(path string) (*DefaultingPolicy, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	policy := &DefaultingPolicy{}
+	if err := yaml.UnmarshalStrict(data, policy); err != nil {
+		return nil, fmt.Errorf("parse defaulting policy %s: %v", path, err)
+	}
+	for channel, p := range policy.Channels {
+		if err := p.validate(); err != nil {
+			return nil, fmt.Errorf("channel %s: %v", channel, err)
+		}
+	}
+	if err := policy.Default.validate(); err != nil {
+		return nil, fmt.Errorf("default: %v", err)
+	}
+	return policy, nil
+}
+
+func (p ChannelPolicy) validate() error {
+	if p.RequestRatio < 0 || p.RequestRatio > 1 {
+		return fmt.Errorf("requestRatio %v must be within (0, 1], or 0 to leave it unset", p.RequestRatio)
+	}
+	if p.Replicas != nil && (*p.Replicas < MinReplicas || *p.Replicas > MaxReplicas) {
+		return fmt.Errorf("replicas %d must be between %d and %d", *p.Replicas, MinReplicas, MaxReplicas)
+	}
+	if p.Capacity != "" {
+		if _, err := resource.ParseQuantity(p.Capacity); err != nil {
+			return fmt.Errorf("capacity %q: %v", p.Capacity, err)
+		}
+	}
+	return nil
+}
+
+// ForChannel merges the channel override over the default policy.
+func (p *DefaultingPolicy) ForChannel(channel string) ChannelPolicy {
+	merged := p.Default
+	override, ok := p.Channels[channel]
+	if !ok {
+		return merged
+	}
+	if override.RequestRatio != 0 {
+		merged.RequestRatio = override.RequestRatio
+	}
+	if override.Replicas != nil {
+		merged.Replicas = override.Replicas
+	}
+	if override.Capacity != "" {
+		merged.Capacity = override.Capacity
+	}
+	if override.Namespaces != nil {
+		merged.Namespaces = override.Namespaces
+	}
+	return merged
+}
+
+// validateNamespace checks that namespace may create workspaces in channel.
+func (p *DefaultingPolicy) validateNamespace(channel, namespace string) field.ErrorList {
+	allowed := p.ForChannel(channel).Namespaces
+	if len(allowed) == 0 {
+		return nil
+	}
+	for _, ns := range allowed {
+		if ns == namespace {
+			return nil
+		}
+	}
+	return field.ErrorList{field.Forbidden(field.NewPath("metadata", "labels").Key("channel"),
+		fmt.Sprintf("channel %q is not allowed in namespace %q", channel, namespace))}
+}
+
+// request derives a request from limit, returns "" when the limit is not a valid quantity
+func (p ChannelPolicy) request(limit string, name string) string {
+	q, err := resource.ParseQuantity(limit)
+	if err != nil {
+		return ""
+	}
+	ratio := p.RequestRatio
+	if ratio == 0 {
+		ratio = 1
+	}
+	if name == "cpu" {
+		return resource.NewMilliQuantity(int64(math.Ceil(float64(q.MilliValue())*ratio)), resource.DecimalSI).String()
+	}
+	return resource.NewQuantity(int64(math.Ceil(float64(q.Value())*ratio)), resource.BinarySI).String()
+}
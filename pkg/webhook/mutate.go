@@ -0,0 +1,113 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/klog"
+)
+
+// patchOperation is one RFC 6902 JSON patch operation
+type patchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// rawTraincrd keeps spec as a map so that omitted fields can be told apart from zero values
+type rawTraincrd struct {
+	Metadata struct {
+		Labels map[string]string `json:"labels"`
+	} `json:"metadata"`
+	Spec map[string]interface{} `json:"spec"`
+}
+
+func mutateTraincrd(policy *DefaultingPolicy) admitFunc {
+	return func(req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+		if req.Operation != admissionv1.Create && req.Operation != admissionv1.Update {
+			return &admissionv1.AdmissionResponse{Allowed: true}
+		}
+
+		train := &rawTraincrd{}
+		if err := json.Unmarshal(req.Object.Raw, train); err != nil {
+			return errorResponse(http.StatusBadRequest, metav1.StatusReasonBadRequest, err.Error())
+		}
+
+		create := req.Operation == admissionv1.Create
+		if create && train.Metadata.Labels["username"] == "" && req.UserInfo.Username != "" {
+			// the label names directories of the public storage, a user such as system:serviceaccount:ns:name
+			// or an email address has to set it explicitly
+			if msgs := validation.IsDNS1123Label(req.UserInfo.Username); len(msgs) > 0 {
+				return errorResponse(http.StatusUnprocessableEntity, metav1.StatusReasonInvalid,
+					fmt.Sprintf("metadata.labels[username]: Required value: cannot be derived from user %q (%s), set the label explicitly",
+						req.UserInfo.Username, strings.Join(msgs, "; ")))
+			}
+		}
+
+		patch := defaultTraincrd(train, policy, req.UserInfo.Username, create)
+		if len(patch) == 0 {
+			return &admissionv1.AdmissionResponse{Allowed: true}
+		}
+		data, err := json.Marshal(patch)
+		if err != nil {
+			return errorResponse(http.StatusInternalServerError, metav1.StatusReasonInternalError, err.Error())
+		}
+
+		klog.V(2).Infof("default traincrd %s/%s: %s", req.Namespace, req.Name, data)
+		patchType := admissionv1.PatchTypeJSONPatch
+		return &admissionv1.AdmissionResponse{Allowed: true, Patch: data, PatchType: &patchType}
+	}
+}
+
+// defaultTraincrd returns the patch filling in what the user omitted.
+// replicas is only defaulted on create: 0 is omitted from the body, so a culled or scaled down workspace would be started again.
+// The username label is only set on create too, otherwise the controller's own updates would stamp its service account on it.
+func defaultTraincrd(train *rawTraincrd, policy *DefaultingPolicy, username string, create bool) []patchOperation {
+	var patch []patchOperation
+
+	labels := train.Metadata.Labels
+	if labels["username"] == "" && username != "" && create {
+		if labels == nil {
+			patch = append(patch, patchOperation{Op: "add", Path: "/metadata/labels", Value: map[string]string{"username": username}})
+		} else {
+			patch = append(patch, patchOperation{Op: "add", Path: "/metadata/labels/username", Value: username})
+		}
+	}
+
+	p := policy.ForChannel(labels["channel"])
+	if train.Spec == nil {
+		train.Spec = map[string]interface{}{}
+		patch = append(patch, patchOperation{Op: "add", Path: "/spec", Value: map[string]interface{}{}})
+	}
+	spec := train.Spec
+
+	addString := func(key, value string) {
+		if value == "" {
+			return
+		}
+		if current, _ := spec[key].(string); current == "" {
+			patch = append(patch, patchOperation{Op: "add", Path: "/spec/" + key, Value: value})
+		}
+	}
+
+	// a profile or a clone source provides its own requests and capacity, the controller merges them
+	if profile, _ := spec["profile"].(string); profile == "" && spec["cloneFrom"] == nil {
+		if cpu, ok := spec["cpu"].(string); ok {
+			addString("reqcpu", p.request(cpu, "cpu"))
+		}
+		if memory, ok := spec["memory"].(string); ok {
+			addString("reqmemory", p.request(memory, "memory"))
+		}
+		addString("capacity", p.Capacity)
+	}
+	if _, ok := spec["replicas"]; !ok && create && p.Replicas != nil {
+		patch = append(patch, patchOperation{Op: "add", Path: "/spec/replicas", Value: *p.Replicas})
+	}
+
+	return patch
+}
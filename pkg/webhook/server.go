@@ -0,0 +1,150 @@
+package webhook
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	v1 "finupgroup.com/decision/traincrd/pkg/apis/v1"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/klog"
+)
+
+// admitFunc handles one AdmissionRequest and returns the response without the UID
+type admitFunc func(*admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse
+
+// Server is the HTTPS admission and conversion webhook server for Traincrds.
+type Server struct {
+	addr     string
+	certFile string
+	keyFile  string
+	policy   *DefaultingPolicy
+}
+
+func NewServer(addr, certFile, keyFile string, policy *DefaultingPolicy) *Server {
+	return &Server{addr: addr, certFile: certFile, keyFile: keyFile, policy: policy}
+}
+
+// Handler returns the mux serving every admission path, useful for tests with httptest.
+func Handler(policy *DefaultingPolicy) http.Handler {
+	validate, mutate := validateTraincrd(policy), mutateTraincrd(policy)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/validate", func(w http.ResponseWriter, r *http.Request) {
+		serve(w, r, validate)
+	})
+	mux.HandleFunc("/mutate", func(w http.ResponseWriter, r *http.Request) {
+		serve(w, r, mutate)
+	})
+	mux.HandleFunc("/convert", serveConversion)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	return mux
+}
+
+// Run serves until stopCh is closed.
+func (s *Server) Run(stopCh <-chan struct{}) error {
+	cert, err := tls.LoadX509KeyPair(s.certFile, s.keyFile)
+	if err != nil {
+		return fmt.Errorf("load webhook certificate: %v", err)
+	}
+
+	server := &http.Server{
+		Addr:      s.addr,
+		Handler:   Handler(s.policy),
+		TLSConfig: &tls.Config{Certificates: []tls.Certificate{cert}},
+	}
+	go func() {
+		<-stopCh
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	}()
+
+	klog.Infof("admission webhook listening on %s", s.addr)
+	if err := server.ListenAndServeTLS("", ""); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+func serve(w http.ResponseWriter, r *http.Request, admit admitFunc) {
+	if contentType := r.Header.Get("Content-Type"); contentType != "application/json" {
+		http.Error(w, fmt.Sprintf("unsupported content type %q, expect application/json", contentType), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	review := admissionv1.AdmissionReview{}
+	if err := json.Unmarshal(body, &review); err != nil || review.Request == nil {
+		http.Error(w, fmt.Sprintf("malformed AdmissionReview: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	response := admit(review.Request)
+	response.UID = review.Request.UID
+	review.Response = response
+	review.Request = nil
+
+	out, err := json.Marshal(review)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(out)
+}
+
+func validateTraincrd(policy *DefaultingPolicy) admitFunc {
+	return func(req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+		if req.Operation != admissionv1.Create && req.Operation != admissionv1.Update {
+			return &admissionv1.AdmissionResponse{Allowed: true}
+		}
+
+		train := &v1.Traincrd{}
+		if err := json.Unmarshal(req.Object.Raw, train); err != nil {
+			return errorResponse(http.StatusBadRequest, metav1.StatusReasonBadRequest, err.Error())
+		}
+
+		var errs field.ErrorList
+		if req.Operation == admissionv1.Create {
+			errs = ValidateTraincrd(train)
+			// the channel label is immutable, so this decides which channel the workspace belongs to
+			errs = append(errs, policy.validateNamespace(train.Labels["channel"], req.Namespace)...)
+		} else {
+			old := &v1.Traincrd{}
+			if err := json.Unmarshal(req.OldObject.Raw, old); err != nil {
+				return errorResponse(http.StatusBadRequest, metav1.StatusReasonBadRequest, err.Error())
+			}
+			errs = ValidateTraincrdUpdate(train, old)
+		}
+		if len(errs) > 0 {
+			klog.Infof("reject traincrd %s/%s: %v", req.Namespace, train.Name, errs.ToAggregate())
+			return errorResponse(http.StatusUnprocessableEntity, metav1.StatusReasonInvalid, errs.ToAggregate().Error())
+		}
+		return &admissionv1.AdmissionResponse{Allowed: true}
+	}
+}
+
+func errorResponse(code int32, reason metav1.StatusReason, message string) *admissionv1.AdmissionResponse {
+	return &admissionv1.AdmissionResponse{
+		Allowed: false,
+		Result: &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Code:    code,
+			Reason:  reason,
+			Message: message,
+		},
+	}
+}
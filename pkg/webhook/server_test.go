@@ -0,0 +1,188 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// postFixture sends testdata/<fixture> to path and returns the decoded response
+func postFixture(t *testing.T, path, fixture string) *admissionv1.AdmissionResponse {
+	body, err := ioutil.ReadFile(filepath.Join("testdata", fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	policy := DefaultDefaultingPolicy
+	policy.Channels = map[string]ChannelPolicy{"course": {RequestRatio: 0.25, Capacity: "5Gi", Namespaces: []string{"teaching"}}}
+	server := httptest.NewServer(Handler(&policy))
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+path, "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("%s: unexpected http status %d", fixture, resp.StatusCode)
+	}
+
+	review := admissionv1.AdmissionReview{}
+	if err := json.NewDecoder(resp.Body).Decode(&review); err != nil {
+		t.Fatal(err)
+	}
+	if review.Response == nil {
+		t.Fatalf("%s: no response in review", fixture)
+	}
+	return review.Response
+}
+
+func TestValidateFixtures(t *testing.T) {
+	tests := []struct {
+		fixture string
+		allowed bool
+		message string
+	}{
+		{"validate-valid.json", true, ""},
+		{"validate-empty-reqcpu.json", false, "spec.reqcpu: Required value"},
+		{"validate-request-over-limit.json", false, "spec.reqmemory: Invalid value: \"2Gi\": must be less than or equal to memory limit 1000Mi"},
+		{"validate-bad-quantity.json", false, "spec.cpu: Invalid value: \"three\""},
+		{"validate-replicas-out-of-range.json", false, "spec.replicas: Invalid value: 10: must be between 0 and 5"},
+		{"validate-bad-image.json", false, "spec.image: Invalid value"},
+		{"validate-missing-labels.json", false, "metadata.labels[username]: Required value"},
+		{"validate-bad-name.json", false, "metadata.name: Invalid value: \"1st.workspace\""},
+		{"validate-profile.json", true, ""},
+		{"validate-bad-profile.json", false, "spec.profile: Invalid value: \"GPU_small\""},
+		{"validate-schedule.json", true, ""},
+		{"validate-bad-schedule.json", false, "spec.schedule.start: Invalid value: \"0 9 * * mon-fri\": day of week: \"mon\" is not a number"},
+		{"validate-bad-restore.json", false, "spec.restoreFrom: Invalid value: \"notebook@2019-12-02\""},
+		{"validate-clone.json", true, ""},
+		{"validate-volumes.json", true, ""},
+		{"validate-bad-volumes.json", false, "spec.volumes[0]: Invalid value: \"scratch\": must set exactly one of persistentVolumeClaim, configMap, secret, emptyDir or projected"},
+		{"validate-bad-storage.json", false, "spec.storage.class: Invalid value: \"Local_SSD\""},
+		{"validate-update-deleting.json", true, ""},
+		{"validate-update-deleting-spec.json", false, "spec: Forbidden: may not be changed once the Traincrd is being deleted"},
+		{"validate-v1beta2.json", true, ""},
+		{"validate-v1beta2-ingress.json", false, "spec.ingress: Forbidden: is not supported"},
+		{"validate-update-unchanged.json", true, ""},
+		{"validate-update-changed.json", false, "spec.capacity: Invalid value: \"-1Gi\": must be greater than zero"},
+		{"validate-update-channel.json", false, "metadata.labels[channel]: Invalid value: \"course\": field is immutable"},
+		{"validate-channel-namespace.json", false, "metadata.labels[channel]: Forbidden: channel \"course\" is not allowed in namespace \"default\""},
+	}
+
+	for _, test := range tests {
+		response := postFixture(t, "/validate", test.fixture)
+		if response.Allowed != test.allowed {
+			t.Errorf("%s: expected allowed=%v, got %v (%v)", test.fixture, test.allowed, response.Allowed, response.Result)
+			continue
+		}
+		if test.allowed {
+			continue
+		}
+		if response.Result == nil || !strings.Contains(response.Result.Message, test.message) {
+			t.Errorf("%s: expected message containing %q, got %v", test.fixture, test.message, response.Result)
+		}
+	}
+}
+
+func TestMutateFixtures(t *testing.T) {
+	tests := []struct {
+		fixture string
+		patch   string
+	}{
+		{"mutate-complete.json", ""},
+		{"mutate-omitted.json", `[{"op":"add","path":"/spec/reqcpu","value":"150m"},{"op":"add","path":"/spec/reqmemory","value":"500Mi"},{"op":"add","path":"/spec/capacity","value":"1Gi"},{"op":"add","path":"/spec/replicas","value":1}]`},
+		{"mutate-channel-policy.json", `[{"op":"add","path":"/metadata/labels/username","value":"wangxx"},{"op":"add","path":"/spec/reqcpu","value":"100m"},{"op":"add","path":"/spec/reqmemory","value":"256Mi"},{"op":"add","path":"/spec/capacity","value":"5Gi"}]`},
+		{"mutate-profile.json", `[{"op":"add","path":"/spec/replicas","value":1}]`},
+		{"mutate-scaled-down.json", ""},
+		{"mutate-clone.json", `[{"op":"add","path":"/spec/replicas","value":1}]`},
+		{"mutate-controller-update.json", ""},
+	}
+
+	for _, test := range tests {
+		response := postFixture(t, "/mutate", test.fixture)
+		if !response.Allowed {
+			t.Errorf("%s: expected allowed, got %v", test.fixture, response.Result)
+			continue
+		}
+		if string(response.Patch) != test.patch {
+			t.Errorf("%s: expected patch\n%s\ngot\n%s", test.fixture, test.patch, response.Patch)
+		}
+	}
+
+	// a user name that is not a valid label value is not copied into the username label
+	response := postFixture(t, "/mutate", "mutate-bad-username.json")
+	if response.Allowed || response.Result == nil || !strings.Contains(response.Result.Message, `cannot be derived from user "system:serviceaccount:ci:deployer"`) {
+		t.Errorf("mutate-bad-username.json: expected a denial, got allowed=%v %v", response.Allowed, response.Result)
+	}
+}
+
+func TestImageReference(t *testing.T) {
+	valid := []string{
+		"jupyter",
+		"decision/kodexplorer:4",
+		"10.10.15.51/jupyter/test:1.2.6",
+		"harbor.finupgroup.com:443/decisionoctopus/decisiontrain:1.0.1",
+		"busybox@sha256:7cc4b5aefd1d0cadf8d97d4350462ba51c694ebca145b08d7d41b41acc8db5aa",
+	}
+	invalid := []string{"", "Jupyter", "jupyter:", "jupyter test", "registry/-name:1"}
+
+	for _, image := range valid {
+		if !imageRegexp.MatchString(image) {
+			t.Errorf("expected %q to be a valid image", image)
+		}
+	}
+	for _, image := range invalid {
+		if imageRegexp.MatchString(image) {
+			t.Errorf("expected %q to be an invalid image", image)
+		}
+	}
+}
+
+func TestConvertFixture(t *testing.T) {
+	body, err := ioutil.ReadFile(filepath.Join("testdata", "convert-v1-to-v1beta2.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(Handler(&DefaultDefaultingPolicy))
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/convert", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	review := apiextensionsv1.ConversionReview{}
+	if err := json.NewDecoder(resp.Body).Decode(&review); err != nil {
+		t.Fatal(err)
+	}
+	if review.Response == nil || review.Response.Result.Status != metav1.StatusSuccess {
+		t.Fatalf("expected a successful conversion, got %+v", review.Response)
+	}
+	if review.Response.UID != "705ab4f5-6393-11e8-b7cc-42010a800002" {
+		t.Errorf("unexpected uid %q", review.Response.UID)
+	}
+
+	expected := []string{
+		`{"kind":"Traincrd","apiVersion":"decision.finupgroup.com/v1beta2","metadata":{"name":"notebook","namespace":"wangxx","creationTimestamp":null,"labels":{"username":"wangxx"}},"spec":{"image":"jupyter:1.0","replicas":1,"resources":{"limits":{"cpu":"2","memory":"4Gi"},"requests":{"cpu":"500m","memory":"1Gi"}},"storage":{"capacity":"5Gi"},"ingress":{}},"status":{}}`,
+		`{"apiVersion":"decision.finupgroup.com/v1beta2","kind":"Traincrd","metadata":{"name":"already","namespace":"wangxx"},"spec":{"image":"jupyter:1.0","resources":{"limits":{"cpu":"1"}}}}`,
+	}
+	if len(review.Response.ConvertedObjects) != len(expected) {
+		t.Fatalf("expected %d objects, got %d", len(expected), len(review.Response.ConvertedObjects))
+	}
+	for i, obj := range review.Response.ConvertedObjects {
+		if string(obj.Raw) != expected[i] {
+			t.Errorf("object %d: expected\n%s\ngot\n%s", i, expected[i], obj.Raw)
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package webhook
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	v1 "finupgroup.com/decision/traincrd/pkg/apis/v1"
+	"finupgroup.com/decision/traincrd/pkg/apis/v1beta2"
+	"finupgroup.com/decision/traincrd/pkg/cron"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const (
+	MinReplicas = 0
+	MaxReplicas = 5
+)
+
+// imageRegexp follows the grammar of docker/distribution reference: [domain[:port]/]path[:tag][@digest]
+var imageRegexp = func() *regexp.Regexp {
+	domainComponent := `(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9])`
+	domain := domainComponent + `(?:\.` + domainComponent + `)*(?::[0-9]+)?`
+	pathComponent := `[a-z0-9]+(?:(?:[._]|__|[-]*)[a-z0-9]+)*`
+	name := `(?:` + domain + `/)?` + pathComponent + `(?:/` + pathComponent + `)*`
+	tag := `[\w][\w.-]{0,127}`
+	digest := `[A-Za-z][A-Za-z0-9]*(?:[-_+.][A-Za-z][A-Za-z0-9]*)*:[0-9a-fA-F]{32,}`
+	return regexp.MustCompile(`^` + name + `(?::` + tag + `)?(?:@` + digest + `)?$`)
+}()
+
+// ValidateTraincrd checks everything the executor relies on when it turns a Traincrd into
+// a Deployment, Service, Ingress and PVC.
+func ValidateTraincrd(train *v1.Traincrd) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	metaPath := field.NewPath("metadata")
+	for _, msg := range validation.IsDNS1035Label(train.Name) {
+		allErrs = append(allErrs, field.Invalid(metaPath.Child("name"), train.Name, msg))
+	}
+	allErrs = append(allErrs, validatePathLabel(train.Labels, "username", metaPath.Child("labels"))...)
+	allErrs = append(allErrs, validatePathLabel(train.Labels, "channel", metaPath.Child("labels"))...)
+
+	allErrs = append(allErrs, ValidateTraincrdSpec(&train.Spec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateExtraFields(train)...)
+	return allErrs
+}
+
+// validateExtraFields checks the v1beta2 fields a v1 object keeps in an annotation. env, ports and
+// resources are applied to the workspace container, the ingress is the platform's: a custom host or
+// annotations would let a workspace take over the routes of others.
+func validateExtraFields(train *v1.Traincrd) field.ErrorList {
+	allErrs := field.ErrorList{}
+	extra, err := v1beta2.ExtraFieldsOf(train)
+	if err != nil {
+		annotation := field.NewPath("metadata", "annotations").Key(v1beta2.V1beta2SpecAnnotation)
+		return append(allErrs, field.Invalid(annotation, train.Annotations[v1beta2.V1beta2SpecAnnotation], err.Error()))
+	}
+	if extra.Ingress.Host != "" || extra.Ingress.Path != "" || len(extra.Ingress.Annotations) > 0 {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "ingress"), "is not supported, workspaces are published on the host and path of the controller config"))
+	}
+	return allErrs
+}
+
+// ValidateTraincrdUpdate validates train against the rules for the fields it changes: the errors the
+// old object already had are dropped. The channel label decides which shared claims and profiles a
+// workspace may use, so it cannot change after create, and the spec cannot change once the deletion
+// started: the controller tears the workspace down by it, e.g. by retainPolicy.
+func ValidateTraincrdUpdate(train, old *v1.Traincrd) field.ErrorList {
+	existing := map[string]bool{}
+	for _, err := range ValidateTraincrd(old) {
+		existing[err.Error()] = true
+	}
+	allErrs := field.ErrorList{}
+	for _, err := range ValidateTraincrd(train) {
+		if !existing[err.Error()] {
+			allErrs = append(allErrs, err)
+		}
+	}
+
+	if train.Labels["channel"] != old.Labels["channel"] {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("metadata", "labels").Key("channel"), train.Labels["channel"], "field is immutable"))
+	}
+	if (old.DeletionTimestamp != nil || train.DeletionTimestamp != nil) && !apiequality.Semantic.DeepEqual(train.Spec, old.Spec) {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec"), "may not be changed once the Traincrd is being deleted"))
+	}
+	return allErrs
+}
+
+func ValidateTraincrdSpec(spec *v1.TraincrdSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	// with a profile or a clone source the empty image and resources are filled in by the controller
+	withProfile := spec.Profile != "" || spec.CloneFrom != nil
+
+	if spec.Image == "" {
+		if !withProfile {
+			allErrs = append(allErrs, field.Required(fldPath.Child("image"), ""))
+		}
+	} else if !imageRegexp.MatchString(spec.Image) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("image"), spec.Image, "must be a valid image reference, e.g. registry/repo/name:tag"))
+	}
+
+	cpu, errs := validateQuantity(spec.Cpu, withProfile, fldPath.Child("cpu"))
+	allErrs = append(allErrs, errs...)
+	reqCpu, errs := validateQuantity(spec.ReqCpu, withProfile, fldPath.Child("reqcpu"))
+	allErrs = append(allErrs, errs...)
+	memory, errs := validateQuantity(spec.Memory, withProfile, fldPath.Child("memory"))
+	allErrs = append(allErrs, errs...)
+	reqMemory, errs := validateQuantity(spec.ReqMemory, withProfile, fldPath.Child("reqmemory"))
+	allErrs = append(allErrs, errs...)
+
+	if cpu != nil && reqCpu != nil && reqCpu.Cmp(*cpu) > 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("reqcpu"), spec.ReqCpu, fmt.Sprintf("must be less than or equal to cpu limit %s", spec.Cpu)))
+	}
+	if memory != nil && reqMemory != nil && reqMemory.Cmp(*memory) > 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("reqmemory"), spec.ReqMemory, fmt.Sprintf("must be less than or equal to memory limit %s", spec.Memory)))
+	}
+
+	if spec.Replicas < MinReplicas || spec.Replicas > MaxReplicas {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("replicas"), spec.Replicas, fmt.Sprintf("must be between %d and %d", MinReplicas, MaxReplicas)))
+	}
+
+	if spec.Capacity != "" {
+		if q, err := resource.ParseQuantity(spec.Capacity); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("capacity"), spec.Capacity, err.Error()))
+		} else if q.Sign() <= 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("capacity"), spec.Capacity, "must be greater than zero"))
+		}
+	}
+
+	switch spec.RetainPolicy {
+	case "", v1.RetainPolicyDelete, v1.RetainPolicyRetain, v1.RetainPolicyArchive:
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("retainPolicy"), spec.RetainPolicy,
+			[]string{string(v1.RetainPolicyDelete), string(v1.RetainPolicyRetain), string(v1.RetainPolicyArchive)}))
+	}
+
+	if spec.Template != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(spec.Template) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("template"), spec.Template, msg))
+		}
+	}
+	if spec.Profile != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(spec.Profile) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("profile"), spec.Profile, msg))
+		}
+	}
+
+	if spec.TTL != nil && spec.TTL.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("ttl"), spec.TTL.Duration.String(), "must be greater than zero"))
+	}
+	switch spec.ExpireAction {
+	case "", v1.ExpireActionSuspend, v1.ExpireActionDelete:
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("expireAction"), spec.ExpireAction,
+			[]string{string(v1.ExpireActionSuspend), string(v1.ExpireActionDelete)}))
+	}
+	if spec.Schedule != nil {
+		if _, err := cron.Parse(spec.Schedule.Start); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("schedule", "start"), spec.Schedule.Start, err.Error()))
+		}
+		if _, err := cron.Parse(spec.Schedule.Stop); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("schedule", "stop"), spec.Schedule.Stop, err.Error()))
+		}
+	}
+	if spec.RestoreFrom != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(spec.RestoreFrom) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("restoreFrom"), spec.RestoreFrom, msg))
+		}
+	}
+	if from := spec.CloneFrom; from != nil {
+		clonePath := fldPath.Child("cloneFrom")
+		if spec.RestoreFrom != "" {
+			allErrs = append(allErrs, field.Forbidden(clonePath, "may not be set together with restoreFrom"))
+		}
+		if from.Namespace != "" {
+			for _, msg := range validation.IsDNS1123Label(from.Namespace) {
+				allErrs = append(allErrs, field.Invalid(clonePath.Child("namespace"), from.Namespace, msg))
+			}
+		}
+		if from.Name == "" {
+			allErrs = append(allErrs, field.Required(clonePath.Child("name"), ""))
+		} else {
+			for _, msg := range validation.IsDNS1035Label(from.Name) {
+				allErrs = append(allErrs, field.Invalid(clonePath.Child("name"), from.Name, msg))
+			}
+		}
+	}
+	allErrs = append(allErrs, validateVolumes(spec.Volumes, fldPath.Child("volumes"))...)
+	if spec.Storage != nil && spec.Storage.Class != "" {
+		for _, msg := range validation.IsDNS1123Label(spec.Storage.Class) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("storage", "class"), spec.Storage.Class, msg))
+		}
+	}
+
+	return allErrs
+}
+
+// validateVolumes checks the shape of spec.volumes, whether a channel may mount a claim is up to the controller config
+func validateVolumes(volumes []v1.TraincrdVolume, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	names, mountPaths := map[string]bool{}, map[string]bool{}
+	for i, volume := range volumes {
+		idxPath := fldPath.Index(i)
+		if volume.Name == "" {
+			allErrs = append(allErrs, field.Required(idxPath.Child("name"), ""))
+		} else if names[volume.Name] {
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("name"), volume.Name))
+		} else {
+			for _, msg := range validation.IsDNS1123Label(volume.Name) {
+				allErrs = append(allErrs, field.Invalid(idxPath.Child("name"), volume.Name, msg))
+			}
+		}
+		names[volume.Name] = true
+
+		mountPath := path.Clean(volume.MountPath)
+		if volume.MountPath == "" {
+			allErrs = append(allErrs, field.Required(idxPath.Child("mountPath"), ""))
+		} else if !path.IsAbs(volume.MountPath) || strings.Contains(volume.MountPath, ":") {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("mountPath"), volume.MountPath, "must be an absolute path without ':'"))
+		} else if mountPaths[mountPath] {
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("mountPath"), volume.MountPath))
+		}
+		mountPaths[mountPath] = true
+
+		sources := 0
+		if claim := volume.PersistentVolumeClaim; claim != nil {
+			sources++
+			for _, msg := range validation.IsDNS1123Subdomain(claim.ClaimName) {
+				allErrs = append(allErrs, field.Invalid(idxPath.Child("persistentVolumeClaim", "claimName"), claim.ClaimName, msg))
+			}
+		}
+		if configMap := volume.ConfigMap; configMap != nil {
+			sources++
+			for _, msg := range validation.IsDNS1123Subdomain(configMap.Name) {
+				allErrs = append(allErrs, field.Invalid(idxPath.Child("configMap", "name"), configMap.Name, msg))
+			}
+		}
+		if secret := volume.Secret; secret != nil {
+			sources++
+			for _, msg := range validation.IsDNS1123Subdomain(secret.SecretName) {
+				allErrs = append(allErrs, field.Invalid(idxPath.Child("secret", "secretName"), secret.SecretName, msg))
+			}
+		}
+		if emptyDir := volume.EmptyDir; emptyDir != nil {
+			sources++
+			if emptyDir.SizeLimit != nil && emptyDir.SizeLimit.Sign() <= 0 {
+				allErrs = append(allErrs, field.Invalid(idxPath.Child("emptyDir", "sizeLimit"), emptyDir.SizeLimit.String(), "must be greater than zero"))
+			}
+		}
+		if projected := volume.Projected; projected != nil {
+			sources++
+			if len(projected.Sources) == 0 {
+				allErrs = append(allErrs, field.Required(idxPath.Child("projected", "sources"), ""))
+			}
+		}
+		if sources != 1 {
+			allErrs = append(allErrs, field.Invalid(idxPath, volume.Name, "must set exactly one of persistentVolumeClaim, configMap, secret, emptyDir or projected"))
+		}
+	}
+	return allErrs
+}
+
+func validateQuantity(value string, optional bool, fldPath *field.Path) (*resource.Quantity, field.ErrorList) {
+	if value == "" {
+		if optional {
+			return nil, nil
+		}
+		return nil, field.ErrorList{field.Required(fldPath, "")}
+	}
+	q, err := resource.ParseQuantity(value)
+	if err != nil {
+		return nil, field.ErrorList{field.Invalid(fldPath, value, err.Error())}
+	}
+	if q.Sign() <= 0 {
+		return nil, field.ErrorList{field.Invalid(fldPath, value, "must be greater than zero")}
+	}
+	return &q, nil
+}
+
+// validatePathLabel username and channel end up in the workspace path, so they must be DNS-1123 labels
+func validatePathLabel(labels map[string]string, key string, fldPath *field.Path) field.ErrorList {
+	value, ok := labels[key]
+	if !ok || value == "" {
+		return field.ErrorList{field.Required(fldPath.Key(key), "")}
+	}
+	allErrs := field.ErrorList{}
+	for _, msg := range validation.IsDNS1123Label(value) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Key(key), value, msg))
+	}
+	return allErrs
+}
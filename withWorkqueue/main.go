@@ -1,10 +1,12 @@
 package main
 
 import (
+	"flag"
 	"os"
 	"os/signal"
 	"syscall"
 
+	"finupgroup.com/decision/traincrd/pkg/clientconfig"
 	log "github.com/Sirupsen/logrus"
 	api_v1 "k8s.io/api/core/v1"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -12,17 +14,20 @@ import (
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
-	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/util/workqueue"
 )
 
-// retrieve the Kubernetes cluster client from outside of the cluster
+// retrieve the Kubernetes cluster client, in cluster or through --kubeconfig/$KUBECONFIG
 func getKubernetesClient() kubernetes.Interface {
-	// construct the path to resolve to `~/.kube/config`
-	kubeConfigPath := os.Getenv("HOME") + "/.kube/config"
+	options, err := clientconfig.NewOptions()
+	if err != nil {
+		log.Fatalf("getClusterConfig: %v", err)
+	}
+	options.AddFlags(flag.CommandLine)
+	flag.Parse()
 
-	// create the config from the path
-	config, err := clientcmd.BuildConfigFromFlags("", kubeConfigPath)
+	// create the config from the flags, falling back to the in-cluster config and ~/.kube/config
+	config, err := options.Config()
 	if err != nil {
 		log.Fatalf("getClusterConfig: %v", err)
 	}